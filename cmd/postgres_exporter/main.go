@@ -17,7 +17,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
@@ -50,6 +53,11 @@ var (
 	excludeDatabases       = kingpin.Flag("exclude-databases", "A list of databases to remove when autoDiscoverDatabases is enabled (DEPRECATED)").Default("").Envar("PG_EXPORTER_EXCLUDE_DATABASES").String()
 	includeDatabases       = kingpin.Flag("include-databases", "A list of databases to include when autoDiscoverDatabases is enabled (DEPRECATED)").Default("").Envar("PG_EXPORTER_INCLUDE_DATABASES").String()
 	metricPrefix           = kingpin.Flag("metric-prefix", "A metric prefix can be used to have non-default (not \"pg\") prefixes for each of the metrics").Default("pg").Envar("PG_EXPORTER_METRIC_PREFIX").String()
+	dbDSNs                 = kingpin.Flag("db.dsns", "Data source name of a PostgreSQL server to monitor. Repeat the flag to monitor multiple servers, the metrics of each are labeled with the server they were collected from.").Strings()
+	dbReplicaDSN           = kingpin.Flag("db.replica-dsn", "Data source name of a read replica to run the collectors which prefer a replica against. Only used when monitoring a single server.").Default("").String()
+	probeMaxScrapeTimeout  = kingpin.Flag("probe.max-scrape-timeout", "Maximum timeout of the collectors of a /probe request, the scrape_timeout parameter of the request is capped to it.").Default("60s").Duration()
+	dbConnectTimeout       = kingpin.Flag("db.connect-timeout-seconds", "Maximum time to wait for a connection to the monitored servers, set as connect_timeout of the data sources which don't set one. pg_up is 0 when the server can't be connected to within it. 0 means no timeout.").Default("0").Int()
+	dbPingQuery            = kingpin.Flag("db.ping-query", "Query run to check that the server is up, e.g. to also check the permissions of the monitoring role. pg_up is 0 when it fails.").Default("SELECT 1").String()
 	logger                 = log.NewNopLogger()
 )
 
@@ -91,6 +99,11 @@ func main() {
 		level.Error(logger).Log("msg", "Failed reading data sources", "err", err.Error())
 		os.Exit(1)
 	}
+	dsns = append(dsns, *dbDSNs...)
+	for i, dsn := range dsns {
+		dsns[i] = withConnectTimeout(dsn, *dbConnectTimeout)
+	}
+	*dbReplicaDSN = withConnectTimeout(*dbReplicaDSN, *dbConnectTimeout)
 
 	excludedDatabases := strings.Split(*excludeDatabases, ",")
 	logger.Log("msg", "Excluded databases", "databases", fmt.Sprintf("%v", excludedDatabases))
@@ -115,6 +128,8 @@ func main() {
 		WithConstantLabels(*constantLabelsList),
 		ExcludeDatabases(excludedDatabases),
 		IncludeDatabases(*includeDatabases),
+		WithPingQuery(*dbPingQuery),
+		WithConnectTimeout(time.Duration(*dbConnectTimeout) * time.Second),
 	}
 
 	exporter := NewExporter(dsns, opts...)
@@ -126,25 +141,15 @@ func main() {
 
 	prometheus.MustRegister(exporter)
 
-	// TODO(@sysadmind): Remove this with multi-target support. We are removing multiple DSN support
-	dsn := ""
-	if len(dsns) > 0 {
-		dsn = dsns[0]
-	}
+	pcs := registerPostgresCollectors(prometheus.DefaultRegisterer, dsns, excludedDatabases)
 
-	pe, err := collector.NewPostgresCollector(
-		logger,
-		excludedDatabases,
-		dsn,
-		[]string{},
-	)
-	if err != nil {
-		level.Warn(logger).Log("msg", "Failed to create PostgresCollector", "err", err.Error())
-	} else {
-		prometheus.MustRegister(pe)
-	}
+	http.Handle(*metricsPath, metricsHandler(exporter, pcs))
 
-	http.Handle(*metricsPath, promhttp.Handler())
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go reloadOnSignal(hup, func() {
+		reloadConnections(exporter.servers, pcs)
+	})
 
 	if *metricsPath != "/" && *metricsPath != "" {
 		landingConfig := web.LandingConfig{
@@ -156,6 +161,10 @@ func main() {
 					Address: *metricsPath,
 					Text:    "Metrics",
 				},
+				{
+					Address: "/config",
+					Text:    "Configuration",
+				},
 			},
 		}
 		landingPage, err := web.NewLandingPage(landingConfig)
@@ -167,6 +176,7 @@ func main() {
 	}
 
 	http.HandleFunc("/probe", handleProbe(logger, excludedDatabases))
+	http.Handle("/config", configHandler(dsns, excludedDatabases))
 
 	srv := &http.Server{}
 	if err := web.ListenAndServe(srv, webConfig, logger); err != nil {
@@ -174,3 +184,152 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// serverCollector is a PostgresCollector together with the server label its
+// metrics are registered with, if any.
+type serverCollector struct {
+	server    string
+	collector *collector.PostgresCollector
+}
+
+// register registers the collector, or only its collectors of the given
+// scope, with reg.
+func (s serverCollector) register(reg prometheus.Registerer, scope collector.Scope) {
+	pc := s.collector
+	if scope != "" {
+		pc = pc.WithScope(scope)
+	}
+	if s.server != "" {
+		reg = prometheus.WrapRegistererWith(prometheus.Labels{serverLabelName: s.server}, reg)
+	}
+	reg.MustRegister(pc)
+}
+
+// registerPostgresCollectors registers a PostgresCollector for every DSN. When
+// more than one DSN is monitored, the metrics of each collector are labeled
+// with the server they were collected from.
+func registerPostgresCollectors(reg prometheus.Registerer, dsns []string, excludedDatabases []string) []serverCollector {
+	if len(dsns) <= 1 {
+		dsn := ""
+		if len(dsns) > 0 {
+			dsn = dsns[0]
+		}
+		opts := []collector.Option{collector.WithCollectorLabels(c.GetConfig().CollectorLabels())}
+		if *dbReplicaDSN != "" {
+			opts = append(opts, collector.WithReplica(*dbReplicaDSN))
+		}
+		pe, err := collector.NewPostgresCollector(
+			logger,
+			excludedDatabases,
+			dsn,
+			[]string{},
+			opts...,
+		)
+		if err != nil {
+			level.Warn(logger).Log("msg", "Failed to create PostgresCollector", "err", err.Error())
+			return nil
+		}
+		sc := serverCollector{collector: pe}
+		sc.register(reg, "")
+		return []serverCollector{sc}
+	}
+
+	if *dbReplicaDSN != "" {
+		level.Warn(logger).Log("msg", "Ignoring the replica DSN since multiple servers are monitored")
+	}
+
+	var scs []serverCollector
+	servers := make(map[string]bool, len(dsns))
+	for _, dsn := range dsns {
+		server, err := parseFingerprint(dsn)
+		if err != nil {
+			level.Warn(logger).Log("msg", "Failed to parse DSN", "dsn", loggableDSN(dsn), "err", err.Error())
+			continue
+		}
+		// The server label is the host:port of the DSN, so a further DSN of
+		// the same server, e.g. for another database, would report the
+		// same series.
+		if servers[server] {
+			level.Warn(logger).Log("msg", "Skipping DSN of a server which is already monitored", "server", server, "dsn", loggableDSN(dsn))
+			continue
+		}
+		servers[server] = true
+
+		pe, err := collector.NewPostgresCollector(
+			log.With(logger, "server", server),
+			excludedDatabases,
+			dsn,
+			[]string{},
+			collector.WithCollectorLabels(c.GetConfig().CollectorLabels()),
+		)
+		if err != nil {
+			level.Warn(logger).Log("msg", "Failed to create PostgresCollector", "server", server, "err", err.Error())
+			continue
+		}
+		sc := serverCollector{server: server, collector: pe}
+		sc.register(reg, "")
+		scs = append(scs, sc)
+	}
+	return scs
+}
+
+// reloadOnSignal calls reload for every signal received on sig.
+func reloadOnSignal(sig <-chan os.Signal, reload func()) {
+	for range sig {
+		reload()
+	}
+}
+
+// reloadConnections reconnects to the monitored servers, e.g. after their
+// client certificate files were rotated.
+func reloadConnections(servers *Servers, scs []serverCollector) {
+	level.Info(logger).Log("msg", "Reloading database connections")
+	servers.Reload()
+	for _, sc := range scs {
+		if err := sc.collector.Reload(); err != nil {
+			level.Error(logger).Log("msg", "Failed to reload database connections", "server", sc.server, "err", err)
+		}
+	}
+}
+
+// metricsHandler serves the metrics of the default registry. With the scope
+// query parameter, e.g. /metrics?scope=global, only the collectors of that
+// scope are run, so that cheap cluster-level metrics can be scraped more often
+// than expensive per-database ones. The default metrics of the exporter are
+// considered global.
+func metricsHandler(exporter prometheus.Collector, scs []serverCollector) http.Handler {
+	defaultHandler := promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, handlerOpts()),
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("scope") {
+			defaultHandler.ServeHTTP(w, r)
+			return
+		}
+		scope, err := collector.ParseScope(r.URL.Query().Get("scope"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		if scope == collector.ScopeGlobal {
+			registry.MustRegister(exporter)
+		}
+		for _, sc := range scs {
+			sc.register(registry, scope)
+		}
+		promhttp.HandlerFor(registry, handlerOpts()).ServeHTTP(w, r)
+	})
+}
+
+// handlerOpts returns the options of the metrics handlers. The OpenMetrics
+// format is only negotiated when exemplars are enabled, since it is the only
+// format which exposes them, and it adds the _total suffix to the names of
+// the counters which lack it.
+func handlerOpts() promhttp.HandlerOpts {
+	return promhttp.HandlerOpts{
+		EnableOpenMetrics: collector.ExemplarsEnabled(),
+	}
+}
@@ -413,10 +413,13 @@ type Exporter struct {
 	includeDatabases []string
 	dsn              []string
 	userQueriesPath  string
+	pingQuery        string
+	connectTimeout   time.Duration
 	constantLabels   prometheus.Labels
 	duration         prometheus.Gauge
 	error            prometheus.Gauge
 	psqlUp           prometheus.Gauge
+	serverUp         *prometheus.Desc
 	userQueriesError *prometheus.GaugeVec
 	totalScrapes     prometheus.Counter
 
@@ -472,6 +475,20 @@ func WithUserQueriesPath(p string) ExporterOpt {
 	}
 }
 
+// WithPingQuery configures the query run to check that a server is up.
+func WithPingQuery(q string) ExporterOpt {
+	return func(e *Exporter) {
+		e.pingQuery = q
+	}
+}
+
+// WithConnectTimeout bounds the check that a server is up.
+func WithConnectTimeout(d time.Duration) ExporterOpt {
+	return func(e *Exporter) {
+		e.connectTimeout = d
+	}
+}
+
 // WithConstantLabels configures constant labels.
 func WithConstantLabels(s string) ExporterOpt {
 	return func(e *Exporter) {
@@ -517,7 +534,7 @@ func NewExporter(dsn []string, opts ...ExporterOpt) *Exporter {
 	}
 
 	e.setupInternalMetrics()
-	e.servers = NewServers(ServerWithLabels(e.constantLabels))
+	e.servers = NewServers(ServerWithLabels(e.constantLabels), ServerWithPingQuery(e.pingQuery), ServerWithConnectTimeout(e.connectTimeout))
 
 	return e
 }
@@ -550,6 +567,12 @@ func (e *Exporter) setupInternalMetrics() {
 		Help:        "Whether the last scrape of metrics from PostgreSQL was able to connect to the server (1 for yes, 0 for no).",
 		ConstLabels: e.constantLabels,
 	})
+	e.serverUp = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "up"),
+		"Whether the last scrape of metrics from PostgreSQL was able to connect to the server (1 for yes, 0 for no).",
+		[]string{serverLabelName},
+		e.constantLabels,
+	)
 	e.userQueriesError = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   namespace,
 		Subsystem:   exporter,
@@ -570,7 +593,10 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	ch <- e.duration
 	ch <- e.totalScrapes
 	ch <- e.error
-	ch <- e.psqlUp
+	// With multiple DSNs pg_up is reported per server by scrape.
+	if len(e.dsn) <= 1 {
+		ch <- e.psqlUp
+	}
 	e.userQueriesError.Collect(ch)
 }
 
@@ -674,8 +700,15 @@ func (e *Exporter) scrape(ch chan<- prometheus.Metric) {
 
 	var errorsCount int
 	var connectionErrorsCount int
+	// A server is up if any of its DSNs could be connected to.
+	serversUp := make(map[string]bool)
 
 	for _, dsn := range dsns {
+		server, err := parseFingerprint(dsn)
+		if err != nil {
+			server = loggableDSN(dsn)
+		}
+		connected := true
 		if err := e.scrapeDSN(ch, dsn); err != nil {
 			errorsCount++
 
@@ -683,7 +716,19 @@ func (e *Exporter) scrape(ch chan<- prometheus.Metric) {
 
 			if _, ok := err.(*ErrorConnectToServer); ok {
 				connectionErrorsCount++
+				connected = false
+			}
+		}
+		serversUp[server] = serversUp[server] || connected
+	}
+
+	if len(e.dsn) > 1 {
+		for server, up := range serversUp {
+			upValue := 0.0
+			if up {
+				upValue = 1
 			}
+			ch <- prometheus.MustNewConstMetric(e.serverUp, prometheus.GaugeValue, upValue, server)
 		}
 	}
 
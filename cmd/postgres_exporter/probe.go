@@ -14,8 +14,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -35,6 +39,16 @@ func handleProbe(logger log.Logger, excludeDatabases []string) http.HandlerFunc
 			http.Error(w, "target is required", http.StatusBadRequest)
 			return
 		}
+		timeout, err := probeScrapeTimeout(params, *probeMaxScrapeTimeout)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
 		var authModule config.AuthModule
 		authModuleName := params.Get("auth_module")
 		if authModuleName == "" {
@@ -59,8 +73,6 @@ func handleProbe(logger log.Logger, excludeDatabases []string) http.HandlerFunc
 			return
 		}
 
-		// TODO(@sysadmind): Timeout
-
 		tl := log.With(logger, "target", target)
 
 		registry := prometheus.NewRegistry()
@@ -75,7 +87,11 @@ func handleProbe(logger log.Logger, excludeDatabases []string) http.HandlerFunc
 			IncludeDatabases(*includeDatabases),
 		}
 
-		dsns := []string{dsn.GetConnectionString()}
+		// connect_timeout bounds the connection, e.g. to an unreachable
+		// target, beyond the context of the probe, which doesn't bound
+		// the startup of the connection.
+		connStr := withConnectTimeout(dsn.GetConnectionString(), probeConnectTimeout(timeout, *dbConnectTimeout))
+		dsns := []string{connStr}
 		exporter := NewExporter(dsns, opts...)
 		defer func() {
 			exporter.servers.Close()
@@ -83,7 +99,7 @@ func handleProbe(logger log.Logger, excludeDatabases []string) http.HandlerFunc
 		registry.MustRegister(exporter)
 
 		// Run the probe
-		pc, err := collector.NewProbeCollector(tl, excludeDatabases, registry, dsn)
+		pc, err := collector.NewProbeCollector(ctx, tl, excludeDatabases, registry, connStr, conf.CollectorLabels())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -93,15 +109,46 @@ func handleProbe(logger log.Logger, excludeDatabases []string) http.HandlerFunc
 		defer pc.Close()
 
 		// TODO(@sysadmind): Remove the registry.MustRegister() call below and instead handle the collection here. That will allow
-		// for the passing of context, handling of timeouts, and more control over the collection.
+		// for more control over the collection.
 		// The current NewProbeCollector() implementation relies on the MustNewConstMetric() call to create the metrics which is not
 		// ideal to use without the registry.MustRegister() call.
-		_ = ctx
-
-		registry.MustRegister(pc)
+		registry.MustRegister(pc.WithContext(ctx))
 
 		// TODO check success, etc
-		h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+		h := promhttp.HandlerFor(registry, handlerOpts())
 		h.ServeHTTP(w, r)
 	}
 }
+
+// probeScrapeTimeout returns the timeout requested by the scrape_timeout
+// parameter of a probe, e.g. 5s, capped to max. It is 0, no timeout, when the
+// parameter isn't set and max is 0.
+func probeScrapeTimeout(params url.Values, max time.Duration) (time.Duration, error) {
+	if !params.Has("scrape_timeout") {
+		return max, nil
+	}
+	timeout, err := time.ParseDuration(params.Get("scrape_timeout"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid scrape_timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid scrape_timeout %s: must be positive", params.Get("scrape_timeout"))
+	}
+	if max > 0 && timeout > max {
+		return max, nil
+	}
+	return timeout, nil
+}
+
+// probeConnectTimeout returns the connect_timeout in seconds of a probe with
+// timeout, which is at most max seconds unless max is 0.
+func probeConnectTimeout(timeout time.Duration, max int) int {
+	if timeout <= 0 {
+		return max
+	}
+	seconds := int(math.Ceil(timeout.Seconds()))
+	if max > 0 && max < seconds {
+		return max
+	}
+	return seconds
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/postgres_exporter/collector"
+)
+
+// resolvedConfig is the effective configuration of the exporter, after
+// flags, environment variables and their defaults have been applied.
+type resolvedConfig struct {
+	Driver            string            `json:"driver"`
+	DataSources       []string          `json:"data_sources"`
+	ReplicaDataSource string            `json:"replica_data_source,omitempty"`
+	EnabledCollectors []string          `json:"enabled_collectors"`
+	ExcludeDatabases  []string          `json:"exclude_databases"`
+	IncludeDatabases  []string          `json:"include_databases"`
+	Flags             map[string]string `json:"flags"`
+}
+
+// configHandler serves the resolved configuration as JSON. The passwords of
+// the data sources are removed, and the flags which set data sources are only
+// reported through the redacted data sources.
+func configHandler(dsns []string, excludedDatabases []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := resolvedConfig{
+			Driver:            collector.DriverName,
+			DataSources:       make([]string, 0, len(dsns)),
+			EnabledCollectors: collector.EnabledCollectors(),
+			ExcludeDatabases:  []string{},
+			IncludeDatabases:  []string{},
+			Flags:             make(map[string]string),
+		}
+		for _, dsn := range dsns {
+			cfg.DataSources = append(cfg.DataSources, redactDSN(dsn))
+		}
+		if *dbReplicaDSN != "" {
+			cfg.ReplicaDataSource = redactDSN(*dbReplicaDSN)
+		}
+		for _, datname := range excludedDatabases {
+			if datname != "" {
+				cfg.ExcludeDatabases = append(cfg.ExcludeDatabases, datname)
+			}
+		}
+		if *includeDatabases != "" {
+			cfg.IncludeDatabases = strings.Split(*includeDatabases, ",")
+		}
+		for _, f := range kingpin.CommandLine.Model().Flags {
+			if f.Name == "help" || f.Name == "version" || strings.Contains(f.Name, "dsn") {
+				continue
+			}
+			cfg.Flags[f.Name] = f.Value.String()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cfg); err != nil {
+			level.Error(logger).Log("msg", "Failed to write the configuration", "err", err)
+		}
+	})
+}
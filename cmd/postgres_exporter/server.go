@@ -14,6 +14,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"sync"
@@ -21,6 +22,7 @@ import (
 
 	"github.com/blang/semver/v4"
 	"github.com/go-kit/log/level"
+	"github.com/prometheus-community/postgres_exporter/collector"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -31,6 +33,13 @@ type Server struct {
 	labels      prometheus.Labels
 	master      bool
 	runonserver string
+	// pingQuery is run to check that the server is up. The connection is
+	// only pinged when it's empty.
+	pingQuery string
+	// connectTimeout bounds the check that the server is up, e.g. when it
+	// stops answering on an established connection. New connections are
+	// bounded by the connect_timeout of the DSN. 0 means no timeout.
+	connectTimeout time.Duration
 
 	// Last version used to calculate metric map. If mismatch on scrape,
 	// then maps are recalculated.
@@ -57,6 +66,20 @@ func ServerWithLabels(labels prometheus.Labels) ServerOpt {
 	}
 }
 
+// ServerWithPingQuery configures the query run by Ping.
+func ServerWithPingQuery(q string) ServerOpt {
+	return func(s *Server) {
+		s.pingQuery = q
+	}
+}
+
+// ServerWithConnectTimeout configures the timeout of Ping.
+func ServerWithConnectTimeout(d time.Duration) ServerOpt {
+	return func(s *Server) {
+		s.connectTimeout = d
+	}
+}
+
 // NewServer establishes a new connection using DSN.
 func NewServer(dsn string, opts ...ServerOpt) (*Server, error) {
 	fingerprint, err := parseFingerprint(dsn)
@@ -64,7 +87,7 @@ func NewServer(dsn string, opts ...ServerOpt) (*Server, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := collector.OpenDB(dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +119,7 @@ func (s *Server) Close() error {
 
 // Ping checks connection availability and possibly invalidates the connection if it fails.
 func (s *Server) Ping() error {
-	if err := s.db.Ping(); err != nil {
+	if err := s.ping(); err != nil {
 		if cerr := s.Close(); cerr != nil {
 			level.Error(logger).Log("msg", "Error while closing non-pinging DB connection", "server", s, "err", cerr)
 		}
@@ -105,6 +128,26 @@ func (s *Server) Ping() error {
 	return nil
 }
 
+func (s *Server) ping() error {
+	ctx := context.Background()
+	if s.connectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.connectTimeout)
+		defer cancel()
+	}
+	if s.pingQuery == "" {
+		return s.db.PingContext(ctx)
+	}
+	rows, err := s.db.QueryContext(ctx, s.pingQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	// Errors of the query can also be reported with its first row.
+	rows.Next()
+	return rows.Err()
+}
+
 // String returns server's fingerprint.
 func (s *Server) String() string {
 	return s.labels[serverLabelName]
@@ -178,6 +221,24 @@ func (s *Servers) GetServer(dsn string) (*Server, error) {
 	return server, nil
 }
 
+// Reload disconnects from all known servers, so that they are reconnected to
+// with the current client certificate files on the next scrape. Servers whose
+// client certificate and key don't load stay connected.
+func (s *Servers) Reload() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for dsn, server := range s.servers {
+		if err := collector.ValidateClientCertificate(dsn); err != nil {
+			level.Error(logger).Log("msg", "Keeping connection after failing to load the client certificate", "server", server, "err", err)
+			continue
+		}
+		if err := server.Close(); err != nil {
+			level.Error(logger).Log("msg", "Failed to close connection", "server", server, "err", err)
+		}
+		delete(s.servers, dsn)
+	}
+}
+
 // Close disconnects from all known servers.
 func (s *Servers) Close() {
 	s.m.Lock()
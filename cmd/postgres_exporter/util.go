@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"math"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -214,8 +215,65 @@ func loggableDSN(dsn string) string {
 	}
 	// Blank user info if not nil
 	if pDSN.User != nil {
-		pDSN.User = url.UserPassword(pDSN.User.Username(), "PASSWORD_REMOVED")
+		pDSN.User = url.UserPassword(pDSN.User.Username(), passwordRemoved)
 	}
 
 	return pDSN.String()
 }
+
+var dsnConnectTimeoutRe = regexp.MustCompile(`\bconnect_timeout\s*=`)
+
+// withConnectTimeout sets connect_timeout to seconds in a DSN which doesn't
+// set it already. The DSN is returned unchanged when seconds is 0.
+func withConnectTimeout(dsn string, seconds int) string {
+	if dsn == "" || seconds <= 0 {
+		return dsn
+	}
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		if dsnConnectTimeoutRe.MatchString(dsn) {
+			return dsn
+		}
+		return fmt.Sprintf("%s connect_timeout=%d", dsn, seconds)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	q := u.Query()
+	if !q.Has("connect_timeout") {
+		q.Set("connect_timeout", strconv.Itoa(seconds))
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+const passwordRemoved = "PASSWORD_REMOVED"
+
+var dsnPasswordRe = regexp.MustCompile(`\b((?:ssl)?password)\s*=\s*(?:'(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactDSN removes the passwords from a DSN, which unlike loggableDSN also
+// handles key/value connection strings and passwords in URL parameters.
+func redactDSN(dsn string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsnPasswordRe.ReplaceAllString(dsn, "${1}="+passwordRemoved)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "could not parse DSN"
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), passwordRemoved)
+		}
+	}
+	q := u.Query()
+	for _, param := range []string{"password", "sslpassword"} {
+		if q.Has(param) {
+			q.Set(param, passwordRemoved)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -17,13 +17,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 var (
@@ -31,7 +35,15 @@ var (
 	initiatedCollectorsMtx = sync.Mutex{}
 	initiatedCollectors    = make(map[string]Collector)
 	collectorState         = make(map[string]*bool)
-	forcedCollectors       = map[string]bool{} // collectors which have been explicitly enabled or disabled
+	collectorScopes        = make(map[string]Scope)
+	collectorPreferReplica = make(map[string]bool)
+	collectorIntervals     = make(map[string]*time.Duration)
+	collectorFiles         = make(map[string]string) // source files the collectors are registered in
+	forcedCollectors       = map[string]bool{}       // collectors which have been explicitly enabled or disabled
+	emitNullAsZero         = kingpin.Flag("collector.emit-null-as-zero", "Emit 0 for metrics whose value is NULL. When disabled, such metrics are omitted.").Default("true").Bool()
+	failFast               = kingpin.Flag("collector.fail-fast", "Return from a collector on its first failing query. When disabled, collectors which run several independent queries still emit the metrics of the queries which succeeded.").Default("false").Bool()
+	softErrors             = kingpin.Flag("collector.soft-errors", "SQLSTATE of errors which skip a collector instead of failing it, e.g. errors of functions which aren't allowed on a standby. Repeat the flag for multiple SQLSTATEs.").Default("25006", "57P03").Strings()
+	maxSeriesPerScrape     = kingpin.Flag("max-series-per-scrape", "Maximum number of series emitted by the collectors per scrape, further series are dropped. 0 means no limit.").Default("0").Int()
 )
 
 const (
@@ -55,8 +67,47 @@ var (
 		[]string{"collector"},
 		nil,
 	)
+	scrapeSkippedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_skipped"),
+		"postgres_exporter: Whether a collector was skipped on an error listed in --collector.soft-errors.",
+		[]string{"collector"},
+		nil,
+	)
+	scrapeLastSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "collector_last_success_timestamp_seconds"),
+		"postgres_exporter: Unix timestamp of the last successful scrape of a collector.",
+		[]string{"collector"},
+		nil,
+	)
+	seriesLimitExceededDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "series_limit_exceeded"),
+		"postgres_exporter: Whether the last scrape exceeded --max-series-per-scrape and series were dropped.",
+		nil,
+		nil,
+	)
+)
+
+// Scope describes what a collector reports on, so that cheap cluster-level
+// collectors can be scraped separately from expensive per-database ones.
+type Scope string
+
+const (
+	// ScopeGlobal collectors report on the cluster as a whole.
+	ScopeGlobal Scope = "global"
+	// ScopeDatabase collectors report on the objects of the database the
+	// exporter is connected to, such as tables and indexes.
+	ScopeDatabase Scope = "database"
 )
 
+// ParseScope returns the Scope named s.
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeGlobal, ScopeDatabase:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown scope: %s", s)
+}
+
 type Collector interface {
 	Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error
 }
@@ -64,9 +115,13 @@ type Collector interface {
 type collectorConfig struct {
 	logger           log.Logger
 	excludeDatabases []string
+	// omitNull skips metrics whose value is NULL instead of reporting 0.
+	omitNull bool
+	// failFast returns on the first failing query of a collector.
+	failFast bool
 }
 
-func registerCollector(name string, isDefaultEnabled bool, createFunc func(collectorConfig) (Collector, error)) {
+func registerCollector(name string, isDefaultEnabled bool, scope Scope, createFunc func(collectorConfig) (Collector, error)) {
 	var helpDefaultState string
 	if isDefaultEnabled {
 		helpDefaultState = "enabled"
@@ -81,25 +136,84 @@ func registerCollector(name string, isDefaultEnabled bool, createFunc func(colle
 
 	flag := kingpin.Flag(flagName, flagHelp).Default(defaultValue).Action(collectorFlagAction(name)).Bool()
 	collectorState[name] = flag
+	collectorScopes[name] = scope
+	collectorFiles[name] = callerFile()
+
+	intervalHelp := fmt.Sprintf("Run the %s collector every interval instead of on every scrape, and serve the metrics of the last run. 0 runs it on every scrape.", name)
+	collectorIntervals[name] = kingpin.Flag(flagName+".interval", intervalHelp).Default("0s").Duration()
 
 	// Register the create function for this collector
 	factories[name] = createFunc
 }
 
+// preferReplica marks the named collector as safe to run against a read
+// replica, i.e. it only reads data which is the same on the replica as on the
+// primary, such as catalogs and object sizes. Statistics views must not be
+// read from a replica since they reflect the replica's own activity.
+func preferReplica(name string) {
+	collectorPreferReplica[name] = true
+}
+
+// EnabledCollectors returns the sorted names of the enabled collectors.
+func EnabledCollectors() []string {
+	var names []string
+	for name, enabled := range collectorState {
+		if *enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // PostgresCollector implements the prometheus.Collector interface.
 type PostgresCollector struct {
 	Collectors map[string]Collector
 	logger     log.Logger
 
 	instance *instance
+	// replica is used instead of instance by the collectors which prefer a
+	// replica, if configured.
+	replica *instance
+	// maxSeries limits the number of series emitted per scrape, 0 means no limit.
+	maxSeries int
+	// collectorLabels are static labels added to the metrics of the collector
+	// of the same name.
+	collectorLabels map[string]map[string]string
+	// stop stops the collectors which run on their own interval.
+	stop context.CancelFunc
 }
 
 type Option func(*PostgresCollector) error
 
+// WithReplica runs the collectors which prefer a replica against the server
+// at dsn. While the replica can't be reached, the primary is used instead.
+func WithReplica(dsn string) Option {
+	return func(p *PostgresCollector) error {
+		replica, err := openInstance(dsn)
+		if err != nil {
+			return err
+		}
+		p.replica = replica
+		return nil
+	}
+}
+
+// WithCollectorLabels adds static labels to the metrics of the named
+// collectors only, e.g. a role label to the metrics of the replication
+// collector.
+func WithCollectorLabels(labels map[string]map[string]string) Option {
+	return func(p *PostgresCollector) error {
+		p.collectorLabels = labels
+		return nil
+	}
+}
+
 // NewPostgresCollector creates a new PostgresCollector.
 func NewPostgresCollector(logger log.Logger, excludeDatabases []string, dsn string, filters []string, options ...Option) (*PostgresCollector, error) {
 	p := &PostgresCollector{
-		logger: logger,
+		logger:    logger,
+		maxSeries: *maxSeriesPerScrape,
 	}
 	// Apply options to customize the collector
 	for _, o := range options {
@@ -133,6 +247,8 @@ func NewPostgresCollector(logger log.Logger, excludeDatabases []string, dsn stri
 			collector, err := factories[key](collectorConfig{
 				logger:           log.With(logger, "collector", key),
 				excludeDatabases: excludeDatabases,
+				omitNull:         !*emitNullAsZero,
+				failFast:         *failFast,
 			})
 			if err != nil {
 				return nil, err
@@ -142,60 +258,472 @@ func NewPostgresCollector(logger log.Logger, excludeDatabases []string, dsn stri
 		}
 	}
 
+	if *metricsVerboseHelp {
+		verboseHelpCollectors(collectors)
+	}
+	if err := labelCollectors(collectors, p.collectorLabels); err != nil {
+		return nil, err
+	}
 	p.Collectors = collectors
 
 	if dsn == "" {
 		return nil, errors.New("empty dsn")
 	}
 
-	instance, err := newInstance(dsn)
+	// The server is connected to on the first scrape, and retried on the
+	// following ones while it can't be reached.
+	instance, err := openInstance(dsn)
 	if err != nil {
 		return nil, err
 	}
 	p.instance = instance
 
+	ctx, stop := context.WithCancel(context.Background())
+	p.stop = stop
+	for name, c := range p.Collectors {
+		if interval := collectorIntervals[name]; interval != nil && *interval > 0 {
+			sc := newScheduledCollector(c)
+			go p.runScheduled(ctx, name, sc, *interval)
+			p.Collectors[name] = sc
+		}
+	}
+
 	return p, nil
 }
 
+// Close stops the collectors which run on their own interval and closes the
+// database connections.
+func (p *PostgresCollector) Close() error {
+	if p.stop != nil {
+		p.stop()
+	}
+	if p.replica != nil {
+		p.replica.Close()
+	}
+	return p.instance.Close()
+}
+
+// Reload reconnects to the server and the replica, so that rotated client
+// certificate files are used. The existing connections are kept if the new
+// client certificate and key don't load.
+func (p *PostgresCollector) Reload() error {
+	if err := p.instance.reload(); err != nil {
+		return err
+	}
+	if p.replica != nil {
+		return p.replica.reload()
+	}
+	return nil
+}
+
+// runScheduled runs the named collector every interval until ctx is done.
+func (p *PostgresCollector) runScheduled(ctx context.Context, name string, sc *scheduledCollector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	sc.run(ctx, ticker.C, func() *instance {
+		// The replica isn't checked again, the check of the last scrape
+		// is used.
+		if p.replica != nil && collectorPreferReplica[name] && p.replica.available.Load() {
+			return p.replica
+		}
+		return p.instance
+	})
+}
+
+// WithScope returns a copy of the PostgresCollector which only runs the
+// collectors of the given scope. The copy shares the database connection.
+func (p *PostgresCollector) WithScope(scope Scope) *PostgresCollector {
+	collectors := make(map[string]Collector)
+	for name, c := range p.Collectors {
+		if collectorScopes[name] == scope {
+			collectors[name] = c
+		}
+	}
+	return &PostgresCollector{
+		Collectors: collectors,
+		logger:     p.logger,
+		instance:   p.instance,
+		replica:    p.replica,
+		maxSeries:  p.maxSeries,
+	}
+}
+
 // Describe implements the prometheus.Collector interface.
 func (p PostgresCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- scrapeDurationDesc
 	ch <- scrapeSuccessDesc
+	ch <- scrapeSkippedDesc
+	ch <- scrapeLastSuccessDesc
+	if p.maxSeries > 0 {
+		ch <- seriesLimitExceededDesc
+	}
 }
 
 // Collect implements the prometheus.Collector interface.
 func (p PostgresCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx := context.TODO()
+
+	if err := p.instance.setup(ctx); err != nil {
+		level.Error(p.logger).Log("msg", "Failed to connect to the server", "err", err)
+		p.collectFailed(ch)
+		return
+	}
+
+	out := ch
+	var limiter *seriesLimiter
+	if p.maxSeries > 0 {
+		limiter = newSeriesLimiter(ch, p.maxSeries)
+		out = limiter.in
+	}
+
+	replica := p.availableReplica(ctx)
+
 	wg := sync.WaitGroup{}
 	wg.Add(len(p.Collectors))
 	for name, c := range p.Collectors {
-		go func(name string, c Collector) {
-			execute(ctx, name, c, p.instance, ch, p.logger)
+		inst := p.instance
+		if replica != nil && collectorPreferReplica[name] {
+			inst = replica
+		}
+		go func(name string, c Collector, inst *instance) {
+			execute(ctx, name, c, inst, out, ch, p.logger)
 			wg.Done()
-		}(name, c)
+		}(name, c, inst)
 	}
 	wg.Wait()
+
+	if limiter != nil {
+		exceeded := 0.0
+		if limiter.close() {
+			level.Warn(p.logger).Log("msg", "Scrape exceeded the series limit, further series were dropped", "limit", p.maxSeries)
+			exceeded = 1
+		}
+		ch <- prometheus.MustNewConstMetric(seriesLimitExceededDesc, prometheus.GaugeValue, exceeded)
+	}
+}
+
+// availableReplica returns the replica if it can be reached, or nil. The
+// replica is only checked if one of the collectors prefers it, once per
+// scrape, and is connected to on the first scrape it can be reached on.
+func (p PostgresCollector) availableReplica(ctx context.Context) *instance {
+	if p.replica == nil {
+		return nil
+	}
+	prefer := false
+	for name := range p.Collectors {
+		if collectorPreferReplica[name] {
+			prefer = true
+			break
+		}
+	}
+	if !prefer {
+		return nil
+	}
+
+	err := p.replica.setup(ctx)
+	if err == nil {
+		err = p.replica.getDB().PingContext(ctx)
+	}
+	p.replica.available.Store(err == nil)
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "Replica is unavailable, using the primary for all collectors", "err", err)
+		return nil
+	}
+	return p.replica
+}
+
+// collectFailed reports all collectors as failed, while the server hasn't
+// been connected to yet.
+func (p PostgresCollector) collectFailed(ch chan<- prometheus.Metric) {
+	for name := range p.Collectors {
+		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, 0, name)
+		ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, 0, name)
+		ch <- prometheus.MustNewConstMetric(scrapeSkippedDesc, prometheus.GaugeValue, 0, name)
+	}
+	if p.maxSeries > 0 {
+		ch <- prometheus.MustNewConstMetric(seriesLimitExceededDesc, prometheus.GaugeValue, 0)
+	}
+}
+
+// ValidateCollectorLabels returns an error if static labels are configured
+// for a missing collector, or collide with a label of one of the metrics of
+// their collector.
+func ValidateCollectorLabels(labels map[string]map[string]string) error {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := collectorState[name]; !ok {
+			return fmt.Errorf("labels for missing collector: %s", name)
+		}
+		own := fileLabels[collectorFiles[name]]
+		for label := range labels[name] {
+			if own[label] {
+				return fmt.Errorf("configured label %q collides with a label of the %s collector", label, name)
+			}
+		}
+	}
+	return nil
 }
 
-func execute(ctx context.Context, name string, c Collector, instance *instance, ch chan<- prometheus.Metric, logger log.Logger) {
+// labelCollectors wraps the collectors which have static labels, so that the
+// labels are added to their metrics. The cached collectors are left as is.
+func labelCollectors(collectors map[string]Collector, labels map[string]map[string]string) error {
+	if err := ValidateCollectorLabels(labels); err != nil {
+		return err
+	}
+	for name, l := range labels {
+		if c, ok := collectors[name]; ok && len(l) > 0 {
+			collectors[name] = labeledCollector{collector: c, labels: l}
+		}
+	}
+	return nil
+}
+
+// labeledCollector adds static labels to the metrics of a collector. Metrics
+// which already have one of the labels are dropped and the collector fails,
+// since a static label must not replace a label of the collector.
+type labeledCollector struct {
+	collector Collector
+	labels    prometheus.Labels
+}
+
+func (l labeledCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	var err, collision error
+	update := collectFunc(func(out chan<- prometheus.Metric) {
+		in := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for m := range in {
+				if err := l.checkLabels(m); err != nil {
+					collision = err
+					continue
+				}
+				out <- m
+			}
+		}()
+		err = l.collector.Update(ctx, instance, in)
+		close(in)
+		<-done
+	})
+
+	// The wrapping registerer adds the labels to the metrics, including their
+	// descriptors.
+	var reg collectorRegisterer
+	prometheus.WrapRegistererWith(l.labels, &reg).MustRegister(update)
+	reg.collector.Collect(ch)
+	if err != nil {
+		return err
+	}
+	return collision
+}
+
+// checkLabels returns an error if m already has one of the static labels.
+func (l labeledCollector) checkLabels(m prometheus.Metric) error {
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return err
+	}
+	for _, label := range pb.GetLabel() {
+		if _, ok := l.labels[label.GetName()]; ok {
+			return fmt.Errorf("configured label %q collides with a label of %s", label.GetName(), m.Desc())
+		}
+	}
+	return nil
+}
+
+// collectFunc is a prometheus.Collector which collects by calling itself.
+type collectFunc func(ch chan<- prometheus.Metric)
+
+func (f collectFunc) Describe(ch chan<- *prometheus.Desc) {}
+
+func (f collectFunc) Collect(ch chan<- prometheus.Metric) {
+	f(ch)
+}
+
+// collectorRegisterer keeps the last collector registered with it.
+type collectorRegisterer struct {
+	collector prometheus.Collector
+}
+
+func (r *collectorRegisterer) Register(c prometheus.Collector) error {
+	r.collector = c
+	return nil
+}
+
+func (r *collectorRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		r.collector = c
+	}
+}
+
+func (r *collectorRegisterer) Unregister(c prometheus.Collector) bool {
+	return false
+}
+
+// scheduledCollector runs a collector on its own schedule, decoupled from the
+// scrapes, and serves the metrics of its last run, so that slowly changing
+// data isn't queried on every scrape.
+type scheduledCollector struct {
+	collector Collector
+
+	mtx     sync.Mutex
+	metrics []prometheus.Metric
+	err     error
+	// lastSuccess is the time the last successful run completed, the
+	// scrapes serving the cached metrics don't advance it.
+	lastSuccess time.Time
+}
+
+func newScheduledCollector(c Collector) *scheduledCollector {
+	// Until the first run completes there is nothing to serve.
+	return &scheduledCollector{collector: c, err: ErrNoData}
+}
+
+// run runs the collector immediately and on every tick until ctx is done.
+func (s *scheduledCollector) run(ctx context.Context, tick <-chan time.Time, inst func() *instance) {
+	for {
+		s.update(ctx, inst())
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		}
+	}
+}
+
+// update runs the collector and caches its metrics.
+func (s *scheduledCollector) update(ctx context.Context, inst *instance) {
+	ch := make(chan prometheus.Metric)
+	var metrics []prometheus.Metric
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+	}()
+	err := inst.setup(ctx)
+	if err == nil {
+		err = s.collector.Update(ctx, inst, ch)
+	}
+	close(ch)
+	<-done
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.metrics, s.err = metrics, err
+	if err == nil {
+		s.lastSuccess = time.Now()
+	}
+}
+
+// getLastSuccess returns the time the collector last ran successfully, if it
+// ever did.
+func (s *scheduledCollector) getLastSuccess() (time.Time, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.lastSuccess, !s.lastSuccess.IsZero()
+}
+
+// Update sends the metrics of the last run and returns its error.
+func (s *scheduledCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	s.mtx.Lock()
+	metrics, err := s.metrics, s.err
+	s.mtx.Unlock()
+
+	for _, m := range metrics {
+		ch <- m
+	}
+	return err
+}
+
+// seriesLimiter forwards the metrics sent on in to out until limit metrics
+// have been forwarded, and drops the rest.
+type seriesLimiter struct {
+	in       chan prometheus.Metric
+	done     chan struct{}
+	exceeded bool
+}
+
+func newSeriesLimiter(out chan<- prometheus.Metric, limit int) *seriesLimiter {
+	l := &seriesLimiter{
+		in:   make(chan prometheus.Metric),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(l.done)
+		sent := 0
+		for m := range l.in {
+			if sent >= limit {
+				l.exceeded = true
+				continue
+			}
+			out <- m
+			sent++
+		}
+	}()
+	return l
+}
+
+// close stops the limiter once all metrics have been sent and reports whether
+// any were dropped.
+func (l *seriesLimiter) close() bool {
+	close(l.in)
+	<-l.done
+	return l.exceeded
+}
+
+// execute runs the collector, which sends its metrics to out, and sends the
+// metrics of the scrape of the collector to ch. They aren't counted against
+// the series limit, so that the health of the exporter is always reported.
+func execute(ctx context.Context, name string, c Collector, instance *instance, out, ch chan<- prometheus.Metric, logger log.Logger) {
 	begin := time.Now()
-	err := c.Update(ctx, instance, ch)
+	err := c.Update(ctx, instance, out)
 	duration := time.Since(begin)
-	var success float64
+	var success, skipped float64
 
 	if err != nil {
-		if IsNoDataError(err) {
+		switch {
+		case IsNoDataError(err):
 			level.Debug(logger).Log("msg", "collector returned no data", "name", name, "duration_seconds", duration.Seconds(), "err", err)
-		} else {
+			success = 0
+		case isSoftError(err, *softErrors):
+			// The collector didn't collect anything, so it didn't succeed,
+			// but it isn't logged as failed.
+			level.Debug(logger).Log("msg", "collector skipped on a soft error", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			success = 0
+			skipped = 1
+		default:
 			level.Error(logger).Log("msg", "collector failed", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			success = 0
 		}
-		success = 0
 	} else {
 		level.Debug(logger).Log("msg", "collector succeeded", "name", name, "duration_seconds", duration.Seconds())
 		success = 1
 	}
+
+	// A scheduled collector serves cached metrics, its last success is the
+	// last successful run on its own schedule.
+	var lastSuccess time.Time
+	var ok bool
+	if sc, scheduled := c.(*scheduledCollector); scheduled {
+		lastSuccess, ok = sc.getLastSuccess()
+	} else {
+		if err == nil {
+			instance.setLastSuccess(name, begin.Add(duration))
+		}
+		lastSuccess, ok = instance.getLastSuccess(name)
+	}
 	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
 	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+	ch <- prometheus.MustNewConstMetric(scrapeSkippedDesc, prometheus.GaugeValue, skipped, name)
+	if ok {
+		ch <- prometheus.MustNewConstMetric(scrapeLastSuccessDesc, prometheus.GaugeValue, float64(lastSuccess.UnixNano())/1e9, name)
+	}
 }
 
 // collectorFlagAction generates a new action function for the given collector
@@ -216,3 +744,95 @@ var ErrNoData = errors.New("collector returned no data")
 func IsNoDataError(err error) bool {
 	return err == ErrNoData
 }
+
+// isUndefinedTable reports whether err is a PostgreSQL undefined_table error.
+func isUndefinedTable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "42P01"
+}
+
+// isUndefinedFunction reports whether err is a PostgreSQL undefined_function error.
+func isUndefinedFunction(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "42883"
+}
+
+// isUndefinedFile reports whether err is a PostgreSQL undefined_file error.
+func isUndefinedFile(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "58P01"
+}
+
+// isSoftError reports whether err is a PostgreSQL error with one of the given
+// SQLSTATEs. The errors of a collector which ran several queries are only
+// soft if all of them are.
+func isSoftError(err error, codes []string) bool {
+	if errs, ok := err.(multiError); ok {
+		for _, err := range errs {
+			if !isSoftError(err, codes) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	for _, code := range codes {
+		if string(pqErr.Code) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isPermissionDenied reports whether err is a PostgreSQL insufficient_privilege error.
+func isPermissionDenied(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "42501"
+}
+
+// updateErrors collects the errors of the independent queries of a
+// collector, so that a failing query doesn't prevent the metrics of the other
+// queries from being emitted. In fail-fast mode the collector stops on the
+// first error.
+type updateErrors struct {
+	failFast bool
+	errs     []error
+}
+
+// add records err, if any, and reports whether the collector should stop.
+func (e *updateErrors) add(err error) bool {
+	if err == nil {
+		return false
+	}
+	e.errs = append(e.errs, err)
+	return e.failFast
+}
+
+// err returns the recorded errors combined into one, or nil.
+func (e *updateErrors) err() error {
+	switch len(e.errs) {
+	case 0:
+		return nil
+	case 1:
+		return e.errs[0]
+	}
+	return multiError(e.errs)
+}
+
+// multiError is the combination of several errors.
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m multiError) Unwrap() []error {
+	return m
+}
@@ -13,10 +13,21 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
 )
 
 type labelMap map[string]string
@@ -55,7 +66,627 @@ func sanitizeQuery(q string) string {
 	q = strings.Replace(q, "{", "\\{", -1)
 	q = strings.Replace(q, "}", "\\}", -1)
 	q = strings.Replace(q, "*", "\\*", -1)
+	q = strings.Replace(q, "+", "\\+", -1)
 	q = strings.Replace(q, "^", "\\^", -1)
 	q = strings.Replace(q, "$", "\\$", -1)
 	return q
 }
+
+func TestPostgresCollectorServerLabel(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	for _, server := range []string{"db1:5432", "db2:5432"} {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Error opening a stub db connection: %s", err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(pgWALQuery)).WillReturnRows(
+			sqlmock.NewRows([]string{"segments", "size"}).AddRow(47, 788529152))
+
+		p := &PostgresCollector{
+			Collectors: map[string]Collector{walSubsystem: PGWALCollector{}},
+			logger:     log.NewNopLogger(),
+			instance:   &instance{db: db},
+		}
+		prometheus.WrapRegistererWith(prometheus.Labels{"server": server}, reg).MustRegister(p)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Error gathering metrics: %s", err)
+	}
+
+	servers := map[string][]string{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "server" {
+					servers[mf.GetName()] = append(servers[mf.GetName()], l.GetValue())
+				}
+			}
+		}
+	}
+
+	convey.Convey("Metrics are labeled per server", t, func() {
+		convey.So(servers["pg_wal_segments"], convey.ShouldResemble, []string{"db1:5432", "db2:5432"})
+		convey.So(servers["pg_wal_size_bytes"], convey.ShouldResemble, []string{"db1:5432", "db2:5432"})
+		convey.So(servers["pg_scrape_collector_success"], convey.ShouldResemble, []string{"db1:5432", "db2:5432"})
+	})
+}
+
+func TestPostgresCollectorConnectsLater(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	// The server is down on the first scrape and up on the second.
+	mock.ExpectQuery(sanitizeQuery("SELECT version();")).WillReturnError(errors.New("connection refused"))
+	mock.ExpectQuery(sanitizeQuery("SELECT version();")).WillReturnRows(
+		sqlmock.NewRows([]string{"version"}).AddRow("PostgreSQL 16.1 on x86_64-pc-linux-gnu"))
+	mock.ExpectQuery(sanitizeQuery(pgWALQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"segments", "size"}).AddRow(47, 788529152))
+
+	p := PostgresCollector{
+		Collectors: map[string]Collector{walSubsystem: PGWALCollector{}},
+		logger:     log.NewNopLogger(),
+		instance:   &instance{db: db, pending: true},
+	}
+
+	success := func() float64 {
+		var value float64
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			p.Collect(ch)
+		}()
+		for m := range ch {
+			if m.Desc() == scrapeSuccessDesc {
+				value = readMetric(m).value
+			}
+		}
+		return value
+	}
+
+	convey.Convey("The server is connected to once it is up", t, func() {
+		convey.So(success(), convey.ShouldEqual, 0)
+		convey.So(success(), convey.ShouldEqual, 1)
+		convey.So(p.instance.version.String(), convey.ShouldEqual, "16.1.0")
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPostgresCollectorWithScope(t *testing.T) {
+	p := &PostgresCollector{
+		Collectors: map[string]Collector{
+			bgWriterSubsystem:        PGStatBGWriterCollector{},
+			walSubsystem:             PGWALCollector{},
+			replicationSubsystem:     &PGReplicationCollector{},
+			userTableSubsystem:       &PGStatUserTablesCollector{},
+			statioUserTableSubsystem: PGStatIOUserTablesCollector{},
+		},
+		logger: log.NewNopLogger(),
+	}
+
+	names := func(p *PostgresCollector) []string {
+		var names []string
+		for name := range p.Collectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	convey.Convey("Collectors are filtered by scope", t, func() {
+		convey.So(names(p.WithScope(ScopeGlobal)), convey.ShouldResemble, []string{replicationSubsystem, bgWriterSubsystem, walSubsystem})
+		convey.So(names(p.WithScope(ScopeDatabase)), convey.ShouldResemble, []string{userTableSubsystem, statioUserTableSubsystem})
+		convey.So(p.Collectors, convey.ShouldHaveLength, 5)
+	})
+
+	convey.Convey("Scopes are parsed", t, func() {
+		scope, err := ParseScope("global")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(scope, convey.ShouldEqual, ScopeGlobal)
+
+		_, err = ParseScope("tables")
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
+
+func TestPostgresCollectorCollectorLabels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(pgWALQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"segments", "size"}).AddRow(47, 788529152))
+
+	collectors := map[string]Collector{
+		walSubsystem: PGWALCollector{},
+		"series":     seriesCollector{series: 1},
+	}
+	if err := labelCollectors(collectors, map[string]map[string]string{walSubsystem: {"role": "primary"}}); err != nil {
+		t.Fatalf("Error labeling collectors: %s", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(&PostgresCollector{
+		Collectors: collectors,
+		logger:     log.NewNopLogger(),
+		instance:   &instance{db: db},
+	})
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Error gathering metrics: %s", err)
+	}
+
+	roles := map[string][]string{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			role := ""
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "role" {
+					role = l.GetValue()
+				}
+			}
+			roles[mf.GetName()] = append(roles[mf.GetName()], role)
+		}
+	}
+
+	convey.Convey("Only the metrics of the labeled collector have the label", t, func() {
+		convey.So(roles["pg_wal_segments"], convey.ShouldResemble, []string{"primary"})
+		convey.So(roles["pg_wal_size_bytes"], convey.ShouldResemble, []string{"primary"})
+		convey.So(roles["pg_parallel_workers_active"], convey.ShouldResemble, []string{""})
+		convey.So(roles["pg_scrape_collector_success"], convey.ShouldResemble, []string{"", ""})
+	})
+
+	convey.Convey("Labels of missing collectors are rejected", t, func() {
+		err := labelCollectors(map[string]Collector{}, map[string]map[string]string{"replication_lag": {"role": "primary"}})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Labels colliding with a label of the collector are rejected", t, func() {
+		err := ValidateCollectorLabels(map[string]map[string]string{databaseSubsystem: {"datname": "postgres"}})
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(ValidateCollectorLabels(map[string]map[string]string{databaseSubsystem: {"role": "primary"}}), convey.ShouldBeNil)
+
+		collectors := map[string]Collector{databaseSubsystem: PGDatabaseCollector{}}
+		err = labelCollectors(collectors, map[string]map[string]string{databaseSubsystem: {"datname": "postgres"}})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
+
+// metricCollector emits the given metrics.
+type metricCollector struct {
+	metrics []prometheus.Metric
+}
+
+func (c metricCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+	return nil
+}
+
+func TestLabeledCollectorCollision(t *testing.T) {
+	c := labeledCollector{
+		collector: metricCollector{metrics: []prometheus.Metric{
+			prometheus.MustNewConstMetric(pgToastSizeBytes, prometheus.GaugeValue, 8192, "app", "public", "users"),
+			prometheus.MustNewConstMetric(pgParallelWorkersActive, prometheus.GaugeValue, 1),
+		}},
+		labels: prometheus.Labels{"datname": "primary"},
+	}
+
+	ch := make(chan prometheus.Metric)
+	var err error
+	go func() {
+		defer close(ch)
+		err = c.Update(context.Background(), &instance{}, ch)
+	}()
+
+	var metrics []MetricResult
+	for m := range ch {
+		metrics = append(metrics, readMetric(m))
+	}
+
+	convey.Convey("Metrics with a colliding label are dropped", t, func() {
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(metrics, convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{"datname": "primary"}, metricType: dto.MetricType_GAUGE, value: 1},
+		})
+	})
+}
+
+// seriesCollector emits the given number of series.
+type seriesCollector struct {
+	series int
+}
+
+func (c seriesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	for i := 0; i < c.series; i++ {
+		ch <- prometheus.MustNewConstMetric(pgParallelWorkersActive, prometheus.GaugeValue, 1)
+	}
+	return nil
+}
+
+func TestPostgresCollectorSeriesLimit(t *testing.T) {
+	p := PostgresCollector{
+		Collectors: map[string]Collector{"series": seriesCollector{series: 10}},
+		logger:     log.NewNopLogger(),
+		instance:   &instance{},
+		maxSeries:  4,
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		p.Collect(ch)
+	}()
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	// The metrics of the scrape of the collector aren't counted against the
+	// limit.
+	counts := make(map[*prometheus.Desc]int)
+	for _, m := range metrics {
+		counts[m.Desc()]++
+	}
+
+	convey.Convey("Emission stops at the limit", t, func() {
+		convey.So(counts, convey.ShouldResemble, map[*prometheus.Desc]int{
+			pgParallelWorkersActive: 4,
+			scrapeDurationDesc:      1,
+			scrapeSuccessDesc:       1,
+			scrapeSkippedDesc:       1,
+			scrapeLastSuccessDesc:   1,
+			seriesLimitExceededDesc: 1,
+		})
+		last := metrics[len(metrics)-1]
+		convey.So(last.Desc(), convey.ShouldEqual, seriesLimitExceededDesc)
+		convey.So(readMetric(last).value, convey.ShouldEqual, 1)
+	})
+}
+
+// instanceCollector records the instance it was run against.
+type instanceCollector struct {
+	instance *instance
+}
+
+func (c *instanceCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	c.instance = instance
+	return nil
+}
+
+func TestPostgresCollectorPreferReplica(t *testing.T) {
+	primaryDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer primaryDB.Close()
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer replicaDB.Close()
+
+	primary := &instance{db: primaryDB}
+	replica := &instance{db: replicaDB}
+
+	collect := func(p PostgresCollector) {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			p.Collect(ch)
+		}()
+		for range ch {
+		}
+	}
+
+	convey.Convey("Collectors which prefer a replica use the replica", t, func() {
+		replicaMock.ExpectPing()
+		database, wal := &instanceCollector{}, &instanceCollector{}
+		collect(PostgresCollector{
+			Collectors: map[string]Collector{databaseSubsystem: database, walSubsystem: wal},
+			logger:     log.NewNopLogger(),
+			instance:   primary,
+			replica:    replica,
+		})
+		convey.So(database.instance, convey.ShouldEqual, replica)
+		convey.So(wal.instance, convey.ShouldEqual, primary)
+	})
+
+	convey.Convey("The primary is used when the replica is unavailable", t, func() {
+		replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		database := &instanceCollector{}
+		collect(PostgresCollector{
+			Collectors: map[string]Collector{databaseSubsystem: database},
+			logger:     log.NewNopLogger(),
+			instance:   primary,
+			replica:    replica,
+		})
+		convey.So(database.instance, convey.ShouldEqual, primary)
+		convey.So(replica.available.Load(), convey.ShouldBeFalse)
+	})
+
+	convey.Convey("The replica isn't checked without a collector which prefers it", t, func() {
+		replica.available.Store(true)
+		wal := &instanceCollector{}
+		collect(PostgresCollector{
+			Collectors: map[string]Collector{walSubsystem: wal},
+			logger:     log.NewNopLogger(),
+			instance:   primary,
+			replica:    replica,
+		})
+		convey.So(wal.instance, convey.ShouldEqual, primary)
+		// An unexpected ping would have failed and marked the replica
+		// unavailable.
+		convey.So(replica.available.Load(), convey.ShouldBeTrue)
+	})
+
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPostgresCollectorReplicaConnectsLater(t *testing.T) {
+	primaryDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer primaryDB.Close()
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer replicaDB.Close()
+
+	// The replica is down on the first scrape and up on the second.
+	replicaMock.ExpectQuery(sanitizeQuery("SELECT version();")).WillReturnError(errors.New("connection refused"))
+	replicaMock.ExpectQuery(sanitizeQuery("SELECT version();")).WillReturnRows(
+		sqlmock.NewRows([]string{"version"}).AddRow("PostgreSQL 16.1 on x86_64-pc-linux-gnu"))
+	replicaMock.ExpectPing()
+
+	database := &instanceCollector{}
+	p := PostgresCollector{
+		Collectors: map[string]Collector{databaseSubsystem: database},
+		logger:     log.NewNopLogger(),
+		instance:   &instance{db: primaryDB},
+		replica:    &instance{db: replicaDB, pending: true},
+	}
+	collect := func() *instance {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			p.Collect(ch)
+		}()
+		for range ch {
+		}
+		return database.instance
+	}
+
+	convey.Convey("The replica is used once it is up", t, func() {
+		convey.So(collect(), convey.ShouldEqual, p.instance)
+		convey.So(collect(), convey.ShouldEqual, p.replica)
+		convey.So(p.replica.version.String(), convey.ShouldEqual, "16.1.0")
+	})
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+// failingCollector succeeds unless fail is set.
+type failingCollector struct {
+	fail bool
+}
+
+func (c *failingCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	if c.fail {
+		return errors.New("query failed")
+	}
+	return nil
+}
+
+func TestExecuteLastSuccessTimestamp(t *testing.T) {
+	inst := &instance{}
+	c := &failingCollector{}
+
+	lastSuccess := func() (float64, bool) {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			execute(context.Background(), "failing", c, inst, ch, ch, log.NewNopLogger())
+		}()
+		value, found := 0.0, false
+		for m := range ch {
+			if m.Desc() == scrapeLastSuccessDesc {
+				value, found = readMetric(m).value, true
+			}
+		}
+		return value, found
+	}
+
+	convey.Convey("The last success timestamp only advances on success", t, func() {
+		c.fail = true
+		_, found := lastSuccess()
+		convey.So(found, convey.ShouldBeFalse)
+
+		c.fail = false
+		first, found := lastSuccess()
+		convey.So(found, convey.ShouldBeTrue)
+
+		time.Sleep(time.Millisecond)
+		c.fail = true
+		afterFailure, found := lastSuccess()
+		convey.So(found, convey.ShouldBeTrue)
+		convey.So(afterFailure, convey.ShouldEqual, first)
+
+		c.fail = false
+		second, _ := lastSuccess()
+		convey.So(second, convey.ShouldBeGreaterThan, first)
+	})
+}
+
+// errorCollector fails with the given error.
+type errorCollector struct {
+	err error
+}
+
+func (c errorCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	return c.err
+}
+
+func TestExecuteSoftErrors(t *testing.T) {
+	defer func(codes []string) { *softErrors = codes }(*softErrors)
+	*softErrors = []string{"25006", "57P03"}
+
+	// scrape returns the success and skipped metrics of the collector.
+	scrape := func(err error) [2]float64 {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			execute(context.Background(), "soft", errorCollector{err: err}, &instance{}, ch, ch, log.NewNopLogger())
+		}()
+		values := [2]float64{-1, -1}
+		for m := range ch {
+			switch m.Desc() {
+			case scrapeSuccessDesc:
+				values[0] = readMetric(m).value
+			case scrapeSkippedDesc:
+				values[1] = readMetric(m).value
+			}
+		}
+		return values
+	}
+
+	readOnly := &pq.Error{Code: "25006", Message: "cannot execute pg_switch_wal() in a read-only transaction"}
+	undefinedTable := &pq.Error{Code: "42P01", Message: `relation "pg_stat_statements" does not exist`}
+
+	convey.Convey("Listed SQLSTATEs skip the collector without success", t, func() {
+		convey.So(scrape(readOnly), convey.ShouldEqual, [2]float64{0, 1})
+		convey.So(scrape(fmt.Errorf("query failed: %w", readOnly)), convey.ShouldEqual, [2]float64{0, 1})
+		convey.So(scrape(multiError{readOnly, &pq.Error{Code: "57P03"}}), convey.ShouldEqual, [2]float64{0, 1})
+	})
+
+	convey.Convey("Other errors fail the collector", t, func() {
+		convey.So(scrape(undefinedTable), convey.ShouldEqual, [2]float64{0, 0})
+		convey.So(scrape(multiError{readOnly, undefinedTable}), convey.ShouldEqual, [2]float64{0, 0})
+		convey.So(scrape(errors.New("query failed")), convey.ShouldEqual, [2]float64{0, 0})
+	})
+
+	convey.Convey("Successful collectors aren't skipped", t, func() {
+		convey.So(scrape(nil), convey.ShouldEqual, [2]float64{1, 0})
+	})
+}
+
+// countingCollector reports how many times it ran.
+type countingCollector struct {
+	runs atomic.Int64
+}
+
+func (c *countingCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	runs := c.runs.Add(1)
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, float64(runs), "counting")
+	return nil
+}
+
+func TestScheduledCollector(t *testing.T) {
+	c := &countingCollector{}
+	sc := newScheduledCollector(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tick := make(chan time.Time)
+	go sc.run(ctx, tick, func() *instance { return &instance{} })
+
+	scrape := func() []float64 {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			sc.Update(context.Background(), &instance{}, ch)
+		}()
+		var values []float64
+		for m := range ch {
+			values = append(values, readMetric(m).value)
+		}
+		return values
+	}
+	// waitForRun scrapes until the metrics of the given run are served.
+	waitForRun := func(run float64) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if values := scrape(); len(values) == 1 && values[0] == run {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("Run %v was never served", run)
+	}
+
+	convey.Convey("The collector runs on the ticker, not on every scrape", t, func() {
+		waitForRun(1)
+		for i := 0; i < 3; i++ {
+			convey.So(scrape(), convey.ShouldResemble, []float64{1})
+		}
+		convey.So(c.runs.Load(), convey.ShouldEqual, 1)
+
+		tick <- time.Now()
+		waitForRun(2)
+		convey.So(scrape(), convey.ShouldResemble, []float64{2})
+		convey.So(c.runs.Load(), convey.ShouldEqual, 2)
+	})
+}
+
+func TestExecuteScheduledCollectorLastSuccess(t *testing.T) {
+	c := &failingCollector{}
+	sc := newScheduledCollector(c)
+	inst := &instance{}
+
+	lastSuccess := func() (float64, bool) {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			execute(context.Background(), "scheduled", sc, inst, ch, ch, log.NewNopLogger())
+		}()
+		value, found := 0.0, false
+		for m := range ch {
+			if m.Desc() == scrapeLastSuccessDesc {
+				value, found = readMetric(m).value, true
+			}
+		}
+		return value, found
+	}
+
+	convey.Convey("The last success timestamp only advances on successful runs", t, func() {
+		_, found := lastSuccess()
+		convey.So(found, convey.ShouldBeFalse)
+
+		sc.update(context.Background(), inst)
+		first, found := lastSuccess()
+		convey.So(found, convey.ShouldBeTrue)
+
+		// Replaying the cached metrics doesn't advance it.
+		time.Sleep(time.Millisecond)
+		replayed, _ := lastSuccess()
+		convey.So(replayed, convey.ShouldEqual, first)
+
+		c.fail = true
+		sc.update(context.Background(), inst)
+		afterFailure, found := lastSuccess()
+		convey.So(found, convey.ShouldBeTrue)
+		convey.So(afterFailure, convey.ShouldEqual, first)
+
+		c.fail = false
+		sc.update(context.Background(), inst)
+		second, _ := lastSuccess()
+		convey.So(second, convey.ShouldBeGreaterThan, first)
+	})
+}
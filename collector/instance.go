@@ -14,45 +14,451 @@
 package collector
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
 	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/alecthomas/kingpin/v2"
 	"github.com/blang/semver/v4"
+	"github.com/lib/pq"
 )
 
+// DriverName is the database/sql driver the exporter connects with.
+const DriverName = "postgres"
+
+// exporterApplicationName is reported as the application_name of the
+// exporter's connections unless the DSN or --db.application-name sets one.
+const exporterApplicationName = "postgres_exporter"
+
+var dbApplicationName = kingpin.Flag(
+	"db.application-name",
+	"application_name of the exporter's connections, unless the DSN sets one.",
+).Default(exporterApplicationName).String()
+
+var dbReadOnly = kingpin.Flag(
+	"db.read-only",
+	"Make the exporter's connections read-only, so that the queries run by the exporter can't modify data.",
+).Default("false").Bool()
+
+// readOnlyQuery is run on every connection when --db.read-only is set.
+const readOnlyQuery = "SET default_transaction_read_only = on"
+
 type instance struct {
-	db      *sql.DB
+	dsn     string
 	version semver.Version
+
+	setupMtx sync.Mutex
+	// pending is set until the version of an instance opened by
+	// openInstance has been queried.
+	pending bool
+
+	// available records whether the last scrape found a replica reachable,
+	// for the collectors which run on their own interval.
+	available atomic.Bool
+
+	dbMtx sync.RWMutex
+	db    *sql.DB
+
+	databasesMtx sync.Mutex
+	// databases are connections to the other databases of the server, for
+	// collectors which need to query each database.
+	databases map[string]*sql.DB
+
+	// samples are the samples of the previous scrape of the server, shared
+	// with the other instances of the same DSN.
+	samples *instanceSamples
+
+	lastSuccessMtx sync.Mutex
+	// lastSuccess is the time of the last successful update of each
+	// collector against this instance.
+	lastSuccess map[string]time.Time
+}
+
+// instanceSamples are the samples of the previous scrape of a server, from
+// which the collectors derive values between scrapes.
+type instanceSamples struct {
+	// walSample is used by the wal_health collector to derive the WAL
+	// generation rate between scrapes.
+	walSample walSample
+	// checkpointSample is used by the checkpoint_durations collector to
+	// derive the duration of the checkpoints completed between scrapes.
+	checkpointSample checkpointSample
+	// bgWriterSample is used by the stat_bgwriter collector to derive the
+	// pressure on the background writer between scrapes.
+	bgWriterSample bgWriterSample
+	// rollbackSample is used by the rollback_rate collector to derive the
+	// rate of rollbacks of each database between scrapes.
+	rollbackSample rollbackSample
+	// autovacuumSample is used by the autovacuum_stuck collector to detect
+	// autovacuums which made no progress between scrapes.
+	autovacuumSample autovacuumSample
+	// exporterStatements is used by the stat_statements collector to
+	// exclude the statements of the exporter seen in previous scrapes.
+	exporterStatements exporterStatements
+}
+
+var (
+	samplesMtx sync.Mutex
+	// samplesByDSN are kept outside of the instances, as /probe creates a new
+	// instance for every request.
+	samplesByDSN = make(map[string]*instanceSamples)
+)
+
+// samplesForDSN returns the samples of the server at dsn.
+func samplesForDSN(dsn string) *instanceSamples {
+	samplesMtx.Lock()
+	defer samplesMtx.Unlock()
+	s, ok := samplesByDSN[dsn]
+	if !ok {
+		s = &instanceSamples{}
+		samplesByDSN[dsn] = s
+	}
+	return s
 }
 
-func newInstance(dsn string) (*instance, error) {
-	i := &instance{}
-	db, err := sql.Open("postgres", dsn)
+// newInstance connects to the server at dsn. ctx bounds the connection and
+// the query of the version of the server.
+func newInstance(ctx context.Context, dsn string) (*instance, error) {
+	i, err := openInstance(dsn)
 	if err != nil {
 		return nil, err
 	}
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
-	i.db = db
+	if err := i.setup(ctx); err != nil {
+		i.Close()
+		return nil, err
+	}
+	return i, nil
+}
 
-	version, err := queryVersion(db)
+// openInstance opens the connection pool to the server at dsn without
+// connecting to it, so that a server which is down is retried by setup.
+func openInstance(dsn string) (*instance, error) {
+	db, err := openInstanceDB(dsn)
 	if err != nil {
-		db.Close()
 		return nil, err
 	}
+	return &instance{dsn: dsn, db: db, samples: samplesForDSN(dsn), pending: true}, nil
+}
 
+// setup queries the version of the server if it isn't known yet. The
+// collectors must only be run against an instance once setup succeeded.
+func (i *instance) setup(ctx context.Context) error {
+	i.setupMtx.Lock()
+	defer i.setupMtx.Unlock()
+	if !i.pending {
+		return nil
+	}
+	version, err := queryVersion(ctx, i.getDB())
+	if err != nil {
+		return err
+	}
 	i.version = version
+	i.pending = false
+	return nil
+}
 
-	return i, nil
+// openInstanceDB opens the connection pool of an instance to dsn.
+func openInstanceDB(dsn string) (*sql.DB, error) {
+	db, err := OpenDB(withFallbackApplicationName(dsn, *dbApplicationName))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	return db, nil
 }
 
 func (i *instance) getDB() *sql.DB {
+	i.dbMtx.RLock()
+	defer i.dbMtx.RUnlock()
 	return i.db
 }
 
+// reload replaces the connections to the server and its databases, e.g. to
+// use rotated client certificate files, which lib/pq only reads when
+// connecting. The connections are kept if the client certificate and key
+// don't load.
+func (i *instance) reload() error {
+	if err := ValidateClientCertificate(i.dsn); err != nil {
+		return err
+	}
+	db, err := openInstanceDB(i.dsn)
+	if err != nil {
+		return err
+	}
+
+	i.dbMtx.Lock()
+	old := i.db
+	i.db = db
+	i.dbMtx.Unlock()
+
+	// The connections to the other databases are reopened on first use.
+	i.databasesMtx.Lock()
+	databases := i.databases
+	i.databases = nil
+	i.databasesMtx.Unlock()
+	for _, db := range databases {
+		db.Close()
+	}
+	return old.Close()
+}
+
+// getSamples returns the samples of the previous scrape of the server. An
+// instance not created by newInstance gets samples of its own.
+func (i *instance) getSamples() *instanceSamples {
+	samplesMtx.Lock()
+	defer samplesMtx.Unlock()
+	if i.samples == nil {
+		i.samples = &instanceSamples{}
+	}
+	return i.samples
+}
+
+// setLastSuccess records that the named collector updated successfully at t.
+func (i *instance) setLastSuccess(name string, t time.Time) {
+	i.lastSuccessMtx.Lock()
+	defer i.lastSuccessMtx.Unlock()
+	if i.lastSuccess == nil {
+		i.lastSuccess = make(map[string]time.Time)
+	}
+	i.lastSuccess[name] = t
+}
+
+// getLastSuccess returns the time the named collector last updated
+// successfully, if it ever did.
+func (i *instance) getLastSuccess(name string) (time.Time, bool) {
+	i.lastSuccessMtx.Lock()
+	defer i.lastSuccessMtx.Unlock()
+	t, ok := i.lastSuccess[name]
+	return t, ok
+}
+
+// connectableDatabasesQuery lists the databases which can be connected to.
+const connectableDatabasesQuery = `SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate`
+
+// connectableDatabases returns the databases of the server which can be
+// connected to with getDatabaseDB, except the excluded ones.
+func (i *instance) connectableDatabases(ctx context.Context, excluded []string) ([]string, error) {
+	rows, err := i.getDB().QueryContext(ctx, connectableDatabasesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var datname sql.NullString
+		if err := rows.Scan(&datname); err != nil {
+			return nil, err
+		}
+		if !datname.Valid || sliceContains(excluded, datname.String) {
+			continue
+		}
+		databases = append(databases, datname.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return databases, nil
+}
+
+// getDatabaseDB returns a connection to the named database of the server,
+// which is opened on first use.
+func (i *instance) getDatabaseDB(datname string) (*sql.DB, error) {
+	i.databasesMtx.Lock()
+	defer i.databasesMtx.Unlock()
+	if db, ok := i.databases[datname]; ok {
+		return db, nil
+	}
+
+	dsn, err := withDatabase(i.dsn, datname)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openInstanceDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.databases == nil {
+		i.databases = make(map[string]*sql.DB)
+	}
+	i.databases[datname] = db
+	return db, nil
+}
+
 func (i *instance) Close() error {
-	return i.db.Close()
+	i.databasesMtx.Lock()
+	for _, db := range i.databases {
+		db.Close()
+	}
+	i.databasesMtx.Unlock()
+	return i.getDB().Close()
+}
+
+// OpenDB opens a connection pool to dsn. With --db.read-only, each
+// connection of the pool is made read-only as soon as it is established, so
+// that reconnects are covered as well.
+func OpenDB(dsn string) (*sql.DB, error) {
+	if !*dbReadOnly {
+		return sql.Open(DriverName, dsn)
+	}
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(readOnlyConnector{connector}), nil
+}
+
+// readOnlyConnector runs readOnlyQuery on the connections of the wrapped
+// connector.
+type readOnlyConnector struct {
+	driver.Connector
+}
+
+func (c readOnlyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("connection does not support executing statements")
+	}
+	if _, err := execer.ExecContext(ctx, readOnlyQuery, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// withDatabase returns dsn connecting to the named database instead.
+func withDatabase(dsn string, datname string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Path = "/" + datname
+		u.RawPath = ""
+		return u.String(), nil
+	}
+	// The last occurrence of a key takes precedence.
+	return dsn + " dbname=" + quoteDSNValue(datname), nil
+}
+
+// withFallbackApplicationName tags the connection with the given
+// application_name so the exporter's own activity can be told apart from
+// other clients. An application_name set in the DSN or environment takes
+// precedence.
+func withFallbackApplicationName(dsn string, name string) string {
+	if name == "" {
+		return dsn
+	}
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		q := u.Query()
+		if q.Get("fallback_application_name") != "" {
+			return dsn
+		}
+		q.Set("fallback_application_name", name)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	if strings.Contains(dsn, "fallback_application_name=") {
+		return dsn
+	}
+	return dsn + " fallback_application_name=" + quoteDSNValue(name)
+}
+
+// ValidateClientCertificate checks that the client certificate and key used
+// for dsn, set by sslcert and sslkey or PGSSLCERT and PGSSLKEY, load. The
+// default files in ~/.postgresql are not checked.
+func ValidateClientCertificate(dsn string) error {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		var err error
+		if dsn, err = pq.ParseURL(dsn); err != nil {
+			return err
+		}
+	}
+	values, err := parseDSNValues(dsn)
+	if err != nil {
+		return err
+	}
+
+	cert, key := os.Getenv("PGSSLCERT"), os.Getenv("PGSSLKEY")
+	if v, ok := values["sslcert"]; ok {
+		cert = v
+	}
+	if v, ok := values["sslkey"]; ok {
+		key = v
+	}
+	if cert == "" || key == "" {
+		return nil
+	}
+	if _, err := tls.LoadX509KeyPair(cert, key); err != nil {
+		return fmt.Errorf("invalid client certificate %s: %w", cert, err)
+	}
+	return nil
+}
+
+// parseDSNValues parses a key/value DSN. As for lib/pq, the last occurrence
+// of a key takes precedence.
+func parseDSNValues(dsn string) (map[string]string, error) {
+	values := make(map[string]string)
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing \"=\" after %q in DSN", s)
+		}
+		key = strings.TrimSpace(key)
+		rest = strings.TrimLeft(rest, " \t\n")
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, "'") {
+			rest = rest[1:]
+			for {
+				if rest == "" {
+					return nil, fmt.Errorf("unterminated quoted value of %q in DSN", key)
+				}
+				c := rest[0]
+				rest = rest[1:]
+				if c == '\'' {
+					break
+				}
+				if c == '\\' && rest != "" {
+					c = rest[0]
+					rest = rest[1:]
+				}
+				value.WriteByte(c)
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(rest[:end])
+			rest = rest[end:]
+		}
+		values[key] = value.String()
+		s = strings.TrimSpace(rest)
+	}
+	return values, nil
 }
 
 // Regex used to get the "short-version" from the postgres version field.
@@ -60,9 +466,9 @@ func (i *instance) Close() error {
 var versionRegex = regexp.MustCompile(`^\w+ ((\d+)(\.\d+)?(\.\d+)?)`)
 var serverVersionRegex = regexp.MustCompile(`^((\d+)(\.\d+)?(\.\d+)?)`)
 
-func queryVersion(db *sql.DB) (semver.Version, error) {
+func queryVersion(ctx context.Context, db *sql.DB) (semver.Version, error) {
 	var version string
-	err := db.QueryRow("SELECT version();").Scan(&version)
+	err := db.QueryRowContext(ctx, "SELECT version();").Scan(&version)
 	if err != nil {
 		return semver.Version{}, err
 	}
@@ -73,7 +479,7 @@ func queryVersion(db *sql.DB) (semver.Version, error) {
 
 	// We could also try to parse the version from the server_version field.
 	// This is of the format 13.3 (Debian 13.3-1.pgdg100+1)
-	err = db.QueryRow("SHOW server_version;").Scan(&version)
+	err = db.QueryRowContext(ctx, "SHOW server_version;").Scan(&version)
 	if err != nil {
 		return semver.Version{}, err
 	}
@@ -83,3 +489,14 @@ func queryVersion(db *sql.DB) (semver.Version, error) {
 	}
 	return semver.Version{}, fmt.Errorf("could not parse version from %q", version)
 }
+
+// quoteDSNValue quotes a value of a key/value DSN if it contains characters
+// which would otherwise end the value.
+func quoteDSNValue(v string) string {
+	if !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricsVerboseHelp = kingpin.Flag(
+	"metrics.verbose-help",
+	"Add the unit and the originating view and column of the metrics of the collectors to their help text.",
+).Default("false").Bool()
+
+// verboseDescs maps the descs created with newDesc to the same descs with the
+// unit and source of the metric in their help text.
+var verboseDescs = make(map[*prometheus.Desc]*prometheus.Desc)
+
+// fileLabels maps the source files of the descs created with newDesc to the
+// label names of their descs. Every collector is registered in the file its
+// descs are created in, so that its labels are known without running it.
+var fileLabels = make(map[string]map[string]bool)
+
+// newDesc is prometheus.NewDesc for the metrics of the collectors. The unit of
+// the metric, if any, and the view and column it is read from are added to the
+// help text with --metrics.verbose-help. Descs must only be created with it
+// at package initialization, verboseDescs isn't safe for concurrent writes.
+func newDesc(fqName, help string, variableLabels []string, constLabels prometheus.Labels, unit, source string) *prometheus.Desc {
+	desc := prometheus.NewDesc(fqName, help, variableLabels, constLabels)
+	verboseDescs[desc] = prometheus.NewDesc(fqName, verboseHelp(help, unit, source), variableLabels, constLabels)
+
+	file := callerFile()
+	if fileLabels[file] == nil {
+		fileLabels[file] = make(map[string]bool)
+	}
+	for _, label := range variableLabels {
+		fileLabels[file][label] = true
+	}
+	for label := range constLabels {
+		fileLabels[file][label] = true
+	}
+	return desc
+}
+
+// callerFile returns the name of the source file of the caller of the
+// function calling it.
+func callerFile() string {
+	_, file, _, _ := runtime.Caller(2)
+	return filepath.Base(file)
+}
+
+// verboseHelp returns help followed by the unit and source of the metric, e.g.
+// "Mean time spent in the statement (unit: seconds, source:
+// pg_stat_statements.mean_exec_time)".
+func verboseHelp(help, unit, source string) string {
+	var details []string
+	if unit != "" {
+		details = append(details, "unit: "+unit)
+	}
+	if source != "" {
+		details = append(details, "source: "+source)
+	}
+	if len(details) == 0 {
+		return help
+	}
+	return help + " (" + strings.Join(details, ", ") + ")"
+}
+
+// verboseHelpCollectors wraps the collectors so that their metrics have the
+// verbose help text. The cached collectors are left as is.
+func verboseHelpCollectors(collectors map[string]Collector) {
+	for name, c := range collectors {
+		collectors[name] = verboseHelpCollector{collector: c}
+	}
+}
+
+// verboseHelpCollector replaces the descs of the metrics of a collector with
+// their verbose variant.
+type verboseHelpCollector struct {
+	collector Collector
+}
+
+func (v verboseHelpCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	in := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for m := range in {
+			if desc, ok := verboseDescs[m.Desc()]; ok {
+				m = verboseHelpMetric{Metric: m, desc: desc}
+			}
+			ch <- m
+		}
+	}()
+	err := v.collector.Update(ctx, instance, in)
+	close(in)
+	<-done
+	return err
+}
+
+// verboseHelpMetric is a metric with the verbose variant of its desc.
+type verboseHelpMetric struct {
+	prometheus.Metric
+	desc *prometheus.Desc
+}
+
+func (m verboseHelpMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
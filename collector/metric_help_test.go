@@ -0,0 +1,91 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestVerboseHelpCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", 10000, 8000, 300, 200, 100)
+	mock.ExpectQuery(sanitizeQuery(pgDatabaseTuplesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := verboseHelpCollector{collector: PGDatabaseTuplesCollector{}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling verboseHelpCollector.Update: %s", err)
+		}
+	}()
+
+	var descs []string
+	for m := range ch {
+		descs = append(descs, m.Desc().String())
+	}
+	if len(descs) != 5 {
+		t.Fatalf("got %d metrics, want 5", len(descs))
+	}
+	for _, source := range []string{"tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted"} {
+		want := "(source: pg_stat_database." + source + ")"
+		found := false
+		for _, desc := range descs {
+			if strings.Contains(desc, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no metric has %q in its help text: %v", want, descs)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestVerboseHelp(t *testing.T) {
+	tests := []struct {
+		unit   string
+		source string
+		want   string
+	}{
+		{"seconds", "pg_stat_statements.mean_exec_time", "Mean time (unit: seconds, source: pg_stat_statements.mean_exec_time)"},
+		{"", "pg_stat_statements.calls", "Mean time (source: pg_stat_statements.calls)"},
+		{"", "", "Mean time"},
+	}
+	for _, tt := range tests {
+		if got := verboseHelp("Mean time", tt.unit, tt.source); got != tt.want {
+			t.Errorf("verboseHelp(%q, %q) = %q, want %q", tt.unit, tt.source, got, tt.want)
+		}
+	}
+	// The plain help text is unchanged.
+	if desc := statStatementsMetrics.callsTotal.String(); strings.Contains(desc, "source:") {
+		t.Errorf("plain desc has a source: %s", desc)
+	}
+}
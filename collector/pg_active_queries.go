@@ -0,0 +1,104 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const activeQueriesSubsystem = "active_queries"
+
+func init() {
+	registerCollector(activeQueriesSubsystem, defaultDisabled, ScopeGlobal, NewPGActiveQueriesCollector)
+}
+
+// PGActiveQueriesCollector counts the queries running concurrently. A rising
+// number of long queries running at the same time predicts CPU saturation.
+type PGActiveQueriesCollector struct {
+	log log.Logger
+}
+
+func NewPGActiveQueriesCollector(config collectorConfig) (Collector, error) {
+	return &PGActiveQueriesCollector{log: config.logger}, nil
+}
+
+var (
+	pgActiveQueries = newDesc(
+		prometheus.BuildFQName(namespace, "", activeQueriesSubsystem),
+		"Number of queries being run by client backends",
+		[]string{}, nil,
+		"", "pg_stat_activity.query_start",
+	)
+	pgActiveQueriesOver1s = newDesc(
+		prometheus.BuildFQName(namespace, activeQueriesSubsystem, "over_1s"),
+		"Number of queries being run by client backends which started more than a second ago",
+		[]string{}, nil,
+		"", "pg_stat_activity.query_start",
+	)
+
+	// The query of the exporter itself is always active.
+	pgActiveQueriesQuery = `SELECT
+		EXTRACT(EPOCH FROM now() - query_start) AS age
+	FROM pg_stat_activity
+	WHERE state = 'active'
+		AND backend_type = 'client backend'
+		AND pid <> pg_backend_pid()`
+)
+
+func (c PGActiveQueriesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// backend_type was added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "active_queries collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgActiveQueriesQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var active, over1s float64
+	for rows.Next() {
+		var age sql.NullFloat64
+		if err := rows.Scan(&age); err != nil {
+			return err
+		}
+		active++
+		if age.Valid && age.Float64 > 1 {
+			over1s++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgActiveQueries,
+		prometheus.GaugeValue, active,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		pgActiveQueriesOver1s,
+		prometheus.GaugeValue, over1s,
+	)
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const activeQueryFingerprintsSubsystem = "active_query_fingerprints"
+
+func init() {
+	registerCollector(activeQueryFingerprintsSubsystem, defaultDisabled, ScopeGlobal, NewPGActiveQueryFingerprintsCollector)
+}
+
+// PGActiveQueryFingerprintsCollector counts the running queries by shape,
+// where pg_stat_statements can't be installed. The queries are normalized by
+// the exporter, and the number of fingerprints is bounded by the number of
+// connections.
+type PGActiveQueryFingerprintsCollector struct {
+	log log.Logger
+}
+
+func NewPGActiveQueryFingerprintsCollector(config collectorConfig) (Collector, error) {
+	return &PGActiveQueryFingerprintsCollector{log: config.logger}, nil
+}
+
+var (
+	pgActiveQueryFingerprints = newDesc(
+		prometheus.BuildFQName(namespace, "", activeQueryFingerprintsSubsystem),
+		"Number of queries being run by client backends with the fingerprint, the query text with its literals replaced by ?",
+		[]string{"fingerprint"}, nil,
+		"", "pg_stat_activity.query",
+	)
+
+	pgActiveQueryFingerprintsQuery = `SELECT
+		query
+	FROM pg_stat_activity
+	WHERE state = 'active'
+		AND backend_type = 'client backend'
+		AND pid <> pg_backend_pid()`
+)
+
+func (c PGActiveQueryFingerprintsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// backend_type was added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "active_query_fingerprints collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgActiveQueryFingerprintsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var fingerprints []string
+	counts := make(map[string]float64)
+	for rows.Next() {
+		var query sql.NullString
+		if err := rows.Scan(&query); err != nil {
+			return err
+		}
+		fingerprint := queryFingerprint(query.String)
+		if fingerprint == "" {
+			continue
+		}
+		if _, ok := counts[fingerprint]; !ok {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+		counts[fingerprint]++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fingerprint := range fingerprints {
+		ch <- prometheus.MustNewConstMetric(
+			pgActiveQueryFingerprints,
+			prometheus.GaugeValue, counts[fingerprint],
+			fingerprint,
+		)
+	}
+	return nil
+}
+
+// queryFingerprint normalizes query by replacing its string and numeric
+// literals with ?, removing its comments and collapsing its whitespace.
+// Quoted identifiers and parameters such as $1 are kept.
+func queryFingerprint(query string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+			space = true
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+			space = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			space = true
+		default:
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			switch {
+			case ch == '\'':
+				// A quote in a string literal is doubled.
+				i++
+				for i < len(query) {
+					if query[i] == '\'' {
+						if i+1 < len(query) && query[i+1] == '\'' {
+							i += 2
+							continue
+						}
+						break
+					}
+					i++
+				}
+				i++
+				b.WriteByte('?')
+			case ch == '"':
+				end := strings.IndexByte(query[i+1:], '"')
+				if end < 0 {
+					b.WriteString(query[i:])
+					i = len(query)
+					break
+				}
+				b.WriteString(query[i : i+end+2])
+				i += end + 2
+			case ch == '$' || isIdentifierByte(ch):
+				start := i
+				for i < len(query) && (query[i] == '$' || isIdentifierByte(query[i]) || query[i] >= '0' && query[i] <= '9') {
+					i++
+				}
+				b.WriteString(query[start:i])
+			case ch >= '0' && ch <= '9' || ch == '.' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+				for i < len(query) && (query[i] >= '0' && query[i] <= '9' || query[i] == '.') {
+					i++
+				}
+				b.WriteByte('?')
+			default:
+				b.WriteByte(ch)
+				i++
+			}
+		}
+	}
+	return b.String()
+}
+
+// isIdentifierByte returns whether ch can be part of an unquoted identifier
+// or keyword. Digits are only part of one after its first byte.
+func isIdentifierByte(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= 0x80
+}
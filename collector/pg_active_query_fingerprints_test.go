@@ -0,0 +1,85 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGActiveQueryFingerprintsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	// The first two queries only differ by their literals.
+	rows := sqlmock.NewRows([]string{"query"}).
+		AddRow("SELECT * FROM orders WHERE customer = 'alice' AND total > 10").
+		AddRow("SELECT *  FROM orders\nWHERE customer = 'o''brien' AND total > 2.5").
+		AddRow("UPDATE stock SET quantity = quantity - 1 WHERE item_id = $1")
+	mock.ExpectQuery(sanitizeQuery(pgActiveQueryFingerprintsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGActiveQueryFingerprintsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGActiveQueryFingerprintsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"fingerprint": "SELECT * FROM orders WHERE customer = ? AND total > ?"}, metricType: dto.MetricType_GAUGE, value: 2},
+		{labels: labelMap{"fingerprint": "UPDATE stock SET quantity = quantity - ? WHERE item_id = $1"}, metricType: dto.MetricType_GAUGE, value: 1},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestQueryFingerprint(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"SELECT 1", "SELECT ?"},
+		{"select * from t1 where id in (1, 2, .5)", "select * from t1 where id in (?, ?, ?)"},
+		{"SELECT \"Col1\" FROM \"Table 2\" WHERE name = 'it''s'", "SELECT \"Col1\" FROM \"Table 2\" WHERE name = ?"},
+		{"SELECT a -- the id 42\nFROM t /* comment 'x' */ WHERE b = $2", "SELECT a FROM t WHERE b = $2"},
+		{"  \n ", ""},
+	}
+	for _, tt := range tests {
+		if got := queryFingerprint(tt.query); got != tt.want {
+			t.Errorf("queryFingerprint(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const authSubsystem = "auth"
+
+func init() {
+	registerCollector(authSubsystem, defaultDisabled, ScopeGlobal, NewPGAuthCollector)
+}
+
+// PGAuthCollector reports the authentication method negotiated by the
+// exporter's own connection and the authentication methods configured in
+// pg_hba.conf.
+//
+// The lib/pq driver does not implement SCRAM channel binding
+// (SCRAM-SHA-256-PLUS) and does not expose the negotiated authentication
+// method, so the method is read from the server instead, and whether channel
+// binding is in use cannot be reported.
+type PGAuthCollector struct {
+	log log.Logger
+}
+
+func NewPGAuthCollector(config collectorConfig) (Collector, error) {
+	return &PGAuthCollector{log: config.logger}, nil
+}
+
+var (
+	pgConnectionAuthMethod = newDesc(
+		prometheus.BuildFQName(namespace, "connection", "auth_method"),
+		"Authentication method of the exporter's connection, none if the connection was not authenticated (e.g. trust)",
+		[]string{"auth_method"}, nil,
+		"", "SYSTEM_USER",
+	)
+	pgHBARules = newDesc(
+		prometheus.BuildFQName(namespace, "hba", "rules"),
+		"Number of valid pg_hba.conf rules by authentication method",
+		[]string{"auth_method"}, nil,
+		"", "pg_hba_file_rules.auth_method",
+	)
+
+	// SYSTEM_USER is auth_method:identity, or NULL without authentication.
+	pgAuthMethodQuery = `SELECT split_part(SYSTEM_USER, ':', 1) AS auth_method`
+
+	pgHBARulesQuery = `SELECT
+		auth_method,
+		count(*) AS rules
+	FROM pg_hba_file_rules
+	WHERE error IS NULL
+	GROUP BY auth_method`
+)
+
+func (c PGAuthCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// SYSTEM_USER was added in PostgreSQL 16.
+	if instance.version.GE(semver.MustParse("16.0.0")) {
+		var method sql.NullString
+		if err := db.QueryRowContext(ctx, pgAuthMethodQuery).Scan(&method); err != nil {
+			return err
+		}
+		methodLabel := "none"
+		if method.Valid {
+			methodLabel = method.String
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgConnectionAuthMethod,
+			prometheus.GaugeValue, 1, methodLabel,
+		)
+	}
+
+	// pg_hba_file_rules was added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		return nil
+	}
+	rows, err := db.QueryContext(ctx,
+		pgHBARulesQuery)
+	switch {
+	case isPermissionDenied(err):
+		level.Debug(c.log).Log("msg", "Permission denied reading pg_hba_file_rules, skipping", "err", err)
+		return nil
+	case err != nil:
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var method sql.NullString
+		var count sql.NullInt64
+		if err := rows.Scan(&method, &count); err != nil {
+			return err
+		}
+		if !method.Valid {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgHBARules,
+			prometheus.GaugeValue, float64(count.Int64), method.String,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const autovacuumOverdueSubsystem = "autovacuum_overdue"
+
+func init() {
+	registerCollector(autovacuumOverdueSubsystem, defaultDisabled, ScopeDatabase, NewPGAutovacuumOverdueCollector)
+}
+
+// PGAutovacuumOverdueCollector counts the tables of the database which have
+// crossed their autovacuum or autoanalyze threshold, i.e. which autovacuum
+// should have processed but hasn't yet.
+type PGAutovacuumOverdueCollector struct {
+	log log.Logger
+}
+
+func NewPGAutovacuumOverdueCollector(config collectorConfig) (Collector, error) {
+	return &PGAutovacuumOverdueCollector{log: config.logger}, nil
+}
+
+var (
+	pgTablesVacuumOverdue = newDesc(
+		prometheus.BuildFQName(namespace, "tables", "vacuum_overdue_count"),
+		"Number of tables whose dead tuples exceed their autovacuum threshold",
+		[]string{"datname"}, nil,
+		"", "pg_stat_user_tables.n_dead_tup",
+	)
+	pgTablesAnalyzeOverdue = newDesc(
+		prometheus.BuildFQName(namespace, "tables", "analyze_overdue_count"),
+		"Number of tables whose modifications since the last analyze exceed their autoanalyze threshold",
+		[]string{"datname"}, nil,
+		"", "pg_stat_user_tables.n_mod_since_analyze",
+	)
+
+	pgAutovacuumSettingsQuery = `SELECT
+		current_database() datname,
+		current_setting('autovacuum_vacuum_threshold')::float AS vacuum_threshold,
+		current_setting('autovacuum_vacuum_scale_factor')::float AS vacuum_scale_factor,
+		current_setting('autovacuum_analyze_threshold')::float AS analyze_threshold,
+		current_setting('autovacuum_analyze_scale_factor')::float AS analyze_scale_factor`
+
+	pgAutovacuumOverdueTablesQuery = `SELECT
+		c.reltuples,
+		s.n_dead_tup,
+		s.n_mod_since_analyze,
+		c.reloptions
+	FROM pg_stat_user_tables s
+	JOIN pg_class c
+		ON c.oid = s.relid`
+)
+
+// autovacuumSettings are the autovacuum parameters of a table. The
+// autovacuum and autoanalyze thresholds are threshold + scale_factor *
+// reltuples.
+type autovacuumSettings struct {
+	enabled            bool
+	vacuumThreshold    float64
+	vacuumScaleFactor  float64
+	analyzeThreshold   float64
+	analyzeScaleFactor float64
+	// costLimit and costDelay, in milliseconds, throttle autovacuum.
+	costLimit float64
+	costDelay float64
+}
+
+// withReloptions returns the settings overridden by the storage parameters
+// of a table.
+func (t autovacuumSettings) withReloptions(reloptions []string) autovacuumSettings {
+	for _, option := range reloptions {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			continue
+		}
+		if key == "autovacuum_enabled" {
+			if enabled, ok := parseBoolSetting(value); ok {
+				t.enabled = enabled
+			}
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "autovacuum_vacuum_threshold":
+			t.vacuumThreshold = v
+		case "autovacuum_vacuum_scale_factor":
+			t.vacuumScaleFactor = v
+		case "autovacuum_analyze_threshold":
+			t.analyzeThreshold = v
+		case "autovacuum_analyze_scale_factor":
+			t.analyzeScaleFactor = v
+		case "autovacuum_vacuum_cost_limit":
+			t.costLimit = v
+		case "autovacuum_vacuum_cost_delay":
+			t.costDelay = v
+		}
+	}
+	return t
+}
+
+// parseBoolSetting parses the value of a boolean setting the way PostgreSQL
+// does.
+func parseBoolSetting(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1", "t", "y":
+		return true, true
+	case "off", "false", "no", "0", "f", "n":
+		return false, true
+	}
+	return false, false
+}
+
+func (c PGAutovacuumOverdueCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	var datname string
+	var defaults autovacuumSettings
+	if err := db.QueryRowContext(ctx, pgAutovacuumSettingsQuery).Scan(
+		&datname,
+		&defaults.vacuumThreshold, &defaults.vacuumScaleFactor,
+		&defaults.analyzeThreshold, &defaults.analyzeScaleFactor,
+	); err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		pgAutovacuumOverdueTablesQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var vacuumOverdue, analyzeOverdue float64
+	for rows.Next() {
+		var reltuples sql.NullFloat64
+		var nDeadTup, nModSinceAnalyze sql.NullInt64
+		var reloptions pq.StringArray
+		if err := rows.Scan(&reltuples, &nDeadTup, &nModSinceAnalyze, &reloptions); err != nil {
+			return err
+		}
+
+		// Tables which have never been analyzed have reltuples -1 since
+		// PostgreSQL 14, autovacuum treats them as empty.
+		tuples := reltuples.Float64
+		if tuples < 0 {
+			tuples = 0
+		}
+		t := defaults.withReloptions(reloptions)
+		if nDeadTup.Valid && float64(nDeadTup.Int64) > t.vacuumThreshold+t.vacuumScaleFactor*tuples {
+			vacuumOverdue++
+		}
+		if nModSinceAnalyze.Valid && float64(nModSinceAnalyze.Int64) > t.analyzeThreshold+t.analyzeScaleFactor*tuples {
+			analyzeOverdue++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgTablesVacuumOverdue,
+		prometheus.GaugeValue, vacuumOverdue, datname,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		pgTablesAnalyzeOverdue,
+		prometheus.GaugeValue, analyzeOverdue, datname,
+	)
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGAutovacuumOverdueCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	mock.ExpectQuery(sanitizeQuery(pgAutovacuumSettingsQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"datname", "vacuum_threshold", "vacuum_scale_factor", "analyze_threshold", "analyze_scale_factor"}).
+			AddRow("app", 50, 0.2, 50, 0.1))
+
+	columns := []string{"reltuples", "n_dead_tup", "n_mod_since_analyze", "reloptions"}
+	rows := sqlmock.NewRows(columns).
+		// Vacuum threshold 2050, analyze threshold 1050: both overdue.
+		AddRow(10000, 2100, 1100, nil).
+		// Below both thresholds.
+		AddRow(10000, 2000, 1000, nil).
+		// Lowered per-table vacuum threshold of 100 + 0.01 * 10000.
+		AddRow(10000, 300, 0, "{autovacuum_vacuum_scale_factor=0.01,autovacuum_vacuum_threshold=100}").
+		// Raised per-table analyze threshold, not overdue.
+		AddRow(10000, 0, 5000, "{autovacuum_analyze_scale_factor=0.5}").
+		// Never analyzed, reltuples is -1.
+		AddRow(-1, 10, 60, nil)
+	mock.ExpectQuery(sanitizeQuery(pgAutovacuumOverdueTablesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGAutovacuumOverdueCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGAutovacuumOverdueCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 2},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 2},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
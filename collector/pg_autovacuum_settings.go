@@ -0,0 +1,225 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const autovacuumSettingsSubsystem = "autovacuum_settings"
+
+var (
+	autovacuumSettingsExcludeSchemas = kingpin.Flag(
+		"collector.autovacuum_settings.exclude-schema",
+		"Schema to exclude from the autovacuum_settings collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	autovacuumSettingsLimit = kingpin.Flag(
+		"collector.autovacuum_settings.limit",
+		"Maximum number of tables reported by the autovacuum_settings collector. Tables with custom settings are reported first, then the largest tables.",
+	).Default("100").Int()
+)
+
+func init() {
+	registerCollector(autovacuumSettingsSubsystem, defaultDisabled, ScopeDatabase, NewPGAutovacuumSettingsCollector)
+}
+
+// PGAutovacuumSettingsCollector reports the effective autovacuum settings of
+// the tables of the database, i.e. their storage parameters falling back to
+// the server configuration.
+type PGAutovacuumSettingsCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGAutovacuumSettingsCollector(config collectorConfig) (Collector, error) {
+	return &PGAutovacuumSettingsCollector{
+		log:            config.logger,
+		excludeSchemas: *autovacuumSettingsExcludeSchemas,
+		limit:          *autovacuumSettingsLimit,
+	}, nil
+}
+
+var (
+	autovacuumSettingsLabels = []string{"datname", "schemaname", "relname"}
+
+	pgTableAutovacuumEnabled = newDesc(
+		prometheus.BuildFQName(namespace, "table", "autovacuum_enabled"),
+		"Whether autovacuum processes the table, other than to prevent transaction ID wraparound",
+		autovacuumSettingsLabels, nil,
+		"", "pg_class.reloptions",
+	)
+	pgTableAutovacuumVacuumScaleFactor = newDesc(
+		prometheus.BuildFQName(namespace, "table", "autovacuum_vacuum_scale_factor"),
+		"Effective fraction of the table size added to the vacuum threshold of the table",
+		autovacuumSettingsLabels, nil,
+		"", "pg_class.reloptions",
+	)
+	pgTableAutovacuumVacuumThreshold = newDesc(
+		prometheus.BuildFQName(namespace, "table", "autovacuum_vacuum_threshold"),
+		"Effective minimum number of dead tuples before the table is vacuumed",
+		autovacuumSettingsLabels, nil,
+		"", "pg_class.reloptions",
+	)
+	pgTableAutovacuumVacuumCostLimit = newDesc(
+		prometheus.BuildFQName(namespace, "table", "autovacuum_vacuum_cost_limit"),
+		"Effective cost limit of autovacuum for the table",
+		autovacuumSettingsLabels, nil,
+		"", "pg_class.reloptions",
+	)
+	pgTableAutovacuumVacuumCostDelay = newDesc(
+		prometheus.BuildFQName(namespace, "table", "autovacuum_vacuum_cost_delay_seconds"),
+		"Effective cost delay of autovacuum for the table, in seconds",
+		autovacuumSettingsLabels, nil,
+		"seconds", "pg_class.reloptions",
+	)
+
+	pgAutovacuumSettingsDefaultsQuery = `SELECT
+		name,
+		setting
+	FROM pg_settings
+	WHERE name IN (
+		'autovacuum',
+		'autovacuum_vacuum_threshold',
+		'autovacuum_vacuum_scale_factor',
+		'autovacuum_vacuum_cost_limit',
+		'autovacuum_vacuum_cost_delay',
+		'vacuum_cost_limit',
+		'vacuum_cost_delay'
+	)`
+
+	pgAutovacuumSettingsTablesQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname,
+		c.reloptions
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'm')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'
+		AND n.nspname <> ALL($1)
+	ORDER BY c.reloptions IS NULL, pg_relation_size(c.oid) DESC
+	LIMIT $2`
+)
+
+func (c PGAutovacuumSettingsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	defaults, err := queryAutovacuumDefaults(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		pgAutovacuumSettingsTablesQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname sql.NullString
+		var reloptions pq.StringArray
+		if err := rows.Scan(&datname, &schemaname, &relname, &reloptions); err != nil {
+			return err
+		}
+		if !datname.Valid || !schemaname.Valid || !relname.Valid {
+			continue
+		}
+
+		t := defaults.withReloptions(reloptions)
+		// autovacuum_enabled can't enable autovacuum for a table when it is
+		// disabled for the server.
+		t.enabled = t.enabled && defaults.enabled
+		enabled := 0.0
+		if t.enabled {
+			enabled = 1
+		}
+
+		labels := []string{datname.String, schemaname.String, relname.String}
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			value float64
+		}{
+			{pgTableAutovacuumEnabled, enabled},
+			{pgTableAutovacuumVacuumScaleFactor, t.vacuumScaleFactor},
+			{pgTableAutovacuumVacuumThreshold, t.vacuumThreshold},
+			{pgTableAutovacuumVacuumCostLimit, t.costLimit},
+			{pgTableAutovacuumVacuumCostDelay, t.costDelay / 1000},
+		} {
+			ch <- prometheus.MustNewConstMetric(
+				m.desc,
+				prometheus.GaugeValue, m.value,
+				labels...,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// queryAutovacuumDefaults returns the autovacuum settings of the server. The
+// autovacuum cost settings fall back to the vacuum cost settings when -1.
+func queryAutovacuumDefaults(ctx context.Context, db *sql.DB) (autovacuumSettings, error) {
+	rows, err := db.QueryContext(ctx, pgAutovacuumSettingsDefaultsQuery)
+	if err != nil {
+		return autovacuumSettings{}, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var name, setting string
+		if err := rows.Scan(&name, &setting); err != nil {
+			return autovacuumSettings{}, err
+		}
+		settings[name] = setting
+	}
+	if err := rows.Err(); err != nil {
+		return autovacuumSettings{}, err
+	}
+
+	number := func(name string) float64 {
+		v, _ := strconv.ParseFloat(settings[name], 64)
+		return v
+	}
+	defaults := autovacuumSettings{
+		vacuumThreshold:   number("autovacuum_vacuum_threshold"),
+		vacuumScaleFactor: number("autovacuum_vacuum_scale_factor"),
+		costLimit:         number("autovacuum_vacuum_cost_limit"),
+		costDelay:         number("autovacuum_vacuum_cost_delay"),
+	}
+	defaults.enabled, _ = parseBoolSetting(settings["autovacuum"])
+	if defaults.costLimit < 0 {
+		defaults.costLimit = number("vacuum_cost_limit")
+	}
+	if defaults.costDelay < 0 {
+		defaults.costDelay = number("vacuum_cost_delay")
+	}
+	return defaults, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGAutovacuumSettingsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	mock.ExpectQuery(sanitizeQuery(pgAutovacuumSettingsDefaultsQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"name", "setting"}).
+			AddRow("autovacuum", "on").
+			AddRow("autovacuum_vacuum_threshold", "50").
+			AddRow("autovacuum_vacuum_scale_factor", "0.2").
+			AddRow("autovacuum_vacuum_cost_limit", "-1").
+			AddRow("autovacuum_vacuum_cost_delay", "2").
+			AddRow("vacuum_cost_limit", "200").
+			AddRow("vacuum_cost_delay", "0"))
+
+	columns := []string{"datname", "schemaname", "relname", "reloptions"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "public", "events", "{autovacuum_vacuum_scale_factor=0.01,autovacuum_vacuum_cost_limit=1000,autovacuum_vacuum_cost_delay=0}").
+		AddRow("app", "public", "staging", "{autovacuum_enabled=false}").
+		AddRow("app", "public", "orders", nil)
+	mock.ExpectQuery(sanitizeQuery(pgAutovacuumSettingsTablesQuery)).WithArgs("{}", 100).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGAutovacuumSettingsCollector{limit: 100}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGAutovacuumSettingsCollector.Update: %s", err)
+		}
+	}()
+
+	events := labelMap{"datname": "app", "schemaname": "public", "relname": "events"}
+	staging := labelMap{"datname": "app", "schemaname": "public", "relname": "staging"}
+	orders := labelMap{"datname": "app", "schemaname": "public", "relname": "orders"}
+	expected := []MetricResult{
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 0.01},
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 50},
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 1000},
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: staging, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: staging, metricType: dto.MetricType_GAUGE, value: 0.2},
+		{labels: staging, metricType: dto.MetricType_GAUGE, value: 50},
+		{labels: staging, metricType: dto.MetricType_GAUGE, value: 200},
+		{labels: staging, metricType: dto.MetricType_GAUGE, value: 0.002},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 0.2},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 50},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 200},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 0.002},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
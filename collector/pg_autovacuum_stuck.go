@@ -0,0 +1,179 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const autovacuumStuckSubsystem = "autovacuum_stuck"
+
+var autovacuumStuckThreshold = kingpin.Flag(
+	"collector.autovacuum_stuck.threshold",
+	"Time an autovacuum of a table has to be running for before it is reported as stuck by the autovacuum_stuck collector, if it made no progress since the previous scrape.",
+).Default("1h").Duration()
+
+func init() {
+	registerCollector(autovacuumStuckSubsystem, defaultDisabled, ScopeGlobal, NewPGAutovacuumStuckCollector)
+}
+
+// PGAutovacuumStuckCollector reports the autovacuum workers which have been
+// vacuuming a table for long without making progress between scrapes, e.g.
+// because they wait for a lock.
+type PGAutovacuumStuckCollector struct {
+	log       log.Logger
+	threshold time.Duration
+}
+
+func NewPGAutovacuumStuckCollector(config collectorConfig) (Collector, error) {
+	return &PGAutovacuumStuckCollector{
+		log:       config.logger,
+		threshold: *autovacuumStuckThreshold,
+	}, nil
+}
+
+var (
+	pgAutovacuumStuck = newDesc(
+		prometheus.BuildFQName(namespace, "", autovacuumStuckSubsystem),
+		"Whether the autovacuum of the table has been running for longer than the threshold without vacuuming or scanning heap blocks since the previous scrape (1 for stuck)",
+		[]string{"datname", "relname"}, nil,
+		"", "pg_stat_progress_vacuum.heap_blks_vacuumed and pg_stat_activity.xact_start",
+	)
+
+	// The relation can only be resolved to its name for vacuums running in
+	// the database the exporter is connected to, the oid is reported otherwise.
+	pgAutovacuumStuckQuery = `SELECT
+		p.pid,
+		p.datname,
+		COALESCE(c.relname, p.relid::text) AS relname,
+		p.heap_blks_scanned,
+		p.heap_blks_vacuumed,
+		EXTRACT(EPOCH FROM now() - a.xact_start) AS running_seconds
+	FROM pg_stat_progress_vacuum p
+	JOIN pg_stat_activity a
+		ON a.pid = p.pid
+	LEFT JOIN pg_class c
+		ON c.oid = p.relid
+		AND p.datname = current_database()
+	WHERE a.backend_type = 'autovacuum worker'`
+)
+
+func (c PGAutovacuumStuckCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// pg_stat_activity.backend_type was added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "autovacuum_stuck collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgAutovacuumStuckQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type vacuum struct {
+		key      autovacuumKey
+		progress autovacuumProgress
+		running  float64
+	}
+	var vacuums []vacuum
+	for rows.Next() {
+		var pid sql.NullInt64
+		var datname, relname sql.NullString
+		var scanned, vacuumed, running sql.NullFloat64
+		if err := rows.Scan(&pid, &datname, &relname, &scanned, &vacuumed, &running); err != nil {
+			return err
+		}
+		if !pid.Valid || !datname.Valid || !relname.Valid || !running.Valid {
+			continue
+		}
+		vacuums = append(vacuums, vacuum{
+			key:      autovacuumKey{pid: pid.Int64, datname: datname.String, relname: relname.String},
+			progress: autovacuumProgress{heapBlksScanned: scanned.Float64, heapBlksVacuumed: vacuumed.Float64},
+			running:  running.Float64,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	progress := make(map[autovacuumKey]autovacuumProgress, len(vacuums))
+	for _, v := range vacuums {
+		progress[v.key] = v.progress
+	}
+	stalled := instance.getSamples().autovacuumSample.stalled(progress)
+	for _, v := range vacuums {
+		stuck := 0.0
+		if stalled[v.key] && v.running > c.threshold.Seconds() {
+			stuck = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgAutovacuumStuck,
+			prometheus.GaugeValue, stuck,
+			v.key.datname, v.key.relname,
+		)
+	}
+	return nil
+}
+
+// autovacuumKey identifies the autovacuum of a table by a worker.
+type autovacuumKey struct {
+	pid     int64
+	datname string
+	relname string
+}
+
+// autovacuumProgress is the progress of an autovacuum. heap_blks_vacuumed
+// doesn't advance while the heap is scanned, so both are tracked.
+type autovacuumProgress struct {
+	heapBlksScanned  float64
+	heapBlksVacuumed float64
+}
+
+// autovacuumSample holds the progress of the running autovacuums of an
+// instance at the previous scrape.
+type autovacuumSample struct {
+	mtx      sync.Mutex
+	progress map[autovacuumKey]autovacuumProgress
+}
+
+// stalled records the current progress of the autovacuums and returns the
+// ones which made no progress since the previous sample. Autovacuums which
+// weren't sampled before are omitted.
+func (s *autovacuumSample) stalled(progress map[autovacuumKey]autovacuumProgress) map[autovacuumKey]bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	last := s.progress
+	s.progress = progress
+
+	stalled := make(map[autovacuumKey]bool)
+	for key, p := range progress {
+		if l, ok := last[key]; ok && p == l {
+			stalled[key] = true
+		}
+	}
+	return stalled
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGAutovacuumStuckCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	columns := []string{"pid", "datname", "relname", "heap_blks_scanned", "heap_blks_vacuumed", "running_seconds"}
+	mock.ExpectQuery(sanitizeQuery(pgAutovacuumStuckQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow(100, "app", "orders", 5000, 2000, 7200).
+			AddRow(101, "app", "events", 300, 100, 7200).
+			AddRow(102, "app", "sessions", 10, 0, 60))
+	// orders is blocked on a lock, events is making progress and sessions
+	// only just started.
+	mock.ExpectQuery(sanitizeQuery(pgAutovacuumStuckQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow(100, "app", "orders", 5000, 2000, 7260).
+			AddRow(101, "app", "events", 300, 180, 7260).
+			AddRow(102, "app", "sessions", 10, 0, 120))
+
+	scrape := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			c := PGAutovacuumStuckCollector{threshold: time.Hour}
+
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGAutovacuumStuckCollector.Update: %s", err)
+			}
+		}()
+
+		var results []MetricResult
+		for m := range ch {
+			results = append(results, readMetric(m))
+		}
+		return results
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{"datname": "app", "relname": "orders"}, metricType: dto.MetricType_GAUGE, value: 0},
+			{labels: labelMap{"datname": "app", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: 0},
+			{labels: labelMap{"datname": "app", "relname": "sessions"}, metricType: dto.MetricType_GAUGE, value: 0},
+		})
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{"datname": "app", "relname": "orders"}, metricType: dto.MetricType_GAUGE, value: 1},
+			{labels: labelMap{"datname": "app", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: 0},
+			{labels: labelMap{"datname": "app", "relname": "sessions"}, metricType: dto.MetricType_GAUGE, value: 0},
+		})
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestAutovacuumSampleStalled(t *testing.T) {
+	s := &autovacuumSample{}
+	orders := autovacuumKey{pid: 100, datname: "app", relname: "orders"}
+	s.stalled(map[autovacuumKey]autovacuumProgress{
+		orders: {heapBlksScanned: 5000, heapBlksVacuumed: 2000},
+	})
+
+	// The heap scan of orders advanced without vacuuming blocks, and events
+	// wasn't sampled before.
+	events := autovacuumKey{pid: 101, datname: "app", relname: "events"}
+	stalled := s.stalled(map[autovacuumKey]autovacuumProgress{
+		orders: {heapBlksScanned: 6000, heapBlksVacuumed: 2000},
+		events: {heapBlksScanned: 0, heapBlksVacuumed: 0},
+	})
+	if len(stalled) != 0 {
+		t.Errorf("got stalled autovacuums %v, want none", stalled)
+	}
+	stalled = s.stalled(map[autovacuumKey]autovacuumProgress{
+		events: {heapBlksScanned: 0, heapBlksVacuumed: 0},
+	})
+	if !stalled[events] || len(stalled) != 1 {
+		t.Errorf("got stalled autovacuums %v, want events", stalled)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const backendMemorySubsystem = "backend_memory"
+
+func init() {
+	// Disabled by default because only the memory of the exporter's own
+	// backend can be reported.
+	registerCollector(backendMemorySubsystem, defaultDisabled, ScopeGlobal, NewPGBackendMemoryCollector)
+}
+
+type PGBackendMemoryCollector struct {
+	log      log.Logger
+	omitNull bool
+}
+
+func NewPGBackendMemoryCollector(config collectorConfig) (Collector, error) {
+	return &PGBackendMemoryCollector{log: config.logger, omitNull: config.omitNull}, nil
+}
+
+var (
+	pgExporterBackendMemoryBytes = newDesc(
+		prometheus.BuildFQName(namespace, "exporter", "backend_memory_bytes"),
+		"Memory allocated by the backend of the exporter's own connection",
+		[]string{},
+		prometheus.Labels{},
+		"bytes", "pg_backend_memory_contexts.total_bytes",
+	)
+
+	// No released version of PostgreSQL exposes the memory contexts of other
+	// backends, pg_log_backend_memory_contexts only writes them to the server
+	// log, so no aggregate across backends can be computed.
+	pgBackendMemoryCurrentQuery = `SELECT SUM(total_bytes) FROM pg_backend_memory_contexts`
+)
+
+func (c PGBackendMemoryCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	if instance.version.LT(semver.MustParse("14.0.0")) {
+		level.Debug(c.log).Log("msg", "pg_backend_memory_contexts is not available before PostgreSQL 14")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	var totalBytes sql.NullFloat64
+	if err := db.QueryRowContext(ctx, pgBackendMemoryCurrentQuery).Scan(&totalBytes); err != nil {
+		return err
+	}
+	if !totalBytes.Valid && c.omitNull {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgExporterBackendMemoryBytes,
+		prometheus.GaugeValue,
+		totalBytes.Float64,
+	)
+	return nil
+}
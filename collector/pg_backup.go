@@ -0,0 +1,104 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const backupSubsystem = "backup"
+
+var backupQuery = kingpin.Flag(
+	"collector.backup.query",
+	"SELECT returning the completion time of the last successful backup as a single timestamp column, from which the backup collector reports pg_last_backup_age_seconds.",
+).Default("").String()
+
+func init() {
+	registerCollector(backupSubsystem, defaultDisabled, ScopeGlobal, NewPGBackupCollector)
+}
+
+// PGBackupCollector reports the age of the last successful backup, which is
+// read by a query of the user from wherever the backups record it.
+type PGBackupCollector struct {
+	log   log.Logger
+	query string
+}
+
+func NewPGBackupCollector(config collectorConfig) (Collector, error) {
+	if *backupQuery == "" {
+		return nil, errors.New("the backup collector requires --collector.backup.query")
+	}
+	return &PGBackupCollector{
+		log:   config.logger,
+		query: *backupQuery,
+	}, nil
+}
+
+var (
+	pgLastBackupAge = newDesc(
+		prometheus.BuildFQName(namespace, "last_backup", "age_seconds"),
+		"Time since the completion of the last successful backup, as returned by --collector.backup.query",
+		[]string{}, nil,
+		"seconds", "--collector.backup.query",
+	)
+
+	// The age is computed by the server, so that it doesn't depend on the
+	// clock of the exporter, and a completion time in the future, from
+	// clocks out of sync, is reported as 0. The columns of the backup query
+	// are counted to reject queries which don't return a single column.
+	pgBackupAgeQueryTemplate = `SELECT
+		greatest(EXTRACT(EPOCH FROM now() - completed), 0),
+		(SELECT count(*) FROM json_object_keys(row_to_json(backup)))
+	FROM (%s) AS backup(completed)
+	LIMIT 1`
+)
+
+func (c PGBackupCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	query := fmt.Sprintf(pgBackupAgeQueryTemplate, strings.TrimRight(strings.TrimSpace(c.query), ";"))
+
+	// Only the first row is used.
+	var age sql.NullFloat64
+	var columns int
+	err := db.QueryRowContext(ctx, query).Scan(&age, &columns)
+	if errors.Is(err, sql.ErrNoRows) {
+		level.Debug(c.log).Log("msg", "Backup query returned no backup")
+		return ErrNoData
+	}
+	if err != nil {
+		return fmt.Errorf("backup query did not return a timestamp: %w", err)
+	}
+	if columns != 1 {
+		return fmt.Errorf("backup query returned %d columns, expected a single timestamp column", columns)
+	}
+	if !age.Valid {
+		level.Debug(c.log).Log("msg", "Backup query returned no backup")
+		return ErrNoData
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgLastBackupAge,
+		prometheus.GaugeValue, age.Float64,
+	)
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const testBackupQuery = `SELECT max(finished_at) FROM ops.backups WHERE status = 'success'`
+
+func TestPGBackupCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// A trailing semicolon of the backup query is dropped.
+	rows := sqlmock.NewRows([]string{"greatest", "count"}).
+		AddRow(259200.5, 1)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgBackupAgeQueryTemplate, testBackupQuery))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGBackupCollector{query: testBackupQuery + ";\n"}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGBackupCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 259200.5, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGBackupCollectorNoBackup(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+	}{
+		{name: "no rows", rows: sqlmock.NewRows([]string{"greatest", "count"})},
+		{name: "null", rows: sqlmock.NewRows([]string{"greatest", "count"}).AddRow(nil, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db}
+
+			mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgBackupAgeQueryTemplate, testBackupQuery))).WillReturnRows(tt.rows)
+
+			c := PGBackupCollector{log: log.NewNopLogger(), query: testBackupQuery}
+			ch := make(chan prometheus.Metric, 1)
+			if err := c.Update(context.Background(), inst, ch); !IsNoDataError(err) {
+				t.Errorf("got %v, want no data", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
+
+func TestPGBackupCollectorInvalidQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+		err  error
+	}{
+		{name: "two columns", rows: sqlmock.NewRows([]string{"greatest", "count"}).AddRow(60, 2)},
+		{name: "not a timestamp", err: &pq.Error{Code: "42883", Message: "operator does not exist: timestamp with time zone - text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db}
+
+			expect := mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgBackupAgeQueryTemplate, testBackupQuery)))
+			if tt.err != nil {
+				expect.WillReturnError(tt.err)
+			} else {
+				expect.WillReturnRows(tt.rows)
+			}
+
+			c := PGBackupCollector{log: log.NewNopLogger(), query: testBackupQuery}
+			ch := make(chan prometheus.Metric, 1)
+			if err := c.Update(context.Background(), inst, ch); err == nil || IsNoDataError(err) {
+				t.Errorf("got %v, want an error", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const checkpointDurationsSubsystem = "checkpoint_durations"
+
+func init() {
+	registerCollector(checkpointDurationsSubsystem, defaultDisabled, ScopeGlobal, NewPGCheckpointDurationsCollector)
+}
+
+// PGCheckpointDurationsCollector reports histograms of the time spent writing
+// and syncing per checkpoint. PostgreSQL only exposes the total time spent in
+// all checkpoints, so the duration of the checkpoints completed between two
+// scrapes is approximated by dividing the increase of the total time by the
+// number of checkpoints.
+type PGCheckpointDurationsCollector struct {
+	log log.Logger
+}
+
+func NewPGCheckpointDurationsCollector(config collectorConfig) (Collector, error) {
+	return &PGCheckpointDurationsCollector{log: config.logger}, nil
+}
+
+var (
+	pgCheckpointWriteSeconds = newDesc(
+		prometheus.BuildFQName(namespace, "checkpoint", "write_seconds"),
+		"Approximate time spent writing files to disk per checkpoint, in seconds",
+		[]string{}, nil,
+		"seconds", "pg_stat_checkpointer.write_time",
+	)
+	pgCheckpointSyncSeconds = newDesc(
+		prometheus.BuildFQName(namespace, "checkpoint", "sync_seconds"),
+		"Approximate time spent synchronizing files to disk per checkpoint, in seconds",
+		[]string{}, nil,
+		"seconds", "pg_stat_checkpointer.sync_time",
+	)
+
+	checkpointDurationBuckets = []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600, 1800}
+
+	pgCheckpointDurationsQuery = `SELECT
+		checkpoints_timed + checkpoints_req AS checkpoints,
+		checkpoint_write_time,
+		checkpoint_sync_time
+	FROM pg_stat_bgwriter`
+
+	// The checkpoint statistics moved to pg_stat_checkpointer in PostgreSQL 17.
+	pgCheckpointDurationsCheckpointerQuery = `SELECT
+		num_done AS checkpoints,
+		write_time,
+		sync_time
+	FROM pg_stat_checkpointer`
+)
+
+func (c PGCheckpointDurationsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	query := pgCheckpointDurationsQuery
+	if instance.version.GE(semver.MustParse("17.0.0")) {
+		query = pgCheckpointDurationsCheckpointerQuery
+	}
+
+	db := instance.getDB()
+	var checkpoints, writeTime, syncTime sql.NullFloat64
+	if err := db.QueryRowContext(ctx, query).Scan(&checkpoints, &writeTime, &syncTime); err != nil {
+		return err
+	}
+	if !checkpoints.Valid || !writeTime.Valid || !syncTime.Valid {
+		return ErrNoData
+	}
+
+	write, sync := instance.getSamples().checkpointSample.update(checkpoints.Float64, writeTime.Float64, syncTime.Float64)
+	ch <- prometheus.MustNewConstHistogram(
+		pgCheckpointWriteSeconds,
+		write.count, write.sum, write.bucketCounts(),
+	)
+	ch <- prometheus.MustNewConstHistogram(
+		pgCheckpointSyncSeconds,
+		sync.count, sync.sum, sync.bucketCounts(),
+	)
+	return nil
+}
+
+// checkpointSample holds the checkpoint statistics of an instance at the
+// previous scrape, and the histograms of the checkpoint durations derived
+// from them so far.
+type checkpointSample struct {
+	mtx         sync.Mutex
+	initialized bool
+	checkpoints float64
+	writeTime   float64
+	syncTime    float64
+
+	write durationHistogram
+	sync  durationHistogram
+}
+
+// update records the current checkpoint statistics, with times in
+// milliseconds, observes the average duration of the checkpoints completed
+// since the previous sample and returns the resulting histograms.
+func (s *checkpointSample) update(checkpoints, writeTime, syncTime float64) (durationHistogram, durationHistogram) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delta := checkpoints - s.checkpoints
+	writeDelta := writeTime - s.writeTime
+	syncDelta := syncTime - s.syncTime
+	// Nothing can be derived from the first sample or after the statistics
+	// were reset.
+	if s.initialized && delta > 0 && writeDelta >= 0 && syncDelta >= 0 {
+		s.write.observe(writeDelta/delta/1000, uint64(delta))
+		s.sync.observe(syncDelta/delta/1000, uint64(delta))
+	}
+	s.initialized = true
+	s.checkpoints, s.writeTime, s.syncTime = checkpoints, writeTime, syncTime
+
+	return s.write.clone(), s.sync.clone()
+}
+
+// durationHistogram is a histogram over checkpointDurationBuckets.
+type durationHistogram struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// observe adds n observations of v.
+func (h *durationHistogram) observe(v float64, n uint64) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(checkpointDurationBuckets))
+	}
+	h.count += n
+	h.sum += v * float64(n)
+	for i, le := range checkpointDurationBuckets {
+		if v <= le {
+			h.buckets[i] += n
+		}
+	}
+}
+
+func (h durationHistogram) clone() durationHistogram {
+	h.buckets = append([]uint64(nil), h.buckets...)
+	return h
+}
+
+// bucketCounts returns the cumulative counts by upper bound.
+func (h durationHistogram) bucketCounts() map[float64]uint64 {
+	counts := make(map[float64]uint64, len(checkpointDurationBuckets))
+	for i, le := range checkpointDurationBuckets {
+		if h.buckets != nil {
+			counts[le] = h.buckets[i]
+		} else {
+			counts[le] = 0
+		}
+	}
+	return counts
+}
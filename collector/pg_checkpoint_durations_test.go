@@ -0,0 +1,113 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGCheckpointDurationsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("17.0.0")}
+
+	columns := []string{"checkpoints", "write_time", "sync_time"}
+	mock.ExpectQuery(sanitizeQuery(pgCheckpointDurationsCheckpointerQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow(10, 300000, 5000))
+	// Two checkpoints which spent 60s writing and 4s syncing in total.
+	mock.ExpectQuery(sanitizeQuery(pgCheckpointDurationsCheckpointerQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow(12, 360000, 9000))
+
+	scrape := func() []*dto.Histogram {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			c := PGCheckpointDurationsCollector{}
+
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGCheckpointDurationsCollector.Update: %s", err)
+			}
+		}()
+
+		var histograms []*dto.Histogram
+		for m := range ch {
+			pb := &dto.Metric{}
+			if err := m.Write(pb); err != nil {
+				t.Fatalf("Error writing metric: %s", err)
+			}
+			histograms = append(histograms, pb.GetHistogram())
+		}
+		return histograms
+	}
+
+	bucket := func(h *dto.Histogram, le float64) uint64 {
+		for _, b := range h.GetBucket() {
+			if b.GetUpperBound() == le {
+				return b.GetCumulativeCount()
+			}
+		}
+		t.Fatalf("Missing bucket %v", le)
+		return 0
+	}
+
+	convey.Convey("Checkpoint durations are derived from the deltas between scrapes", t, func() {
+		first := scrape()
+		convey.So(first, convey.ShouldHaveLength, 2)
+		convey.So(first[0].GetSampleCount(), convey.ShouldEqual, 0)
+		convey.So(first[1].GetSampleCount(), convey.ShouldEqual, 0)
+
+		second := scrape()
+		convey.So(second, convey.ShouldHaveLength, 2)
+
+		write := second[0]
+		convey.So(write.GetSampleCount(), convey.ShouldEqual, 2)
+		convey.So(write.GetSampleSum(), convey.ShouldEqual, 60)
+		convey.So(bucket(write, 15), convey.ShouldEqual, 0)
+		convey.So(bucket(write, 30), convey.ShouldEqual, 2)
+
+		sync := second[1]
+		convey.So(sync.GetSampleCount(), convey.ShouldEqual, 2)
+		convey.So(sync.GetSampleSum(), convey.ShouldEqual, 4)
+		convey.So(bucket(sync, 1), convey.ShouldEqual, 0)
+		convey.So(bucket(sync, 5), convey.ShouldEqual, 2)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestCheckpointSampleReset(t *testing.T) {
+	s := &checkpointSample{}
+	s.update(10, 300000, 5000)
+	s.update(12, 360000, 9000)
+	// The statistics were reset, which must not be observed as a checkpoint.
+	write, _ := s.update(1, 1000, 100)
+	if write.count != 2 {
+		t.Errorf("Expected 2 observed checkpoints after a reset, got %d", write.count)
+	}
+	write, _ = s.update(2, 31000, 200)
+	if write.count != 3 || write.sum != 90 {
+		t.Errorf("Expected 3 observed checkpoints summing to 90s, got %d summing to %v", write.count, write.sum)
+	}
+}
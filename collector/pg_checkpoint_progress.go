@@ -0,0 +1,117 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const checkpointProgressSubsystem = "checkpoint_progress"
+
+func init() {
+	registerCollector(checkpointProgressSubsystem, defaultDisabled, ScopeGlobal, NewPGCheckpointProgressCollector)
+}
+
+// PGCheckpointProgressCollector reports where the server is in the
+// checkpoint cycle. A checkpoint starts when checkpoint_timeout has passed
+// or max_wal_size is about to be exceeded since the last one, whichever
+// comes first.
+type PGCheckpointProgressCollector struct {
+	log log.Logger
+}
+
+func NewPGCheckpointProgressCollector(config collectorConfig) (Collector, error) {
+	return &PGCheckpointProgressCollector{log: config.logger}, nil
+}
+
+var (
+	pgCheckpointNextSeconds = newDesc(
+		prometheus.BuildFQName(namespace, "checkpoint", "next_seconds"),
+		"Estimated time until checkpoint_timeout triggers the next checkpoint, in seconds",
+		[]string{}, nil,
+		"seconds", "pg_control_checkpoint.checkpoint_time",
+	)
+	pgCheckpointWALFraction = newDesc(
+		prometheus.BuildFQName(namespace, "checkpoint", "wal_fraction"),
+		"WAL written since the redo point of the last checkpoint as a fraction of max_wal_size",
+		[]string{}, nil,
+		"", "pg_control_checkpoint.redo_lsn",
+	)
+
+	pgCheckpointProgressSettingsQuery = `SELECT
+		(SELECT setting::float FROM pg_settings WHERE name = 'checkpoint_timeout') AS checkpoint_timeout,
+		(SELECT setting::float * pg_size_bytes('1' || unit) FROM pg_settings WHERE name = 'max_wal_size') AS max_wal_size`
+
+	// pg_control_checkpoint() requires superuser or the pg_monitor role.
+	pgCheckpointProgressQuery = `SELECT
+		EXTRACT(EPOCH FROM now() - checkpoint_time) AS since_checkpoint,
+		CASE
+			WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn()
+			ELSE pg_current_wal_lsn()
+		END - redo_lsn AS wal_since_checkpoint
+	FROM pg_control_checkpoint()`
+)
+
+func (c PGCheckpointProgressCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "Checkpoint progress is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	var timeout, maxWALSize sql.NullFloat64
+	if err := db.QueryRowContext(ctx, pgCheckpointProgressSettingsQuery).Scan(&timeout, &maxWALSize); err != nil {
+		return err
+	}
+
+	var sinceCheckpoint, walSinceCheckpoint sql.NullFloat64
+	err := db.QueryRowContext(ctx, pgCheckpointProgressQuery).Scan(&sinceCheckpoint, &walSinceCheckpoint)
+	switch {
+	case isPermissionDenied(err):
+		level.Debug(c.log).Log("msg", "Permission denied reading pg_control_checkpoint(), skipping", "err", err)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if timeout.Valid && sinceCheckpoint.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgCheckpointNextSeconds,
+			prometheus.GaugeValue, checkpointNextSeconds(timeout.Float64, sinceCheckpoint.Float64),
+		)
+	}
+	if maxWALSize.Valid && maxWALSize.Float64 > 0 && walSinceCheckpoint.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgCheckpointWALFraction,
+			prometheus.GaugeValue, walSinceCheckpoint.Float64/maxWALSize.Float64,
+		)
+	}
+	return nil
+}
+
+// checkpointNextSeconds returns the time until checkpoint_timeout has passed
+// since the last checkpoint. It is 0 once the timeout has passed, while the
+// triggered checkpoint is still running.
+func checkpointNextSeconds(timeout, sinceCheckpoint float64) float64 {
+	if sinceCheckpoint >= timeout {
+		return 0
+	}
+	return timeout - sinceCheckpoint
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGCheckpointProgressCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	// checkpoint_timeout of 5min and max_wal_size of 1GB.
+	mock.ExpectQuery(sanitizeQuery(pgCheckpointProgressSettingsQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"checkpoint_timeout", "max_wal_size"}).AddRow(300, 1073741824))
+	// 256MB written in the 120s since the last checkpoint.
+	mock.ExpectQuery(sanitizeQuery(pgCheckpointProgressQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"since_checkpoint", "wal_since_checkpoint"}).AddRow(120, 268435456))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGCheckpointProgressCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGCheckpointProgressCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 180},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 0.25},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGCheckpointProgressCollectorPermissionDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgCheckpointProgressSettingsQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"checkpoint_timeout", "max_wal_size"}).AddRow(300, 1073741824))
+	mock.ExpectQuery(sanitizeQuery(pgCheckpointProgressQuery)).WillReturnError(
+		&pq.Error{Code: "42501", Message: "permission denied for function pg_control_checkpoint"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGCheckpointProgressCollector{log: log.NewNopLogger()}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGCheckpointProgressCollector.Update: %s", err)
+		}
+	}()
+
+	convey.Convey("Nothing is reported without permission", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestCheckpointNextSeconds(t *testing.T) {
+	if v := checkpointNextSeconds(300, 420); v != 0 {
+		t.Errorf("Expected 0 once the timeout has passed, got %v", v)
+	}
+}
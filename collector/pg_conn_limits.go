@@ -0,0 +1,136 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const connLimitsSubsystem = "conn_limits"
+
+func init() {
+	registerCollector(connLimitsSubsystem, defaultDisabled, ScopeGlobal, NewPGConnLimitsCollector)
+}
+
+// PGConnLimitsCollector reports the CONNECTION LIMIT of roles and databases
+// next to the number of connections currently using them. Roles and
+// databases without a limit (-1) are skipped.
+type PGConnLimitsCollector struct {
+	log               log.Logger
+	excludedDatabases []string
+}
+
+func NewPGConnLimitsCollector(config collectorConfig) (Collector, error) {
+	return &PGConnLimitsCollector{
+		log:               config.logger,
+		excludedDatabases: config.excludeDatabases,
+	}, nil
+}
+
+var (
+	pgRoleConnectionLimit = newDesc(
+		prometheus.BuildFQName(namespace, "role", "connection_limit"),
+		"Maximum number of concurrent connections allowed for the role",
+		[]string{"rolname"}, nil,
+		"", "pg_roles.rolconnlimit",
+	)
+	pgRoleConnectionsUsed = newDesc(
+		prometheus.BuildFQName(namespace, "role", "connections_used"),
+		"Number of connections currently open by the role",
+		[]string{"rolname"}, nil,
+		"", "pg_stat_activity.usename",
+	)
+	pgDatabaseConnectionLimit = newDesc(
+		prometheus.BuildFQName(namespace, databaseSubsystem, "connection_limit"),
+		"Maximum number of concurrent connections allowed to the database",
+		[]string{"datname"}, nil,
+		"", "pg_database.datconnlimit",
+	)
+	pgDatabaseConnectionsUsed = newDesc(
+		prometheus.BuildFQName(namespace, databaseSubsystem, "connections_used"),
+		"Number of connections currently open to the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_activity.datname",
+	)
+
+	pgConnLimitsRoleQuery = `SELECT
+		r.rolname,
+		r.rolconnlimit,
+		count(a.pid) AS used
+	FROM pg_roles r
+	LEFT JOIN pg_stat_activity a
+		ON a.usesysid = r.oid
+	GROUP BY r.rolname, r.rolconnlimit`
+
+	pgConnLimitsDatabaseQuery = `SELECT
+		d.datname,
+		d.datconnlimit,
+		count(a.pid) AS used
+	FROM pg_database d
+	LEFT JOIN pg_stat_activity a
+		ON a.datid = d.oid
+	GROUP BY d.datname, d.datconnlimit`
+)
+
+func (c PGConnLimitsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	if err := c.updateLimits(ctx, db, pgConnLimitsRoleQuery, nil, pgRoleConnectionLimit, pgRoleConnectionsUsed, ch); err != nil {
+		return err
+	}
+	return c.updateLimits(ctx, db, pgConnLimitsDatabaseQuery, c.excludedDatabases, pgDatabaseConnectionLimit, pgDatabaseConnectionsUsed, ch)
+}
+
+// updateLimits emits the limit and usage for every row of query, which must
+// return the name, the connection limit and the number of used connections.
+func (c PGConnLimitsCollector) updateLimits(ctx context.Context, db *sql.DB, query string, exclude []string, limitDesc, usedDesc *prometheus.Desc, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name sql.NullString
+		var limit, used sql.NullInt64
+		if err := rows.Scan(&name, &limit, &used); err != nil {
+			return err
+		}
+
+		// A limit of -1 means unlimited.
+		if !name.Valid || !limit.Valid || limit.Int64 < 0 {
+			continue
+		}
+		if sliceContains(exclude, name.String) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			limitDesc,
+			prometheus.GaugeValue, float64(limit.Int64), name.String,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			usedDesc,
+			prometheus.GaugeValue, float64(used.Int64), name.String,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
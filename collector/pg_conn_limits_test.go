@@ -0,0 +1,77 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGConnLimitsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"name", "limit", "used"}
+	roleRows := sqlmock.NewRows(columns).
+		AddRow("postgres", -1, 3).
+		AddRow("app", 20, 19).
+		AddRow("reporting", 5, 0)
+	mock.ExpectQuery(sanitizeQuery(pgConnLimitsRoleQuery)).WillReturnRows(roleRows)
+
+	databaseRows := sqlmock.NewRows(columns).
+		AddRow("postgres", -1, 2).
+		AddRow("app", 100, 40).
+		AddRow("excluded", 10, 1)
+	mock.ExpectQuery(sanitizeQuery(pgConnLimitsDatabaseQuery)).WillReturnRows(databaseRows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGConnLimitsCollector{excludedDatabases: []string{"excluded"}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGConnLimitsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"rolname": "app"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"rolname": "app"}, metricType: dto.MetricType_GAUGE, value: 19},
+		{labels: labelMap{"rolname": "reporting"}, metricType: dto.MetricType_GAUGE, value: 5},
+		{labels: labelMap{"rolname": "reporting"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 100},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 40},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
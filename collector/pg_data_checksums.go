@@ -0,0 +1,77 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const dataChecksumsSubsystem = "data_checksums"
+
+func init() {
+	registerCollector(dataChecksumsSubsystem, defaultDisabled, ScopeGlobal, NewPGDataChecksumsCollector)
+	preferReplica(dataChecksumsSubsystem)
+}
+
+// PGDataChecksumsCollector reports whether data checksums are enabled, which
+// is decided when the cluster is initialized and can only be changed offline.
+type PGDataChecksumsCollector struct {
+	log log.Logger
+}
+
+func NewPGDataChecksumsCollector(config collectorConfig) (Collector, error) {
+	return &PGDataChecksumsCollector{log: config.logger}, nil
+}
+
+var (
+	pgDataChecksumsEnabled = newDesc(
+		prometheus.BuildFQName(namespace, dataChecksumsSubsystem, "enabled"),
+		"Whether data checksums are enabled (1 for yes, 0 for no)",
+		[]string{}, nil,
+		"", "pg_settings.data_checksums",
+	)
+
+	pgDataChecksumsQuery = "SHOW data_checksums"
+)
+
+func (c PGDataChecksumsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	row := db.QueryRowContext(ctx,
+		pgDataChecksumsQuery)
+
+	var setting sql.NullString
+	if err := row.Scan(&setting); err != nil {
+		return err
+	}
+
+	var enabled float64
+	switch setting.String {
+	case "on":
+		enabled = 1
+	case "off":
+		enabled = 0
+	default:
+		return fmt.Errorf("unexpected data_checksums setting: %q", setting.String)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		pgDataChecksumsEnabled,
+		prometheus.GaugeValue, enabled,
+	)
+	return nil
+}
@@ -24,12 +24,14 @@ import (
 const databaseSubsystem = "database"
 
 func init() {
-	registerCollector(databaseSubsystem, defaultEnabled, NewPGDatabaseCollector)
+	registerCollector(databaseSubsystem, defaultEnabled, ScopeDatabase, NewPGDatabaseCollector)
+	preferReplica(databaseSubsystem)
 }
 
 type PGDatabaseCollector struct {
 	log               log.Logger
 	excludedDatabases []string
+	omitNull          bool
 }
 
 func NewPGDatabaseCollector(config collectorConfig) (Collector, error) {
@@ -40,11 +42,12 @@ func NewPGDatabaseCollector(config collectorConfig) (Collector, error) {
 	return &PGDatabaseCollector{
 		log:               config.logger,
 		excludedDatabases: exclude,
+		omitNull:          config.omitNull,
 	}, nil
 }
 
 var (
-	pgDatabaseSizeDesc = prometheus.NewDesc(
+	pgDatabaseSizeDesc = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			databaseSubsystem,
@@ -52,6 +55,7 @@ var (
 		),
 		"Disk space used by the database",
 		[]string{"datname"}, nil,
+		"bytes", "pg_database_size",
 	)
 
 	pgDatabaseQuery     = "SELECT pg_database.datname FROM pg_database;"
@@ -106,14 +110,16 @@ func (c PGDatabaseCollector) Update(ctx context.Context, instance *instance, ch
 			return err
 		}
 
-		sizeMetric := 0.0
-		if size.Valid {
-			sizeMetric = size.Float64
+		if size.Valid || !c.omitNull {
+			sizeMetric := 0.0
+			if size.Valid {
+				sizeMetric = size.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				pgDatabaseSizeDesc,
+				prometheus.GaugeValue, sizeMetric, datname,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			pgDatabaseSizeDesc,
-			prometheus.GaugeValue, sizeMetric, datname,
-		)
 	}
 	if err := rows.Err(); err != nil {
 		return err
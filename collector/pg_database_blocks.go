@@ -0,0 +1,104 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const databaseBlocksSubsystem = "database_blocks"
+
+func init() {
+	registerCollector(databaseBlocksSubsystem, defaultDisabled, ScopeGlobal, NewPGDatabaseBlocksCollector)
+}
+
+// PGDatabaseBlocksCollector reports the block read and hit counters of
+// pg_stat_database, from which the buffer cache miss rate over a window can be
+// derived instead of the ratio averaged since the statistics were reset.
+type PGDatabaseBlocksCollector struct {
+	log               log.Logger
+	excludedDatabases []string
+}
+
+func NewPGDatabaseBlocksCollector(config collectorConfig) (Collector, error) {
+	return &PGDatabaseBlocksCollector{
+		log:               config.logger,
+		excludedDatabases: config.excludeDatabases,
+	}, nil
+}
+
+var (
+	pgBlocksReadTotal = newDesc(
+		prometheus.BuildFQName(namespace, "blocks", "read_total"),
+		"Number of disk blocks read in the database, which were not found in the buffer cache",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.blks_read",
+	)
+	pgBlocksHitTotal = newDesc(
+		prometheus.BuildFQName(namespace, "blocks", "hit_total"),
+		"Number of times disk blocks were found in the buffer cache in the database, so that a read was not necessary",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.blks_hit",
+	)
+
+	pgDatabaseBlocksQuery = `SELECT
+		datname,
+		blks_read,
+		blks_hit
+	FROM pg_stat_database`
+)
+
+func (c PGDatabaseBlocksCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgDatabaseBlocksQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname sql.NullString
+		var read, hit sql.NullFloat64
+		if err := rows.Scan(&datname, &read, &hit); err != nil {
+			return err
+		}
+
+		// Since PostgreSQL 12 pg_stat_database has a row for the shared
+		// objects with a NULL datname.
+		if !datname.Valid || sliceContains(c.excludedDatabases, datname.String) {
+			continue
+		}
+
+		if read.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgBlocksReadTotal,
+				prometheus.CounterValue, read.Float64,
+				datname.String,
+			)
+		}
+		if hit.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgBlocksHitTotal,
+				prometheus.CounterValue, hit.Float64,
+				datname.String,
+			)
+		}
+	}
+	return rows.Err()
+}
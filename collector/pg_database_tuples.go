@@ -0,0 +1,132 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const databaseTuplesSubsystem = "database_tuples"
+
+func init() {
+	registerCollector(databaseTuplesSubsystem, defaultDisabled, ScopeGlobal, NewPGDatabaseTuplesCollector)
+}
+
+// PGDatabaseTuplesCollector reports the tuple counters of pg_stat_database,
+// a lightweight subset of the stat_database collector.
+type PGDatabaseTuplesCollector struct {
+	log               log.Logger
+	excludedDatabases []string
+}
+
+func NewPGDatabaseTuplesCollector(config collectorConfig) (Collector, error) {
+	return &PGDatabaseTuplesCollector{
+		log:               config.logger,
+		excludedDatabases: config.excludeDatabases,
+	}, nil
+}
+
+var (
+	pgTuplesReturnedTotal = newDesc(
+		prometheus.BuildFQName(namespace, "tuples", "returned_total"),
+		"Number of live rows fetched by sequential scans and index entries returned by index scans in the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.tup_returned",
+	)
+	pgTuplesFetchedTotal = newDesc(
+		prometheus.BuildFQName(namespace, "tuples", "fetched_total"),
+		"Number of live rows fetched by index scans in the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.tup_fetched",
+	)
+	pgTuplesInsertedTotal = newDesc(
+		prometheus.BuildFQName(namespace, "tuples", "inserted_total"),
+		"Number of rows inserted by queries in the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.tup_inserted",
+	)
+	pgTuplesUpdatedTotal = newDesc(
+		prometheus.BuildFQName(namespace, "tuples", "updated_total"),
+		"Number of rows updated by queries in the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.tup_updated",
+	)
+	pgTuplesDeletedTotal = newDesc(
+		prometheus.BuildFQName(namespace, "tuples", "deleted_total"),
+		"Number of rows deleted by queries in the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.tup_deleted",
+	)
+
+	pgDatabaseTuplesQuery = `SELECT
+		datname,
+		tup_returned,
+		tup_fetched,
+		tup_inserted,
+		tup_updated,
+		tup_deleted
+	FROM pg_stat_database`
+)
+
+func (c PGDatabaseTuplesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgDatabaseTuplesQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname sql.NullString
+		var returned, fetched, inserted, updated, deleted sql.NullFloat64
+		if err := rows.Scan(&datname, &returned, &fetched, &inserted, &updated, &deleted); err != nil {
+			return err
+		}
+
+		// Since PostgreSQL 12 pg_stat_database has a row for the shared
+		// objects with a NULL datname.
+		if !datname.Valid || sliceContains(c.excludedDatabases, datname.String) {
+			continue
+		}
+
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			value sql.NullFloat64
+		}{
+			{pgTuplesReturnedTotal, returned},
+			{pgTuplesFetchedTotal, fetched},
+			{pgTuplesInsertedTotal, inserted},
+			{pgTuplesUpdatedTotal, updated},
+			{pgTuplesDeletedTotal, deleted},
+		} {
+			if !m.value.Valid {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				m.desc,
+				prometheus.CounterValue, m.value.Float64,
+				datname.String,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
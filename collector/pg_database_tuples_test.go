@@ -0,0 +1,76 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGDatabaseTuplesCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted"}
+	rows := sqlmock.NewRows(columns).
+		AddRow(nil, 500, 400, 0, 0, 0).
+		AddRow("app", 10000, 8000, 300, 200, 100).
+		AddRow("reports", 90000, 1000, 5, 4, 3).
+		AddRow("excluded", 1, 1, 1, 1, 1)
+	mock.ExpectQuery(sanitizeQuery(pgDatabaseTuplesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDatabaseTuplesCollector{excludedDatabases: []string{"excluded"}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDatabaseTuplesCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_COUNTER, value: 10000},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_COUNTER, value: 8000},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_COUNTER, value: 300},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_COUNTER, value: 200},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"datname": "reports"}, metricType: dto.MetricType_COUNTER, value: 90000},
+		{labels: labelMap{"datname": "reports"}, metricType: dto.MetricType_COUNTER, value: 1000},
+		{labels: labelMap{"datname": "reports"}, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: labelMap{"datname": "reports"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"datname": "reports"}, metricType: dto.MetricType_COUNTER, value: 3},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const databaseXactSubsystem = "database_xact"
+
+func init() {
+	registerCollector(databaseXactSubsystem, defaultDisabled, ScopeGlobal, NewPGDatabaseXactCollector)
+}
+
+// PGDatabaseXactCollector reports the transaction counters of
+// pg_stat_database with the time of the last statistics reset as their
+// created timestamp, so that a reset isn't mistaken for a spike of
+// transactions.
+type PGDatabaseXactCollector struct {
+	log               log.Logger
+	excludedDatabases []string
+}
+
+func NewPGDatabaseXactCollector(config collectorConfig) (Collector, error) {
+	return &PGDatabaseXactCollector{
+		log:               config.logger,
+		excludedDatabases: config.excludeDatabases,
+	}, nil
+}
+
+var (
+	pgDatabaseXactCommitTotal = newDesc(
+		prometheus.BuildFQName(namespace, databaseSubsystem, "xact_commit_total"),
+		"Number of transactions in the database that have been committed",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.xact_commit",
+	)
+	pgDatabaseXactRollbackTotal = newDesc(
+		prometheus.BuildFQName(namespace, databaseSubsystem, "xact_rollback_total"),
+		"Number of transactions in the database that have been rolled back",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.xact_rollback",
+	)
+
+	pgDatabaseXactQuery = `SELECT
+		datname,
+		xact_commit,
+		xact_rollback,
+		stats_reset
+	FROM pg_stat_database`
+)
+
+func (c PGDatabaseXactCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgDatabaseXactQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname sql.NullString
+		var commit, rollback sql.NullFloat64
+		var statsReset sql.NullTime
+		if err := rows.Scan(&datname, &commit, &rollback, &statsReset); err != nil {
+			return err
+		}
+
+		// Since PostgreSQL 12 pg_stat_database has a row for the shared
+		// objects with a NULL datname.
+		if !datname.Valid || sliceContains(c.excludedDatabases, datname.String) {
+			continue
+		}
+
+		if commit.Valid {
+			ch <- newXactCounter(pgDatabaseXactCommitTotal, commit.Float64, statsReset, datname.String)
+		}
+		if rollback.Valid {
+			ch <- newXactCounter(pgDatabaseXactRollbackTotal, rollback.Float64, statsReset, datname.String)
+		}
+	}
+	return rows.Err()
+}
+
+// newXactCounter returns a counter created at statsReset. The statistics of a
+// database which were never reset have no created timestamp.
+func newXactCounter(desc *prometheus.Desc, value float64, statsReset sql.NullTime, labelValues ...string) prometheus.Metric {
+	if !statsReset.Valid {
+		return prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labelValues...)
+	}
+	return prometheus.MustNewConstMetricWithCreatedTimestamp(desc, prometheus.CounterValue, value, statsReset.Time, labelValues...)
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGDatabaseXactCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	statsReset := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	columns := []string{"datname", "xact_commit", "xact_rollback", "stats_reset"}
+	rows := sqlmock.NewRows(columns).
+		AddRow(nil, 20, 0, nil).
+		AddRow("app", 1500, 30, statsReset).
+		AddRow("reports", 42000, 7, nil).
+		AddRow("excluded", 1, 1, nil)
+	mock.ExpectQuery(sanitizeQuery(pgDatabaseXactQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDatabaseXactCollector{excludedDatabases: []string{"excluded"}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDatabaseXactCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []struct {
+		desc    *prometheus.Desc
+		datname string
+		value   float64
+		created time.Time
+	}{
+		{desc: pgDatabaseXactCommitTotal, datname: "app", value: 1500, created: statsReset},
+		{desc: pgDatabaseXactRollbackTotal, datname: "app", value: 30, created: statsReset},
+		// The statistics were never reset.
+		{desc: pgDatabaseXactCommitTotal, datname: "reports", value: 42000},
+		{desc: pgDatabaseXactRollbackTotal, datname: "reports", value: 7},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := <-ch
+			convey.So(m.Desc(), convey.ShouldEqual, expect.desc)
+
+			pb := &dto.Metric{}
+			convey.So(m.Write(pb), convey.ShouldBeNil)
+			convey.So(pb.GetLabel()[0].GetValue(), convey.ShouldEqual, expect.datname)
+			convey.So(pb.GetCounter().GetValue(), convey.ShouldEqual, expect.value)
+			if expect.created.IsZero() {
+				convey.So(pb.GetCounter().GetCreatedTimestamp(), convey.ShouldBeNil)
+			} else {
+				convey.So(pb.GetCounter().GetCreatedTimestamp().AsTime(), convey.ShouldEqual, expect.created)
+			}
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
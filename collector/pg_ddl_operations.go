@@ -0,0 +1,137 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const ddlOperationsSubsystem = "ddl_operations"
+
+var ddlOperationsThreshold = kingpin.Flag(
+	"collector.ddl_operations.threshold-seconds",
+	"DDL statements running for longer than this many seconds are reported by the ddl_operations collector.",
+).Default("60").Float64()
+
+func init() {
+	registerCollector(ddlOperationsSubsystem, defaultDisabled, ScopeGlobal, NewPGDDLOperationsCollector)
+}
+
+// PGDDLOperationsCollector reports the long-running ALTER statements, such
+// as the validation of a constraint added to a large table, which have no
+// progress view. They are recognized by the leading keywords of their query.
+type PGDDLOperationsCollector struct {
+	log       log.Logger
+	threshold float64
+}
+
+func NewPGDDLOperationsCollector(config collectorConfig) (Collector, error) {
+	return &PGDDLOperationsCollector{
+		log:       config.logger,
+		threshold: *ddlOperationsThreshold,
+	}, nil
+}
+
+var (
+	pgDDLOperationSeconds = newDesc(
+		prometheus.BuildFQName(namespace, "ddl_operation", "seconds"),
+		"Time the longest running DDL statement of the command in the database has been running",
+		[]string{"datname", "command"}, nil,
+		"seconds", "pg_stat_activity.query_start",
+	)
+
+	pgDDLOperationsQuery = `SELECT
+		datname,
+		query,
+		EXTRACT(EPOCH FROM now() - query_start) AS seconds
+	FROM pg_stat_activity
+	WHERE state = 'active'
+		AND query ~* '^[[:space:]]*alter[[:space:]]'
+		AND now() - query_start > $1 * interval '1 second'`
+
+	// ddlCommandRegexp matches the leading keywords of an ALTER statement,
+	// such as ALTER TABLE.
+	ddlCommandRegexp = regexp.MustCompile(`(?i)^\s*(alter\s+(?:materialized\s+view|foreign\s+table|\w+))\s`)
+	// ddlValidateRegexp matches the validation of a constraint, which scans
+	// the table.
+	ddlValidateRegexp = regexp.MustCompile(`(?i)\bvalidate\s+constraint\b`)
+)
+
+func (c PGDDLOperationsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgDDLOperationsQuery, c.threshold)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type key struct {
+		datname, command string
+	}
+	var keys []key
+	longest := make(map[key]float64)
+	for rows.Next() {
+		var datname, query sql.NullString
+		var seconds sql.NullFloat64
+		if err := rows.Scan(&datname, &query, &seconds); err != nil {
+			return err
+		}
+		command := ddlCommand(query.String)
+		if command == "" || !seconds.Valid {
+			continue
+		}
+
+		k := key{datname.String, command}
+		if s, ok := longest[k]; !ok || seconds.Float64 > s {
+			if !ok {
+				keys = append(keys, k)
+			}
+			longest[k] = seconds.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		ch <- prometheus.MustNewConstMetric(
+			pgDDLOperationSeconds,
+			prometheus.GaugeValue, longest[k],
+			k.datname, k.command,
+		)
+	}
+	return nil
+}
+
+// ddlCommand returns the command of a DDL statement, in upper case with
+// single spaces, or "" if query isn't one. The validation of a constraint is
+// reported as VALIDATE CONSTRAINT.
+func ddlCommand(query string) string {
+	m := ddlCommandRegexp.FindStringSubmatch(query)
+	if m == nil {
+		return ""
+	}
+	if ddlValidateRegexp.MatchString(query) {
+		return "VALIDATE CONSTRAINT"
+	}
+	return strings.ToUpper(strings.Join(strings.Fields(m[1]), " "))
+}
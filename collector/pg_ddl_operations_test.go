@@ -0,0 +1,83 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGDDLOperationsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	rows := sqlmock.NewRows([]string{"datname", "query", "seconds"}).
+		AddRow("shop", "ALTER TABLE orders ADD CONSTRAINT orders_total_check CHECK (total >= 0)", 300).
+		AddRow("shop", "alter  table orders alter column note set not null", 900).
+		AddRow("shop", "ALTER TABLE orders VALIDATE CONSTRAINT orders_customer_fkey", 120)
+	mock.ExpectQuery(sanitizeQuery(pgDDLOperationsQuery)).WithArgs(60.0).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDDLOperationsCollector{threshold: 60}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDDLOperationsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "shop", "command": "ALTER TABLE"}, metricType: dto.MetricType_GAUGE, value: 900},
+		{labels: labelMap{"datname": "shop", "command": "VALIDATE CONSTRAINT"}, metricType: dto.MetricType_GAUGE, value: 120},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestDDLCommand(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"ALTER TABLE orders ADD COLUMN note text", "ALTER TABLE"},
+		{"  alter\tindex orders_pkey set tablespace fast", "ALTER INDEX"},
+		{"ALTER MATERIALIZED VIEW totals SET TABLESPACE fast", "ALTER MATERIALIZED VIEW"},
+		{"ALTER TABLE orders VALIDATE CONSTRAINT orders_total_check", "VALIDATE CONSTRAINT"},
+		{"SELECT 'ALTER TABLE orders'", ""},
+	}
+	for _, tt := range tests {
+		if got := ddlCommand(tt.query); got != tt.want {
+			t.Errorf("ddlCommand(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
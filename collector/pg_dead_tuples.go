@@ -0,0 +1,133 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const deadTuplesSubsystem = "dead_tuples"
+
+func init() {
+	registerCollector(deadTuplesSubsystem, defaultDisabled, ScopeGlobal, NewPGDeadTuplesCollector)
+}
+
+// PGDeadTuplesCollector sums the live and dead tuples of the user tables of
+// each database, as a low cardinality rollup of the stat_user_tables
+// collector. pg_stat_user_tables only covers the database it is queried
+// from, so each database is queried from a connection to it.
+type PGDeadTuplesCollector struct {
+	log               log.Logger
+	excludedDatabases []string
+}
+
+func NewPGDeadTuplesCollector(config collectorConfig) (Collector, error) {
+	return &PGDeadTuplesCollector{
+		log:               config.logger,
+		excludedDatabases: config.excludeDatabases,
+	}, nil
+}
+
+var (
+	pgDeadTuplesTotal = newDesc(
+		prometheus.BuildFQName(namespace, "", "dead_tuples_total"),
+		"Estimated number of dead tuples of the user tables of the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_user_tables.n_dead_tup",
+	)
+	pgLiveTuplesTotal = newDesc(
+		prometheus.BuildFQName(namespace, "", "live_tuples_total"),
+		"Estimated number of live tuples of the user tables of the database",
+		[]string{"datname"}, nil,
+		"", "pg_stat_user_tables.n_live_tup",
+	)
+	pgDeadTuplesRatio = newDesc(
+		prometheus.BuildFQName(namespace, "", "dead_tuples_ratio"),
+		"Ratio of dead tuples to all tuples of the user tables of all databases",
+		[]string{}, nil,
+		"", "pg_stat_user_tables.n_dead_tup",
+	)
+
+	pgDeadTuplesQuery = `SELECT
+		n_live_tup,
+		n_dead_tup
+	FROM pg_stat_user_tables`
+)
+
+func (c PGDeadTuplesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	databases, err := instance.connectableDatabases(ctx, c.excludedDatabases)
+	if err != nil {
+		return err
+	}
+
+	var live, dead float64
+	for _, datname := range databases {
+		db, err := instance.getDatabaseDB(datname)
+		if err != nil {
+			level.Warn(c.log).Log("msg", "Failed to connect to database, skipping it", "datname", datname, "err", err)
+			continue
+		}
+
+		dbLive, dbDead, err := c.sumTuples(ctx, db)
+		if err != nil {
+			level.Warn(c.log).Log("msg", "Failed to query pg_stat_user_tables, skipping database", "datname", datname, "err", err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgLiveTuplesTotal,
+			prometheus.GaugeValue, dbLive, datname,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			pgDeadTuplesTotal,
+			prometheus.GaugeValue, dbDead, datname,
+		)
+		live += dbLive
+		dead += dbDead
+	}
+
+	// A cluster without tuples has no meaningful ratio.
+	if live+dead > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			pgDeadTuplesRatio,
+			prometheus.GaugeValue, dead/(live+dead),
+		)
+	}
+	return nil
+}
+
+// sumTuples returns the live and dead tuples of the user tables of db.
+func (c PGDeadTuplesCollector) sumTuples(ctx context.Context, db *sql.DB) (float64, float64, error) {
+	rows, err := db.QueryContext(ctx, pgDeadTuplesQuery)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var live, dead float64
+	for rows.Next() {
+		var nLiveTup, nDeadTup sql.NullFloat64
+		if err := rows.Scan(&nLiveTup, &nDeadTup); err != nil {
+			return 0, 0, err
+		}
+		live += nLiveTup.Float64
+		dead += nDeadTup.Float64
+	}
+	return live, dead, rows.Err()
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGDeadTuplesCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+	appDB, appMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer appDB.Close()
+	reportingDB, reportingMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer reportingDB.Close()
+
+	inst := &instance{
+		db: db,
+		databases: map[string]*sql.DB{
+			"app":       appDB,
+			"reporting": reportingDB,
+		},
+	}
+
+	mock.ExpectQuery(sanitizeQuery(connectableDatabasesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"datname"}).AddRow("app").AddRow("reporting").AddRow("excluded"))
+
+	columns := []string{"n_live_tup", "n_dead_tup"}
+	appMock.ExpectQuery(sanitizeQuery(pgDeadTuplesQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow(6000, 1000).
+			AddRow(1500, 0).
+			AddRow(500, 500))
+	reportingMock.ExpectQuery(sanitizeQuery(pgDeadTuplesQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow(2000, 400).
+			AddRow(0, 100))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDeadTuplesCollector{excludedDatabases: []string{"excluded"}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDeadTuplesCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 8000},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 1500},
+		{labels: labelMap{"datname": "reporting"}, metricType: dto.MetricType_GAUGE, value: 2000},
+		{labels: labelMap{"datname": "reporting"}, metricType: dto.MetricType_GAUGE, value: 500},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 2000.0 / 12000},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	for _, m := range []sqlmock.Sqlmock{mock, appMock, reportingMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	}
+}
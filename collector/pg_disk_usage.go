@@ -0,0 +1,121 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const diskUsageSubsystem = "disk_usage"
+
+func init() {
+	registerCollector(diskUsageSubsystem, defaultDisabled, ScopeGlobal, NewPGDiskUsageCollector)
+}
+
+// PGDiskUsageCollector reports the size of the directories which commonly
+// fill up the disk: pg_wal, the server log directory and the temporary files.
+type PGDiskUsageCollector struct {
+	log      log.Logger
+	failFast bool
+}
+
+func NewPGDiskUsageCollector(config collectorConfig) (Collector, error) {
+	return &PGDiskUsageCollector{log: config.logger, failFast: config.failFast}, nil
+}
+
+var (
+	pgDirSizeBytes = newDesc(
+		prometheus.BuildFQName(namespace, "dir", "size_bytes"),
+		"Total size of the files in the directory",
+		[]string{"dir"}, nil,
+		"bytes", "pg_ls_waldir, pg_ls_logdir and pg_ls_tmpdir.size",
+	)
+
+	pgDiskUsageWALDirQuery = `SELECT size FROM pg_ls_waldir()`
+	pgDiskUsageLogDirQuery = `SELECT size FROM pg_ls_logdir()`
+	pgDiskUsageTmpDirQuery = `SELECT size FROM pg_ls_tmpdir()`
+)
+
+// diskUsageDir is a directory and the query listing the sizes of its files.
+type diskUsageDir struct {
+	name    string
+	query   string
+	version semver.Version
+}
+
+var diskUsageDirs = []diskUsageDir{
+	{name: "pg_wal", query: pgDiskUsageWALDirQuery, version: semver.MustParse("10.0.0")},
+	{name: "log", query: pgDiskUsageLogDirQuery, version: semver.MustParse("10.0.0")},
+	{name: "pgsql_tmp", query: pgDiskUsageTmpDirQuery, version: semver.MustParse("12.0.0")},
+}
+
+func (c PGDiskUsageCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	// A directory which fails doesn't prevent the others from being
+	// reported.
+	errs := updateErrors{failFast: c.failFast}
+	for _, dir := range diskUsageDirs {
+		if instance.version.LT(dir.version) {
+			continue
+		}
+
+		size, err := queryDirSize(ctx, db, dir.query)
+		switch {
+		case isPermissionDenied(err):
+			level.Debug(c.log).Log("msg", "Permission denied listing directory, skipping", "dir", dir.name, "err", err)
+		case isUndefinedFile(err):
+			// The log directory only exists once logging_collector
+			// has written to it.
+			level.Debug(c.log).Log("msg", "Directory does not exist, skipping", "dir", dir.name, "err", err)
+		case err != nil:
+			if errs.add(err) {
+				return errs.err()
+			}
+		default:
+			ch <- prometheus.MustNewConstMetric(
+				pgDirSizeBytes,
+				prometheus.GaugeValue, size, dir.name,
+			)
+		}
+	}
+	return errs.err()
+}
+
+// queryDirSize sums the file sizes returned by query.
+func queryDirSize(ctx context.Context, db *sql.DB, query string) (float64, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var total float64
+	for rows.Next() {
+		var size sql.NullInt64
+		if err := rows.Scan(&size); err != nil {
+			return 0, err
+		}
+		total += float64(size.Int64)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGDiskUsageCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageWALDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(16777216).AddRow(16777216).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageLogDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(1024).AddRow(2048))
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageTmpDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDiskUsageCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDiskUsageCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"dir": "pg_wal"}, metricType: dto.MetricType_GAUGE, value: 50331648},
+		{labels: labelMap{"dir": "log"}, metricType: dto.MetricType_GAUGE, value: 3072},
+		{labels: labelMap{"dir": "pgsql_tmp"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGDiskUsageCollectorPermissionDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("11.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageWALDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageLogDirQuery)).WillReturnError(
+		&pq.Error{Code: "42501", Message: "permission denied for function pg_ls_logdir"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDiskUsageCollector{log: log.NewNopLogger()}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDiskUsageCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"dir": "pg_wal"}, metricType: dto.MetricType_GAUGE, value: 16777216},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGDiskUsageCollectorMissingLogDir(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("12.0.0")}
+
+	// The log directory doesn't exist while logging_collector is off.
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageWALDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageLogDirQuery)).WillReturnError(
+		&pq.Error{Code: "58P01", Message: `could not open directory "log": No such file or directory`})
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageTmpDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(1048576))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDiskUsageCollector{log: log.NewNopLogger()}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGDiskUsageCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"dir": "pg_wal"}, metricType: dto.MetricType_GAUGE, value: 16777216},
+		{labels: labelMap{"dir": "pgsql_tmp"}, metricType: dto.MetricType_GAUGE, value: 1048576},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGDiskUsageCollectorDirError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("12.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageWALDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageLogDirQuery)).WillReturnError(
+		&pq.Error{Code: "XX000", Message: "internal error"})
+	mock.ExpectQuery(sanitizeQuery(pgDiskUsageTmpDirQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"size"}).AddRow(1048576))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGDiskUsageCollector{log: log.NewNopLogger()}
+
+		if err := c.Update(context.Background(), inst, ch); err == nil {
+			t.Error("PGDiskUsageCollector.Update succeeded, want the error of the log directory")
+		}
+	}()
+
+	// The other directories are still reported.
+	expected := []MetricResult{
+		{labels: labelMap{"dir": "pg_wal"}, metricType: dto.MetricType_GAUGE, value: 16777216},
+		{labels: labelMap{"dir": "pgsql_tmp"}, metricType: dto.MetricType_GAUGE, value: 1048576},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const diskUsedSubsystem = "disk_used"
+
+var diskTotalBytes = kingpin.Flag(
+	"disk.total-bytes",
+	"Size in bytes of the volume of the data directory, against which the disk_used collector reports the size of the databases. 0 disables the collector.",
+).Default("0").Uint64()
+
+func init() {
+	registerCollector(diskUsedSubsystem, defaultDisabled, ScopeGlobal, NewPGDiskUsedCollector)
+}
+
+// PGDiskUsedCollector approximates the disk usage of the server on platforms
+// where the filesystem can't be monitored, from the size of its databases and
+// the configured size of the volume. The free space can only be known with
+// access to the host.
+type PGDiskUsedCollector struct {
+	log        log.Logger
+	totalBytes uint64
+}
+
+func NewPGDiskUsedCollector(config collectorConfig) (Collector, error) {
+	return &PGDiskUsedCollector{
+		log:        config.logger,
+		totalBytes: *diskTotalBytes,
+	}, nil
+}
+
+var (
+	pgDiskUsedRatio = newDesc(
+		prometheus.BuildFQName(namespace, "disk", "used_ratio"),
+		"Size of all databases as a fraction of the configured size of the volume of the data directory, WAL and other files are not counted",
+		[]string{}, nil,
+		"", "pg_database_size",
+	)
+
+	pgDiskUsedQuery = `SELECT sum(pg_database_size(oid)) AS size FROM pg_database`
+)
+
+func (c PGDiskUsedCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	if c.totalBytes == 0 {
+		level.Debug(c.log).Log("msg", "disk_used collector requires --disk.total-bytes")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	var size sql.NullFloat64
+	if err := db.QueryRowContext(ctx, pgDiskUsedQuery).Scan(&size); err != nil {
+		return err
+	}
+	if !size.Valid {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgDiskUsedRatio,
+		prometheus.GaugeValue, size.Float64/float64(c.totalBytes),
+	)
+	return nil
+}
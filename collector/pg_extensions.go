@@ -0,0 +1,107 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const extensionsSubsystem = "extensions"
+
+func init() {
+	registerCollector(extensionsSubsystem, defaultDisabled, ScopeDatabase, NewPGExtensionsCollector)
+}
+
+// PGExtensionsCollector reports the extensions installed in the database and
+// whether the server has a newer version of them. The available versions are
+// read from the extension files of the server, which may differ on a
+// replica, so the collector doesn't prefer the replica.
+type PGExtensionsCollector struct {
+	log log.Logger
+}
+
+func NewPGExtensionsCollector(config collectorConfig) (Collector, error) {
+	return &PGExtensionsCollector{log: config.logger}, nil
+}
+
+var (
+	pgExtension = newDesc(
+		prometheus.BuildFQName(namespace, "", "extension"),
+		"Extension installed in the database, its version and schema",
+		[]string{"datname", "name", "version", "schema"}, nil,
+		"", "pg_extension.extversion",
+	)
+	pgExtensionUpdateAvailable = newDesc(
+		prometheus.BuildFQName(namespace, "extension", "update_available"),
+		"Whether ALTER EXTENSION UPDATE would update the extension to a newer default version (1 for yes)",
+		[]string{"datname", "name"}, nil,
+		"", "pg_available_extensions.default_version",
+	)
+
+	pgExtensionsQuery = `SELECT
+		current_database() datname,
+		e.extname,
+		e.extversion,
+		n.nspname,
+		a.default_version
+	FROM pg_extension e
+	JOIN pg_namespace n
+		ON n.oid = e.extnamespace
+	LEFT JOIN pg_available_extensions a
+		ON a.name = e.extname
+	ORDER BY e.extname`
+)
+
+func (c PGExtensionsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgExtensionsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, name, version, schema, defaultVersion sql.NullString
+		if err := rows.Scan(&datname, &name, &version, &schema, &defaultVersion); err != nil {
+			return err
+		}
+		if !datname.Valid || !name.Valid {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgExtension,
+			prometheus.GaugeValue, 1,
+			datname.String, name.String, version.String, schema.String,
+		)
+
+		// The extension files are missing when the extension package was
+		// removed from the server, in which case nothing can be updated.
+		updateAvailable := 0.0
+		if defaultVersion.Valid && defaultVersion.String != version.String {
+			updateAvailable = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgExtensionUpdateAvailable,
+			prometheus.GaugeValue, updateAvailable,
+			datname.String, name.String,
+		)
+	}
+	return rows.Err()
+}
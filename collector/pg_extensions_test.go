@@ -0,0 +1,68 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGExtensionsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "extname", "extversion", "nspname", "default_version"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "pg_stat_statements", "1.8", "public", "1.10").
+		AddRow("app", "plpgsql", "1.0", "pg_catalog", "1.0")
+	mock.ExpectQuery(sanitizeQuery(pgExtensionsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGExtensionsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGExtensionsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app", "name": "pg_stat_statements", "version": "1.8", "schema": "public"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "app", "name": "pg_stat_statements"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "app", "name": "plpgsql", "version": "1.0", "schema": "pg_catalog"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "app", "name": "plpgsql"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const foreignServersSubsystem = "foreign_servers"
+
+// Probing opens connections from the database server to the foreign servers,
+// so it is only done on request.
+var foreignServersProbe = kingpin.Flag(
+	"collector.foreign_servers.probe",
+	"Run a trivial query on each postgres_fdw foreign server through dblink from the database server, and report whether it succeeds. Requires the dblink extension in the database.",
+).Default("false").Bool()
+
+func init() {
+	registerCollector(foreignServersSubsystem, defaultDisabled, ScopeDatabase, NewPGForeignServersCollector)
+}
+
+// PGForeignServersCollector lists the foreign servers of the database and
+// optionally checks that the postgres_fdw ones can be queried.
+type PGForeignServersCollector struct {
+	log         log.Logger
+	probeServer bool
+}
+
+func NewPGForeignServersCollector(config collectorConfig) (Collector, error) {
+	return &PGForeignServersCollector{
+		log:         config.logger,
+		probeServer: *foreignServersProbe,
+	}, nil
+}
+
+var (
+	pgForeignServer = newDesc(
+		prometheus.BuildFQName(namespace, "", "foreign_server"),
+		"Foreign server of the database and its foreign-data wrapper",
+		[]string{"srvname", "fdwname"}, nil,
+		"", "pg_foreign_server.srvname",
+	)
+	pgForeignServerUp = newDesc(
+		prometheus.BuildFQName(namespace, "foreign_server", "up"),
+		"Whether a query on the postgres_fdw foreign server from the database server succeeded (1 for yes, 0 for no)",
+		[]string{"srvname"}, nil,
+		"", "dblink",
+	)
+
+	pgForeignServersQuery = `SELECT
+		s.srvname,
+		w.fdwname
+	FROM pg_foreign_server s
+	JOIN pg_foreign_data_wrapper w
+		ON w.oid = s.srvfdw`
+
+	// dblink connects to a foreign server by name, from the database server
+	// and with the user mapping of the exporter's role, as postgres_fdw
+	// does.
+	pgForeignServerProbeQuery = `SELECT one FROM dblink($1, 'SELECT 1') AS t(one integer)`
+)
+
+func (c PGForeignServersCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgForeignServersQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var probes []string
+	for rows.Next() {
+		var srvname, fdwname sql.NullString
+		if err := rows.Scan(&srvname, &fdwname); err != nil {
+			return err
+		}
+		if !srvname.Valid || !fdwname.Valid {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgForeignServer,
+			prometheus.GaugeValue, 1,
+			srvname.String, fdwname.String,
+		)
+		if c.probeServer && fdwname.String == "postgres_fdw" {
+			probes = append(probes, srvname.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, srvname := range probes {
+		up := 1.0
+		var one int
+		err := db.QueryRowContext(ctx, pgForeignServerProbeQuery, srvname).Scan(&one)
+		if isUndefinedFunction(err) {
+			level.Debug(c.log).Log("msg", "dblink is not installed, not probing foreign servers", "err", err)
+			return nil
+		}
+		if err != nil {
+			level.Debug(c.log).Log("msg", "Failed to query foreign server", "srvname", srvname, "err", err)
+			up = 0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgForeignServerUp,
+			prometheus.GaugeValue, up,
+			srvname,
+		)
+	}
+	return nil
+}
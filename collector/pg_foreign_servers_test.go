@@ -0,0 +1,162 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func foreignServersRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"srvname", "fdwname"}).
+		AddRow("orders", "postgres_fdw").
+		AddRow("archive", "postgres_fdw").
+		AddRow("files", "file_fdw")
+}
+
+func TestPGForeignServersCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	mock.ExpectQuery(sanitizeQuery(pgForeignServersQuery)).WillReturnRows(foreignServersRows())
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGForeignServersCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGForeignServersCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"srvname": "orders", "fdwname": "postgres_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "archive", "fdwname": "postgres_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "files", "fdwname": "file_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGForeignServersCollectorProbe(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// Only postgres_fdw servers are probed, from the database server.
+	mock.ExpectQuery(sanitizeQuery(pgForeignServersQuery)).WillReturnRows(foreignServersRows())
+	mock.ExpectQuery(sanitizeQuery(pgForeignServerProbeQuery)).WithArgs("orders").WillReturnRows(
+		sqlmock.NewRows([]string{"one"}).AddRow(1))
+	mock.ExpectQuery(sanitizeQuery(pgForeignServerProbeQuery)).WithArgs("archive").WillReturnError(
+		&pq.Error{Code: "08001", Message: "could not establish connection"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGForeignServersCollector{log: log.NewNopLogger(), probeServer: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGForeignServersCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"srvname": "orders", "fdwname": "postgres_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "archive", "fdwname": "postgres_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "files", "fdwname": "file_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "orders"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "archive"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGForeignServersCollectorProbeWithoutDblink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	mock.ExpectQuery(sanitizeQuery(pgForeignServersQuery)).WillReturnRows(foreignServersRows())
+	mock.ExpectQuery(sanitizeQuery(pgForeignServerProbeQuery)).WithArgs("orders").WillReturnError(
+		&pq.Error{Code: "42883", Message: "function dblink(unknown, unknown) does not exist"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGForeignServersCollector{log: log.NewNopLogger(), probeServer: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGForeignServersCollector.Update: %s", err)
+		}
+	}()
+
+	// Without dblink only the servers are reported.
+	expected := []MetricResult{
+		{labels: labelMap{"srvname": "orders", "fdwname": "postgres_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "archive", "fdwname": "postgres_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"srvname": "files", "fdwname": "file_fdw"}, metricType: dto.MetricType_GAUGE, value: 1},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const idleConnectionsSubsystem = "idle_connections"
+
+var idleConnectionsThreshold = kingpin.Flag(
+	"collector.idle-connections.threshold-seconds",
+	"Connections idle for longer than this many seconds are counted by the idle_connections collector.",
+).Default("300").Float64()
+
+func init() {
+	registerCollector(idleConnectionsSubsystem, defaultDisabled, ScopeGlobal, NewPGIdleConnectionsCollector)
+}
+
+// PGIdleConnectionsCollector counts the connections which an idle timeout of
+// the configured threshold would terminate. It only reports them, nothing is
+// terminated.
+type PGIdleConnectionsCollector struct {
+	log       log.Logger
+	threshold float64
+}
+
+func NewPGIdleConnectionsCollector(config collectorConfig) (Collector, error) {
+	return &PGIdleConnectionsCollector{
+		log:       config.logger,
+		threshold: *idleConnectionsThreshold,
+	}, nil
+}
+
+var (
+	pgIdleConnectionsOverThreshold = newDesc(
+		prometheus.BuildFQName(namespace, idleConnectionsSubsystem, "over_threshold"),
+		"Number of connections idle for longer than the threshold",
+		[]string{"datname", "usename"}, nil,
+		"", "pg_stat_activity.state_change",
+	)
+
+	// Databases and users with idle connections are reported even when none
+	// of them is over the threshold, so that the count drops to 0.
+	pgIdleConnectionsQuery = `SELECT
+		datname,
+		usename,
+		count(*) FILTER (WHERE EXTRACT(EPOCH FROM now() - state_change) > $1) AS over_threshold
+	FROM pg_stat_activity
+	WHERE state = 'idle'
+		AND state_change IS NOT NULL
+	GROUP BY datname, usename
+	ORDER BY datname, usename`
+)
+
+func (c PGIdleConnectionsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgIdleConnectionsQuery, c.threshold)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, usename sql.NullString
+		var overThreshold int64
+		if err := rows.Scan(&datname, &usename, &overThreshold); err != nil {
+			return err
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgIdleConnectionsOverThreshold,
+			prometheus.GaugeValue, float64(overThreshold),
+			datname.String, usename.String,
+		)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const limitsSubsystem = "limits"
+
+func init() {
+	registerCollector(limitsSubsystem, defaultDisabled, ScopeGlobal, NewPGLimitsCollector)
+}
+
+// PGLimitsCollector reports the usage of resources which are capped by a
+// setting next to that setting, e.g. the client connections and
+// max_connections.
+type PGLimitsCollector struct {
+	log log.Logger
+}
+
+func NewPGLimitsCollector(config collectorConfig) (Collector, error) {
+	return &PGLimitsCollector{log: config.logger}, nil
+}
+
+var (
+	pgLimitUsed = newDesc(
+		prometheus.BuildFQName(namespace, "limit", "used"),
+		"Amount of a resource in use",
+		[]string{"resource"}, nil,
+		"", "pg_stat_activity, pg_stat_replication, pg_replication_slots and pg_prepared_xacts",
+	)
+	pgLimitMax = newDesc(
+		prometheus.BuildFQName(namespace, "limit", "max"),
+		"Maximum amount of a resource allowed by its setting",
+		[]string{"resource"}, nil,
+		"", "pg_settings.setting",
+	)
+
+	pgLimitsQuery = `SELECT
+		'connections' AS resource,
+		(SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend') AS used,
+		current_setting('max_connections')::float AS max
+	UNION ALL
+	SELECT
+		'wal_senders',
+		(SELECT count(*) FROM pg_stat_replication),
+		current_setting('max_wal_senders')::float
+	UNION ALL
+	SELECT
+		'replication_slots',
+		(SELECT count(*) FROM pg_replication_slots),
+		current_setting('max_replication_slots')::float
+	UNION ALL
+	SELECT
+		'prepared_transactions',
+		(SELECT count(*) FROM pg_prepared_xacts),
+		current_setting('max_prepared_transactions')::float`
+)
+
+func (c PGLimitsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// pg_stat_activity.backend_type was added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "limits collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgLimitsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resource string
+		var used, max sql.NullFloat64
+		if err := rows.Scan(&resource, &used, &max); err != nil {
+			return err
+		}
+		if used.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgLimitUsed,
+				prometheus.GaugeValue, used.Float64, resource,
+			)
+		}
+		if max.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgLimitMax,
+				prometheus.GaugeValue, max.Float64, resource,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGLimitsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	rows := sqlmock.NewRows([]string{"resource", "used", "max"}).
+		AddRow("connections", 42, 100).
+		AddRow("wal_senders", 2, 10).
+		AddRow("replication_slots", 3, 10).
+		AddRow("prepared_transactions", 0, 0)
+	mock.ExpectQuery(sanitizeQuery(pgLimitsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGLimitsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGLimitsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"resource": "connections"}, metricType: dto.MetricType_GAUGE, value: 42},
+		{labels: labelMap{"resource": "connections"}, metricType: dto.MetricType_GAUGE, value: 100},
+		{labels: labelMap{"resource": "wal_senders"}, metricType: dto.MetricType_GAUGE, value: 2},
+		{labels: labelMap{"resource": "wal_senders"}, metricType: dto.MetricType_GAUGE, value: 10},
+		{labels: labelMap{"resource": "replication_slots"}, metricType: dto.MetricType_GAUGE, value: 3},
+		{labels: labelMap{"resource": "replication_slots"}, metricType: dto.MetricType_GAUGE, value: 10},
+		{labels: labelMap{"resource": "prepared_transactions"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"resource": "prepared_transactions"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
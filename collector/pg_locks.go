@@ -24,21 +24,23 @@ import (
 const locksSubsystem = "locks"
 
 func init() {
-	registerCollector(locksSubsystem, defaultEnabled, NewPGLocksCollector)
+	registerCollector(locksSubsystem, defaultEnabled, ScopeGlobal, NewPGLocksCollector)
 }
 
 type PGLocksCollector struct {
-	log log.Logger
+	log      log.Logger
+	omitNull bool
 }
 
 func NewPGLocksCollector(config collectorConfig) (Collector, error) {
 	return &PGLocksCollector{
-		log: config.logger,
+		log:      config.logger,
+		omitNull: config.omitNull,
 	}, nil
 }
 
 var (
-	pgLocksDesc = prometheus.NewDesc(
+	pgLocksDesc = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			locksSubsystem,
@@ -46,6 +48,7 @@ var (
 		),
 		"Number of locks",
 		[]string{"datname", "mode"}, nil,
+		"", "pg_locks.mode",
 	)
 
 	pgLocksQuery = `
@@ -111,16 +114,17 @@ func (c PGLocksCollector) Update(ctx context.Context, instance *instance, ch cha
 			continue
 		}
 
-		countMetric := 0.0
-		if count.Valid {
-			countMetric = float64(count.Int64)
+		if count.Valid || !c.omitNull {
+			countMetric := 0.0
+			if count.Valid {
+				countMetric = float64(count.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				pgLocksDesc,
+				prometheus.GaugeValue, countMetric,
+				datname.String, mode.String,
+			)
 		}
-
-		ch <- prometheus.MustNewConstMetric(
-			pgLocksDesc,
-			prometheus.GaugeValue, countMetric,
-			datname.String, mode.String,
-		)
 	}
 	if err := rows.Err(); err != nil {
 		return err
@@ -0,0 +1,134 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const locksWaitingSubsystem = "locks_waiting"
+
+func init() {
+	registerCollector(locksWaitingSubsystem, defaultDisabled, ScopeGlobal, NewPGLocksWaitingCollector)
+}
+
+// PGLocksWaitingCollector splits the locks of each database and mode into
+// the ones which are held and the ones which are waited for.
+type PGLocksWaitingCollector struct {
+	log log.Logger
+}
+
+func NewPGLocksWaitingCollector(config collectorConfig) (Collector, error) {
+	return &PGLocksWaitingCollector{log: config.logger}, nil
+}
+
+var (
+	pgLocksGrantedDesc = newDesc(
+		prometheus.BuildFQName(namespace, locksSubsystem, "granted"),
+		"Number of locks held",
+		[]string{"datname", "mode"}, nil,
+		"", "pg_locks.granted",
+	)
+	pgLocksWaitingDesc = newDesc(
+		prometheus.BuildFQName(namespace, locksSubsystem, "waiting"),
+		"Number of locks waited for",
+		[]string{"datname", "mode"}, nil,
+		"", "pg_locks.granted",
+	)
+
+	// Locks are attributed to the database of their backend, so that the
+	// locks on transaction ids, which have no database, are counted too.
+	// Locks without a backend, e.g. of prepared transactions, fall back to
+	// the database of the locked object.
+	pgLocksWaitingQuery = `
+		SELECT
+		  pg_database.datname as datname,
+		  tmp.mode as mode,
+		  COALESCE(granted, 0) as granted,
+		  COALESCE(waiting, 0) as waiting
+		FROM
+		  (
+		    VALUES
+		      ('accesssharelock'),
+		      ('rowsharelock'),
+		      ('rowexclusivelock'),
+		      ('shareupdateexclusivelock'),
+		      ('sharelock'),
+		      ('sharerowexclusivelock'),
+		      ('exclusivelock'),
+		      ('accessexclusivelock'),
+		      ('sireadlock')
+		  ) AS tmp(mode)
+		  CROSS JOIN pg_database
+		  LEFT JOIN (
+		    SELECT
+		      COALESCE(pg_stat_activity.datid, pg_locks.database) AS database,
+		      lower(pg_locks.mode) AS mode,
+		      count(*) FILTER (WHERE pg_locks.granted) AS granted,
+		      count(*) FILTER (WHERE NOT pg_locks.granted) AS waiting
+		    FROM
+		      pg_locks
+		      LEFT JOIN pg_stat_activity ON pg_stat_activity.pid = pg_locks.pid
+		    GROUP BY
+		      1, 2
+		  ) AS tmp2 ON tmp.mode = tmp2.mode
+		  and pg_database.oid = tmp2.database
+		ORDER BY
+		  1
+	`
+)
+
+func (c PGLocksWaitingCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgLocksWaitingQuery,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, mode sql.NullString
+		var granted, waiting sql.NullInt64
+		if err := rows.Scan(&datname, &mode, &granted, &waiting); err != nil {
+			return err
+		}
+
+		if !datname.Valid || !mode.Valid {
+			continue
+		}
+
+		// Every mode of every database is reported, with zeros for the
+		// databases without locks.
+		ch <- prometheus.MustNewConstMetric(
+			pgLocksGrantedDesc,
+			prometheus.GaugeValue, float64(granted.Int64),
+			datname.String, mode.String,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			pgLocksWaitingDesc,
+			prometheus.GaugeValue, float64(waiting.Int64),
+			datname.String, mode.String,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const logicalReplicationSubsystem = "logical_replication"
+
+func init() {
+	registerCollector(logicalReplicationSubsystem, defaultDisabled, ScopeGlobal, NewPGLogicalReplicationCollector)
+}
+
+type PGLogicalReplicationCollector struct {
+	log log.Logger
+}
+
+func NewPGLogicalReplicationCollector(config collectorConfig) (Collector, error) {
+	return &PGLogicalReplicationCollector{log: config.logger}, nil
+}
+
+var (
+	pgLogicalReplicationConflictsTotal = newDesc(
+		prometheus.BuildFQName(namespace, logicalReplicationSubsystem, "conflicts_total"),
+		"Number of conflicts raised while applying changes of the subscription, by type of conflict",
+		[]string{"subname", "conflict_type"}, nil,
+		"", "pg_stat_subscription_stats.confl_*",
+	)
+
+	// logicalReplicationConflictTypes are the conflict types, in the order
+	// of the columns returned by pgLogicalReplicationConflictsQuery.
+	logicalReplicationConflictTypes = []string{
+		"insert_exists",
+		"update_origin_differs",
+		"update_exists",
+		"update_missing",
+		"delete_origin_differs",
+		"delete_missing",
+		"multiple_unique_conflicts",
+	}
+
+	pgLogicalReplicationConflictsQuery = `SELECT
+		subname,
+		confl_insert_exists,
+		confl_update_origin_differs,
+		confl_update_exists,
+		confl_update_missing,
+		confl_delete_origin_differs,
+		confl_delete_missing,
+		confl_multiple_unique_conflicts
+	FROM pg_stat_subscription_stats`
+)
+
+func (c PGLogicalReplicationCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// The conflict counters were added to pg_stat_subscription_stats in
+	// PostgreSQL 18.
+	if instance.version.LT(semver.MustParse("18.0.0")) {
+		level.Debug(c.log).Log("msg", "logical_replication collector is not supported before PostgreSQL 18")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgLogicalReplicationConflictsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var subname sql.NullString
+		conflicts := make([]sql.NullInt64, len(logicalReplicationConflictTypes))
+		dest := []interface{}{&subname}
+		for i := range conflicts {
+			dest = append(dest, &conflicts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		if !subname.Valid {
+			continue
+		}
+
+		for i, conflictType := range logicalReplicationConflictTypes {
+			if !conflicts[i].Valid {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				pgLogicalReplicationConflictsTotal,
+				prometheus.CounterValue, float64(conflicts[i].Int64),
+				subname.String, conflictType,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGLogicalReplicationCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("18.0.0")}
+
+	columns := []string{
+		"subname",
+		"confl_insert_exists",
+		"confl_update_origin_differs",
+		"confl_update_exists",
+		"confl_update_missing",
+		"confl_delete_origin_differs",
+		"confl_delete_missing",
+		"confl_multiple_unique_conflicts",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("orders_sub", 12, 0, 1, 3, 0, 2, 0)
+	mock.ExpectQuery(sanitizeQuery(pgLogicalReplicationConflictsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGLogicalReplicationCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGLogicalReplicationCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "insert_exists"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "update_origin_differs"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "update_exists"}, metricType: dto.MetricType_COUNTER, value: 1},
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "update_missing"}, metricType: dto.MetricType_COUNTER, value: 3},
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "delete_origin_differs"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "delete_missing"}, metricType: dto.MetricType_COUNTER, value: 2},
+		{labels: labelMap{"subname": "orders_sub", "conflict_type": "multiple_unique_conflicts"}, metricType: dto.MetricType_COUNTER, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const oldestXminSubsystem = "oldest_xmin"
+
+func init() {
+	registerCollector(oldestXminSubsystem, defaultDisabled, ScopeGlobal, NewPGOldestXminCollector)
+}
+
+// PGOldestXminCollector reports the age of the oldest xmin held in the
+// cluster, and what holds it. VACUUM can't remove tuples which are still
+// visible to that xmin.
+type PGOldestXminCollector struct {
+	log log.Logger
+}
+
+func NewPGOldestXminCollector(config collectorConfig) (Collector, error) {
+	return &PGOldestXminCollector{log: config.logger}, nil
+}
+
+var (
+	pgOldestXminAge = newDesc(
+		prometheus.BuildFQName(namespace, oldestXminSubsystem, "age"),
+		"Age in transactions of the oldest xmin held by a replication slot, backend or prepared transaction",
+		[]string{}, nil,
+		"", "pg_replication_slots.xmin, pg_stat_activity.backend_xmin and pg_prepared_xacts.transaction",
+	)
+	pgOldestXminSource = newDesc(
+		prometheus.BuildFQName(namespace, oldestXminSubsystem, "source"),
+		"Holder of the oldest xmin, the source is replication_slot, backend or prepared_transaction",
+		[]string{"source", "holder"}, nil,
+		"", "pg_replication_slots.xmin, pg_stat_activity.backend_xmin and pg_prepared_xacts.transaction",
+	)
+
+	// The holder is the slot name, the pid of the backend or the global
+	// identifier of the prepared transaction.
+	pgOldestXminQuery = `SELECT source, holder, xmin_age FROM (
+		SELECT 'replication_slot' AS source, slot_name::text AS holder, age(xmin) AS xmin_age
+		FROM pg_replication_slots
+		WHERE xmin IS NOT NULL
+		UNION ALL
+		SELECT 'replication_slot', slot_name::text, age(catalog_xmin)
+		FROM pg_replication_slots
+		WHERE catalog_xmin IS NOT NULL
+		UNION ALL
+		SELECT 'backend', pid::text, age(backend_xmin)
+		FROM pg_stat_activity
+		WHERE backend_xmin IS NOT NULL
+		UNION ALL
+		SELECT 'prepared_transaction', gid, age(transaction)
+		FROM pg_prepared_xacts
+	) holders
+	ORDER BY xmin_age DESC
+	LIMIT 1`
+)
+
+func (c PGOldestXminCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgOldestXminQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Nothing is reported when no xmin is held.
+	for rows.Next() {
+		var source, holder sql.NullString
+		var age sql.NullFloat64
+		if err := rows.Scan(&source, &holder, &age); err != nil {
+			return err
+		}
+		if !age.Valid {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgOldestXminAge,
+			prometheus.GaugeValue, age.Float64,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			pgOldestXminSource,
+			prometheus.GaugeValue, 1,
+			source.String, holder.String,
+		)
+	}
+	return rows.Err()
+}
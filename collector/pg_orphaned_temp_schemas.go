@@ -0,0 +1,91 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const orphanedTempSchemasSubsystem = "orphaned_temp_schemas"
+
+func init() {
+	registerCollector(orphanedTempSchemasSubsystem, defaultDisabled, ScopeDatabase, NewPGOrphanedTempSchemasCollector)
+}
+
+// PGOrphanedTempSchemasCollector counts the temporary schemas of the database
+// which still contain tables although the backend owning them is gone, which
+// happens when a backend crashes.
+type PGOrphanedTempSchemasCollector struct {
+	log log.Logger
+}
+
+func NewPGOrphanedTempSchemasCollector(config collectorConfig) (Collector, error) {
+	return &PGOrphanedTempSchemasCollector{log: config.logger}, nil
+}
+
+var (
+	pgOrphanedTempSchemasCount = newDesc(
+		prometheus.BuildFQName(namespace, "orphaned_temp_schemas", "count"),
+		"Number of temporary schemas containing tables whose owning backend no longer exists",
+		[]string{}, nil,
+		"", "pg_namespace.nspname",
+	)
+
+	// Temporary schemas are named after the backend ID of their owner and
+	// are kept empty for reuse when a backend exits cleanly, so only those
+	// still containing tables are leaked. A schema is owned by the backend
+	// with that ID if it is connected to the same database.
+	pgOrphanedTempSchemasQuery = `SELECT
+		count(*) AS schemas
+	FROM pg_namespace n
+	WHERE n.nspname ~ '^pg_temp_[0-9]+$'
+		AND EXISTS (
+			SELECT 1 FROM pg_class c WHERE c.relnamespace = n.oid
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM pg_stat_get_backend_idset() AS b(id)
+			JOIN pg_database d
+				ON d.oid = pg_stat_get_backend_dbid(b.id)
+			WHERE b.id = substring(n.nspname FROM 9)::int
+				AND d.datname = current_database()
+		)`
+)
+
+func (c PGOrphanedTempSchemasCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// pg_stat_get_backend_idset() returns the backend IDs used to name the
+	// temporary schemas since PostgreSQL 16.
+	if instance.version.LT(semver.MustParse("16.0.0")) {
+		level.Debug(c.log).Log("msg", "orphaned_temp_schemas collector is not supported before PostgreSQL 16")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	var schemas sql.NullInt64
+	if err := db.QueryRowContext(ctx, pgOrphanedTempSchemasQuery).Scan(&schemas); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgOrphanedTempSchemasCount,
+		prometheus.GaugeValue, float64(schemas.Int64),
+	)
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const parallelWorkersSubsystem = "parallel_workers"
+
+func init() {
+	registerCollector(parallelWorkersSubsystem, defaultDisabled, ScopeGlobal, NewPGParallelWorkersCollector)
+}
+
+// PGParallelWorkersCollector groups the parallel workers of pg_stat_activity
+// by their leader. Each group is a single parallel query, which activity
+// counts per backend would count once per worker.
+type PGParallelWorkersCollector struct {
+	log log.Logger
+}
+
+func NewPGParallelWorkersCollector(config collectorConfig) (Collector, error) {
+	return &PGParallelWorkersCollector{log: config.logger}, nil
+}
+
+var (
+	pgParallelGroupsActive = newDesc(
+		prometheus.BuildFQName(namespace, "parallel", "groups_active"),
+		"Number of parallel queries, counted as the distinct leaders of active parallel workers",
+		[]string{}, nil,
+		"", "pg_stat_activity.leader_pid",
+	)
+	pgParallelWorkersPerLeader = newDesc(
+		prometheus.BuildFQName(namespace, "parallel", "workers_per_leader"),
+		"Distribution of the number of active parallel workers per parallel query",
+		[]string{}, nil,
+		"", "pg_stat_activity.leader_pid",
+	)
+
+	// parallelWorkersPerLeaderBuckets are the upper bounds of the workers
+	// per leader histogram buckets.
+	parallelWorkersPerLeaderBuckets = []float64{1, 2, 4, 8, 16}
+
+	// Parallel apply workers of logical replication also have a leader_pid
+	// on PostgreSQL 16+, they are not parallel queries.
+	pgParallelWorkersQuery = `SELECT
+		leader_pid,
+		count(*) AS workers
+	FROM pg_stat_activity
+	WHERE backend_type = 'parallel worker'
+		AND state = 'active'
+		AND leader_pid IS NOT NULL
+	GROUP BY leader_pid`
+)
+
+func (c PGParallelWorkersCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	if instance.version.LT(semver.MustParse("13.0.0")) {
+		level.Debug(c.log).Log("msg", "Parallel worker grouping is not supported before PostgreSQL 13")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgParallelWorkersQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var groups uint64
+	var sum float64
+	buckets := make(map[float64]uint64, len(parallelWorkersPerLeaderBuckets))
+	for _, le := range parallelWorkersPerLeaderBuckets {
+		buckets[le] = 0
+	}
+	for rows.Next() {
+		var leaderPID, workers sql.NullInt64
+		if err := rows.Scan(&leaderPID, &workers); err != nil {
+			return err
+		}
+		if !leaderPID.Valid || !workers.Valid {
+			continue
+		}
+		groups++
+		sum += float64(workers.Int64)
+		for _, le := range parallelWorkersPerLeaderBuckets {
+			if float64(workers.Int64) <= le {
+				buckets[le]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgParallelGroupsActive,
+		prometheus.GaugeValue, float64(groups),
+	)
+	ch <- prometheus.MustNewConstHistogram(
+		pgParallelWorkersPerLeader,
+		groups, sum, buckets,
+	)
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGParallelWorkersCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	// Three parallel queries running 2, 2 and 6 workers.
+	rows := sqlmock.NewRows([]string{"leader_pid", "workers"}).
+		AddRow(101, 2).
+		AddRow(202, 2).
+		AddRow(303, 6)
+	mock.ExpectQuery(sanitizeQuery(pgParallelWorkersQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGParallelWorkersCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGParallelWorkersCollector.Update: %s", err)
+		}
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		m := readMetric(<-ch)
+		convey.So(m, convey.ShouldResemble, MetricResult{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 3})
+
+		pb := &dto.Metric{}
+		convey.So((<-ch).Write(pb), convey.ShouldBeNil)
+		buckets := map[float64]uint64{}
+		for _, b := range pb.GetHistogram().GetBucket() {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		convey.So(pb.GetHistogram().GetSampleCount(), convey.ShouldEqual, 3)
+		convey.So(pb.GetHistogram().GetSampleSum(), convey.ShouldEqual, 10)
+		convey.So(buckets, convey.ShouldResemble, map[float64]uint64{1: 0, 2: 2, 4: 2, 8: 3, 16: 3})
+
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
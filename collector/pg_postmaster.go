@@ -23,18 +23,19 @@ import (
 const postmasterSubsystem = "postmaster"
 
 func init() {
-	registerCollector(postmasterSubsystem, defaultDisabled, NewPGPostmasterCollector)
+	registerCollector(postmasterSubsystem, defaultDisabled, ScopeGlobal, NewPGPostmasterCollector)
 }
 
 type PGPostmasterCollector struct {
+	omitNull bool
 }
 
-func NewPGPostmasterCollector(collectorConfig) (Collector, error) {
-	return &PGPostmasterCollector{}, nil
+func NewPGPostmasterCollector(config collectorConfig) (Collector, error) {
+	return &PGPostmasterCollector{omitNull: config.omitNull}, nil
 }
 
 var (
-	pgPostMasterStartTimeSeconds = prometheus.NewDesc(
+	pgPostMasterStartTimeSeconds = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			postmasterSubsystem,
@@ -42,6 +43,7 @@ var (
 		),
 		"Time at which postmaster started",
 		[]string{}, nil,
+		"seconds", "pg_postmaster_start_time",
 	)
 
 	pgPostmasterQuery = "SELECT extract(epoch from pg_postmaster_start_time) from pg_postmaster_start_time();"
@@ -57,13 +59,15 @@ func (c *PGPostmasterCollector) Update(ctx context.Context, instance *instance,
 	if err != nil {
 		return err
 	}
-	startTimeSecondsMetric := 0.0
-	if startTimeSeconds.Valid {
-		startTimeSecondsMetric = startTimeSeconds.Float64
+	if startTimeSeconds.Valid || !c.omitNull {
+		startTimeSecondsMetric := 0.0
+		if startTimeSeconds.Valid {
+			startTimeSecondsMetric = startTimeSeconds.Float64
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgPostMasterStartTimeSeconds,
+			prometheus.GaugeValue, startTimeSecondsMetric,
+		)
 	}
-	ch <- prometheus.MustNewConstMetric(
-		pgPostMasterStartTimeSeconds,
-		prometheus.GaugeValue, startTimeSecondsMetric,
-	)
 	return nil
 }
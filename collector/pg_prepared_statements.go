@@ -0,0 +1,97 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const preparedStatementsSubsystem = "prepared_statements"
+
+func init() {
+	registerCollector(preparedStatementsSubsystem, defaultDisabled, ScopeGlobal, NewPGPreparedStatementsCollector)
+}
+
+// PGPreparedStatementsCollector reports the prepared statements of the
+// exporter's own session. pg_prepared_statements only lists the statements
+// of the current session, and pg_stat_statements doesn't track PREPARE and
+// EXECUTE, so the prepared statements held by other sessions can't be
+// observed.
+type PGPreparedStatementsCollector struct {
+	log log.Logger
+}
+
+func NewPGPreparedStatementsCollector(config collectorConfig) (Collector, error) {
+	return &PGPreparedStatementsCollector{log: config.logger}, nil
+}
+
+var (
+	pgPreparedStatementsCount = newDesc(
+		prometheus.BuildFQName(namespace, preparedStatementsSubsystem, "count"),
+		"Number of prepared statements of the exporter's session, prepared with PREPARE or the extended query protocol",
+		[]string{"from_sql"}, nil,
+		"", "pg_prepared_statements.from_sql",
+	)
+
+	pgPreparedStatementsQuery = `SELECT
+		from_sql,
+		count(*) AS count
+	FROM pg_prepared_statements
+	GROUP BY from_sql`
+)
+
+func (c PGPreparedStatementsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgPreparedStatementsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Both are reported so that the series don't disappear when the
+	// session has no prepared statements.
+	counts := map[string]float64{"true": 0, "false": 0}
+	for rows.Next() {
+		var fromSQL sql.NullBool
+		var count sql.NullInt64
+		if err := rows.Scan(&fromSQL, &count); err != nil {
+			return err
+		}
+		if !fromSQL.Valid {
+			continue
+		}
+		if fromSQL.Bool {
+			counts["true"] += float64(count.Int64)
+		} else {
+			counts["false"] += float64(count.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fromSQL := range []string{"false", "true"} {
+		ch <- prometheus.MustNewConstMetric(
+			pgPreparedStatementsCount,
+			prometheus.GaugeValue, counts[fromSQL],
+			fromSQL,
+		)
+	}
+	return nil
+}
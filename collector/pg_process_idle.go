@@ -23,7 +23,7 @@ import (
 
 func init() {
 	// Making this default disabled because we have no tests for it
-	registerCollector(processIdleSubsystem, defaultDisabled, NewPGProcessIdleCollector)
+	registerCollector(processIdleSubsystem, defaultDisabled, ScopeGlobal, NewPGProcessIdleCollector)
 }
 
 type PGProcessIdleCollector struct {
@@ -36,11 +36,12 @@ func NewPGProcessIdleCollector(config collectorConfig) (Collector, error) {
 	return &PGProcessIdleCollector{log: config.logger}, nil
 }
 
-var pgProcessIdleSeconds = prometheus.NewDesc(
+var pgProcessIdleSeconds = newDesc(
 	prometheus.BuildFQName(namespace, processIdleSubsystem, "seconds"),
 	"Idle time of server processes",
 	[]string{"application_name"},
 	prometheus.Labels{},
+	"seconds", "pg_stat_activity.state_change",
 )
 
 func (PGProcessIdleCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
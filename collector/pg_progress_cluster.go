@@ -0,0 +1,141 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const progressClusterSubsystem = "progress_cluster"
+
+func init() {
+	registerCollector(progressClusterSubsystem, defaultDisabled, ScopeGlobal, NewPGProgressClusterCollector)
+}
+
+// PGProgressClusterCollector reports the progress of the running CLUSTER and
+// VACUUM FULL commands.
+type PGProgressClusterCollector struct {
+	log log.Logger
+}
+
+func NewPGProgressClusterCollector(config collectorConfig) (Collector, error) {
+	return &PGProgressClusterCollector{log: config.logger}, nil
+}
+
+var (
+	progressClusterLabels = []string{"datname", "relname", "command", "phase"}
+
+	pgProgressClusterHeapTuplesScanned = newDesc(
+		prometheus.BuildFQName(namespace, progressClusterSubsystem, "heap_tuples_scanned"),
+		"Number of heap tuples scanned",
+		progressClusterLabels, nil,
+		"", "pg_stat_progress_cluster.heap_tuples_scanned",
+	)
+	pgProgressClusterHeapTuplesWritten = newDesc(
+		prometheus.BuildFQName(namespace, progressClusterSubsystem, "heap_tuples_written"),
+		"Number of heap tuples written",
+		progressClusterLabels, nil,
+		"", "pg_stat_progress_cluster.heap_tuples_written",
+	)
+	pgProgressClusterHeapBlksTotal = newDesc(
+		prometheus.BuildFQName(namespace, progressClusterSubsystem, "heap_blks_total"),
+		"Total number of heap blocks in the table",
+		progressClusterLabels, nil,
+		"", "pg_stat_progress_cluster.heap_blks_total",
+	)
+	pgProgressClusterHeapBlksScanned = newDesc(
+		prometheus.BuildFQName(namespace, progressClusterSubsystem, "heap_blks_scanned"),
+		"Number of heap blocks scanned",
+		progressClusterLabels, nil,
+		"", "pg_stat_progress_cluster.heap_blks_scanned",
+	)
+	pgProgressClusterIndexRebuildCount = newDesc(
+		prometheus.BuildFQName(namespace, progressClusterSubsystem, "index_rebuild_count"),
+		"Number of indexes rebuilt",
+		progressClusterLabels, nil,
+		"", "pg_stat_progress_cluster.index_rebuild_count",
+	)
+
+	// The relation can only be resolved to its name for commands running in
+	// the database the exporter is connected to, the oid is reported otherwise.
+	pgProgressClusterQuery = `SELECT
+		p.datname,
+		COALESCE(c.relname, p.relid::text) AS relname,
+		p.command,
+		p.phase,
+		p.heap_tuples_scanned,
+		p.heap_tuples_written,
+		p.heap_blks_total,
+		p.heap_blks_scanned,
+		p.index_rebuild_count
+	FROM pg_stat_progress_cluster p
+	LEFT JOIN pg_class c
+		ON c.oid = p.relid
+		AND p.datname = current_database()`
+)
+
+func (c PGProgressClusterCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// pg_stat_progress_cluster was added in PostgreSQL 12.
+	if instance.version.LT(semver.MustParse("12.0.0")) {
+		level.Debug(c.log).Log("msg", "progress_cluster collector is not supported before PostgreSQL 12")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgProgressClusterQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, relname, command, phase sql.NullString
+		var heapTuplesScanned, heapTuplesWritten, heapBlksTotal, heapBlksScanned, indexRebuildCount sql.NullInt64
+		if err := rows.Scan(
+			&datname, &relname, &command, &phase,
+			&heapTuplesScanned, &heapTuplesWritten, &heapBlksTotal, &heapBlksScanned, &indexRebuildCount,
+		); err != nil {
+			return err
+		}
+
+		labels := []string{datname.String, relname.String, command.String, phase.String}
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			value sql.NullInt64
+		}{
+			{pgProgressClusterHeapTuplesScanned, heapTuplesScanned},
+			{pgProgressClusterHeapTuplesWritten, heapTuplesWritten},
+			{pgProgressClusterHeapBlksTotal, heapBlksTotal},
+			{pgProgressClusterHeapBlksScanned, heapBlksScanned},
+			{pgProgressClusterIndexRebuildCount, indexRebuildCount},
+		} {
+			ch <- prometheus.MustNewConstMetric(
+				m.desc,
+				prometheus.GaugeValue, float64(m.value.Int64),
+				labels...,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
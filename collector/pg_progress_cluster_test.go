@@ -0,0 +1,111 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+var progressClusterColumns = []string{
+	"datname",
+	"relname",
+	"command",
+	"phase",
+	"heap_tuples_scanned",
+	"heap_tuples_written",
+	"heap_blks_total",
+	"heap_blks_scanned",
+	"index_rebuild_count",
+}
+
+func TestPGProgressClusterCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	rows := sqlmock.NewRows(progressClusterColumns).
+		AddRow("app", "orders", "VACUUM FULL", "seq scanning heap", 150000, 149000, 20000, 8000, 0)
+	mock.ExpectQuery(sanitizeQuery(pgProgressClusterQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGProgressClusterCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGProgressClusterCollector.Update: %s", err)
+		}
+	}()
+
+	labels := labelMap{"datname": "app", "relname": "orders", "command": "VACUUM FULL", "phase": "seq scanning heap"}
+	expected := []MetricResult{
+		{labels: labels, metricType: dto.MetricType_GAUGE, value: 150000},
+		{labels: labels, metricType: dto.MetricType_GAUGE, value: 149000},
+		{labels: labels, metricType: dto.MetricType_GAUGE, value: 20000},
+		{labels: labels, metricType: dto.MetricType_GAUGE, value: 8000},
+		{labels: labels, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGProgressClusterCollectorIdle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgProgressClusterQuery)).WillReturnRows(sqlmock.NewRows(progressClusterColumns))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGProgressClusterCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGProgressClusterCollector.Update: %s", err)
+		}
+	}()
+
+	convey.Convey("No metrics are emitted without running commands", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const publicationsSubsystem = "publications"
+
+func init() {
+	registerCollector(publicationsSubsystem, defaultDisabled, ScopeDatabase, NewPGPublicationsCollector)
+	preferReplica(publicationsSubsystem)
+}
+
+type PGPublicationsCollector struct {
+	log log.Logger
+}
+
+func NewPGPublicationsCollector(config collectorConfig) (Collector, error) {
+	return &PGPublicationsCollector{log: config.logger}, nil
+}
+
+var (
+	pgPublicationInfo = newDesc(
+		prometheus.BuildFQName(namespace, "", "publication"),
+		"Information about a logical replication publication and the operations it publishes",
+		[]string{"pubname", "pubinsert", "pubupdate", "pubdelete"}, nil,
+		"", "pg_publication",
+	)
+	pgPublicationTablesCount = newDesc(
+		prometheus.BuildFQName(namespace, "publication", "tables_count"),
+		"Number of tables published by the publication",
+		[]string{"pubname"}, nil,
+		"", "pg_publication_tables.tablename",
+	)
+
+	pgPublicationsQuery = `SELECT
+		p.pubname,
+		p.pubinsert,
+		p.pubupdate,
+		p.pubdelete,
+		count(pt.tablename) AS tables
+	FROM pg_publication p
+	LEFT JOIN pg_publication_tables pt
+		ON pt.pubname = p.pubname
+	GROUP BY p.pubname, p.pubinsert, p.pubupdate, p.pubdelete`
+)
+
+func (c PGPublicationsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// Logical replication publications were added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "publications collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgPublicationsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pubname sql.NullString
+		var pubinsert, pubupdate, pubdelete sql.NullBool
+		var tables sql.NullInt64
+		if err := rows.Scan(&pubname, &pubinsert, &pubupdate, &pubdelete, &tables); err != nil {
+			return err
+		}
+
+		if !pubname.Valid {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgPublicationInfo,
+			prometheus.GaugeValue, 1,
+			pubname.String,
+			strconv.FormatBool(pubinsert.Bool),
+			strconv.FormatBool(pubupdate.Bool),
+			strconv.FormatBool(pubdelete.Bool),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			pgPublicationTablesCount,
+			prometheus.GaugeValue, float64(tables.Int64),
+			pubname.String,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGPublicationsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	columns := []string{"pubname", "pubinsert", "pubupdate", "pubdelete", "tables"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("orders_pub", true, true, false, 4).
+		AddRow("empty_pub", true, false, false, 0)
+	mock.ExpectQuery(sanitizeQuery(pgPublicationsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGPublicationsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGPublicationsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"pubname": "orders_pub", "pubinsert": "true", "pubupdate": "true", "pubdelete": "false"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"pubname": "orders_pub"}, metricType: dto.MetricType_GAUGE, value: 4},
+		{labels: labelMap{"pubname": "empty_pub", "pubinsert": "true", "pubupdate": "false", "pubdelete": "false"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"pubname": "empty_pub"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
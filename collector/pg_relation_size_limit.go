@@ -0,0 +1,155 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const relationSizeLimitSubsystem = "relation_size_limit"
+
+var (
+	relationSizeLimitThreshold = kingpin.Flag(
+		"collector.relation_size_limit.threshold",
+		"Fraction of the maximum relation size above which the relation_size_limit collector flags a relation.",
+	).Default("0.5").Float64()
+	relationSizeLimitExcludeSchemas = kingpin.Flag(
+		"collector.relation_size_limit.exclude-schema",
+		"Schema to exclude from the relation_size_limit collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	relationSizeLimitLimit = kingpin.Flag(
+		"collector.relation_size_limit.limit",
+		"Maximum number of relations reported by the relation_size_limit collector. The largest relations are reported first.",
+	).Default("10").Int()
+)
+
+func init() {
+	registerCollector(relationSizeLimitSubsystem, defaultDisabled, ScopeDatabase, NewPGRelationSizeLimitCollector)
+	preferReplica(relationSizeLimitSubsystem)
+}
+
+// PGRelationSizeLimitCollector reports how close the largest relations of the
+// database are to the maximum size of a relation, 2^32-1 blocks or about 32TB
+// with the default block size of 8kB. A relation at the limit can't grow any
+// further, and rewriting it to reclaim space needs as much again.
+//
+// The limit applies to each fork of a relation on its own, and the main fork
+// is the only one which can come close to it, so pg_relation_size of the main
+// fork is compared to it. TOAST tables and indexes are relations with their
+// own limit and are reported separately. Relations are stored in segment files
+// of segment_size, 1GB by default, which doesn't change the limit, but a
+// relation near it consists of tens of thousands of files.
+type PGRelationSizeLimitCollector struct {
+	log            log.Logger
+	threshold      float64
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGRelationSizeLimitCollector(config collectorConfig) (Collector, error) {
+	return &PGRelationSizeLimitCollector{
+		log:            config.logger,
+		threshold:      *relationSizeLimitThreshold,
+		excludeSchemas: *relationSizeLimitExcludeSchemas,
+		limit:          *relationSizeLimitLimit,
+	}, nil
+}
+
+var (
+	pgRelationSizeLimitRatio = newDesc(
+		prometheus.BuildFQName(namespace, relationSizeLimitSubsystem, "ratio"),
+		"Size of the main fork of the relation as a fraction of the maximum relation size",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_relation_size",
+	)
+	pgRelationSizeLimitExceeded = newDesc(
+		prometheus.BuildFQName(namespace, relationSizeLimitSubsystem, "threshold_exceeded"),
+		"Whether the size of the relation is above the configured fraction of the maximum relation size (1 for yes, 0 for no)",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_relation_size",
+	)
+
+	// A block number is 32 bits wide and 0xFFFFFFFF is reserved, which bounds a
+	// fork to 2^32-1 blocks.
+	pgRelationSizeLimitQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname,
+		pg_relation_size(c.oid) AS size,
+		current_setting('block_size')::bigint * 4294967295 AS max_size
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'm', 'i', 't')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_temp_'
+		AND n.nspname !~ '^pg_toast_temp_'
+		AND n.nspname <> ALL($1)
+	ORDER BY size DESC NULLS LAST
+	LIMIT $2`
+)
+
+func (c PGRelationSizeLimitCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		pgRelationSizeLimitQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname sql.NullString
+		var size, maxSize sql.NullInt64
+		if err := rows.Scan(&datname, &schemaname, &relname, &size, &maxSize); err != nil {
+			return err
+		}
+		// The size is NULL when the relation was dropped concurrently.
+		if !datname.Valid || !schemaname.Valid || !relname.Valid || !size.Valid || !maxSize.Valid || maxSize.Int64 <= 0 {
+			continue
+		}
+
+		ratio := float64(size.Int64) / float64(maxSize.Int64)
+		exceeded := 0.0
+		if ratio > c.threshold {
+			level.Warn(c.log).Log("msg", "Relation is approaching the maximum relation size", "datname", datname.String,
+				"schemaname", schemaname.String, "relname", relname.String, "ratio", ratio)
+			exceeded = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgRelationSizeLimitRatio,
+			prometheus.GaugeValue, ratio,
+			datname.String, schemaname.String, relname.String,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			pgRelationSizeLimitExceeded,
+			prometheus.GaugeValue, exceeded,
+			datname.String, schemaname.String, relname.String,
+		)
+	}
+	return rows.Err()
+}
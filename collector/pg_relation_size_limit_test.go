@@ -0,0 +1,80 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGRelationSizeLimitCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// The maximum relation size with 8kB blocks, just under 32TB.
+	maxSize := int64(8192) * 4294967295
+	columns := []string{"datname", "schemaname", "relname", "size", "max_size"}
+	rows := sqlmock.NewRows(columns).
+		// An append-only table a few TB short of the limit.
+		AddRow("app", "public", "events", maxSize/10*9, maxSize).
+		AddRow("app", "public", "events_pkey", maxSize/4, maxSize).
+		AddRow("app", "public", "users", 0, maxSize)
+	mock.ExpectQuery(sanitizeQuery(pgRelationSizeLimitQuery)).WithArgs(`{"audit"}`, 10).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGRelationSizeLimitCollector{
+			log:            log.NewNopLogger(),
+			threshold:      0.5,
+			excludeSchemas: []string{"audit"},
+			limit:          10,
+		}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGRelationSizeLimitCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: float64(maxSize/10*9) / float64(maxSize)},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events_pkey"}, metricType: dto.MetricType_GAUGE, value: float64(maxSize/4) / float64(maxSize)},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events_pkey"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "users"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "users"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -22,7 +22,7 @@ import (
 const replicationSubsystem = "replication"
 
 func init() {
-	registerCollector(replicationSubsystem, defaultEnabled, NewPGReplicationCollector)
+	registerCollector(replicationSubsystem, defaultEnabled, ScopeGlobal, NewPGReplicationCollector)
 }
 
 type PGReplicationCollector struct {
@@ -33,7 +33,7 @@ func NewPGReplicationCollector(collectorConfig) (Collector, error) {
 }
 
 var (
-	pgReplicationLag = prometheus.NewDesc(
+	pgReplicationLag = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			replicationSubsystem,
@@ -41,8 +41,9 @@ var (
 		),
 		"Replication lag behind master in seconds",
 		[]string{}, nil,
+		"seconds", "pg_last_xact_replay_timestamp",
 	)
-	pgReplicationIsReplica = prometheus.NewDesc(
+	pgReplicationIsReplica = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			replicationSubsystem,
@@ -50,6 +51,7 @@ var (
 		),
 		"Indicates if the server is a replica",
 		[]string{}, nil,
+		"", "pg_is_in_recovery",
 	)
 
 	pgReplicationQuery = `SELECT
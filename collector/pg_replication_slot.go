@@ -17,6 +17,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/blang/semver/v4"
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -24,19 +25,20 @@ import (
 const replicationSlotSubsystem = "replication_slot"
 
 func init() {
-	registerCollector(replicationSlotSubsystem, defaultEnabled, NewPGReplicationSlotCollector)
+	registerCollector(replicationSlotSubsystem, defaultEnabled, ScopeGlobal, NewPGReplicationSlotCollector)
 }
 
 type PGReplicationSlotCollector struct {
-	log log.Logger
+	log      log.Logger
+	omitNull bool
 }
 
 func NewPGReplicationSlotCollector(config collectorConfig) (Collector, error) {
-	return &PGReplicationSlotCollector{log: config.logger}, nil
+	return &PGReplicationSlotCollector{log: config.logger, omitNull: config.omitNull}, nil
 }
 
 var (
-	pgReplicationSlotCurrentWalDesc = prometheus.NewDesc(
+	pgReplicationSlotCurrentWalDesc = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			replicationSlotSubsystem,
@@ -44,8 +46,9 @@ var (
 		),
 		"current wal lsn value",
 		[]string{"slot_name"}, nil,
+		"bytes", "pg_current_wal_lsn",
 	)
-	pgReplicationSlotCurrentFlushDesc = prometheus.NewDesc(
+	pgReplicationSlotCurrentFlushDesc = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			replicationSlotSubsystem,
@@ -53,8 +56,9 @@ var (
 		),
 		"last lsn confirmed flushed to the replication slot",
 		[]string{"slot_name"}, nil,
+		"bytes", "pg_replication_slots.confirmed_flush_lsn",
 	)
-	pgReplicationSlotIsActiveDesc = prometheus.NewDesc(
+	pgReplicationSlotIsActiveDesc = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			replicationSlotSubsystem,
@@ -62,8 +66,51 @@ var (
 		),
 		"whether the replication slot is active or not",
 		[]string{"slot_name"}, nil,
+		"", "pg_replication_slots.active",
+	)
+	pgReplicationSlotWalStatusDesc = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			replicationSlotSubsystem,
+			"wal_status",
+		),
+		"availability of the WAL files claimed by the replication slot, always 1",
+		[]string{"slot_name", "wal_status"}, nil,
+		"", "pg_replication_slots.wal_status",
+	)
+	pgReplicationSlotWalLostDesc = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			replicationSlotSubsystem,
+			"wal_lost",
+		),
+		"whether WAL files required by the replication slot have been removed, in which case its consumer can't catch up anymore",
+		[]string{"slot_name"}, nil,
+		"", "pg_replication_slots.wal_status",
+	)
+	pgReplicationSlotConfirmedFlushLagDesc = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			replicationSlotSubsystem,
+			"confirmed_flush_lag_bytes",
+		),
+		"bytes of WAL between the current position and the last position confirmed by the consumer of the logical slot",
+		[]string{"slot_name"}, nil,
+		"bytes", "pg_replication_slots.confirmed_flush_lsn",
+	)
+	pgReplicationSlotInactiveSecondsDesc = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			replicationSlotSubsystem,
+			"inactive_seconds",
+		),
+		"time since the replication slot became inactive, 0 for active slots",
+		[]string{"slot_name"}, nil,
+		"seconds", "pg_replication_slots.inactive_since",
 	)
 
+	// confirmed_flush_lsn is only set for logical slots, so confirmed_flush_lag
+	// is NULL for physical ones.
 	pgReplicationSlotQuery = `SELECT
 		slot_name,
 		CASE WHEN pg_is_in_recovery() THEN 
@@ -72,14 +119,63 @@ var (
 		    pg_current_wal_lsn() - '0/0' 
 		END AS current_wal_lsn,
 		COALESCE(confirmed_flush_lsn, '0/0') - '0/0',
-		active
+		active,
+		pg_wal_lsn_diff(
+			CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END,
+			confirmed_flush_lsn
+		) AS confirmed_flush_lag
+	FROM pg_replication_slots;`
+
+	// wal_status was added in PostgreSQL 13.
+	pgReplicationSlotWalStatusQuery = `SELECT
+		slot_name,
+		CASE WHEN pg_is_in_recovery() THEN
+		    pg_last_wal_receive_lsn() - '0/0'
+		ELSE
+		    pg_current_wal_lsn() - '0/0'
+		END AS current_wal_lsn,
+		COALESCE(confirmed_flush_lsn, '0/0') - '0/0',
+		active,
+		pg_wal_lsn_diff(
+			CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END,
+			confirmed_flush_lsn
+		) AS confirmed_flush_lag,
+		wal_status
+	FROM pg_replication_slots;`
+
+	// inactive_since was added in PostgreSQL 17.
+	pgReplicationSlotInactiveSinceQuery = `SELECT
+		slot_name,
+		CASE WHEN pg_is_in_recovery() THEN
+		    pg_last_wal_receive_lsn() - '0/0'
+		ELSE
+		    pg_current_wal_lsn() - '0/0'
+		END AS current_wal_lsn,
+		COALESCE(confirmed_flush_lsn, '0/0') - '0/0',
+		active,
+		pg_wal_lsn_diff(
+			CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END,
+			confirmed_flush_lsn
+		) AS confirmed_flush_lag,
+		wal_status,
+		EXTRACT(EPOCH FROM now() - inactive_since) AS inactive_seconds
 	FROM pg_replication_slots;`
 )
 
-func (PGReplicationSlotCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+func (c PGReplicationSlotCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	query := pgReplicationSlotQuery
+	hasWalStatus := instance.version.GE(semver.MustParse("13.0.0"))
+	hasInactiveSince := instance.version.GE(semver.MustParse("17.0.0"))
+	switch {
+	case hasInactiveSince:
+		query = pgReplicationSlotInactiveSinceQuery
+	case hasWalStatus:
+		query = pgReplicationSlotWalStatusQuery
+	}
+
 	db := instance.getDB()
 	rows, err := db.QueryContext(ctx,
-		pgReplicationSlotQuery)
+		query)
 	if err != nil {
 		return err
 	}
@@ -90,7 +186,17 @@ func (PGReplicationSlotCollector) Update(ctx context.Context, instance *instance
 		var walLSN sql.NullFloat64
 		var flushLSN sql.NullFloat64
 		var isActive sql.NullBool
-		if err := rows.Scan(&slotName, &walLSN, &flushLSN, &isActive); err != nil {
+		var flushLag sql.NullFloat64
+		var walStatus sql.NullString
+		var inactiveSeconds sql.NullFloat64
+		dest := []interface{}{&slotName, &walLSN, &flushLSN, &isActive, &flushLag}
+		if hasWalStatus {
+			dest = append(dest, &walStatus)
+		}
+		if hasInactiveSince {
+			dest = append(dest, &inactiveSeconds)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return err
 		}
 
@@ -103,28 +209,72 @@ func (PGReplicationSlotCollector) Update(ctx context.Context, instance *instance
 			slotNameLabel = slotName.String
 		}
 
-		var walLSNMetric float64
-		if walLSN.Valid {
-			walLSNMetric = walLSN.Float64
-		}
-		ch <- prometheus.MustNewConstMetric(
-			pgReplicationSlotCurrentWalDesc,
-			prometheus.GaugeValue, walLSNMetric, slotNameLabel,
-		)
-		if isActive.Valid && isActive.Bool {
-			var flushLSNMetric float64
-			if flushLSN.Valid {
-				flushLSNMetric = flushLSN.Float64
+		if walLSN.Valid || !c.omitNull {
+			var walLSNMetric float64
+			if walLSN.Valid {
+				walLSNMetric = walLSN.Float64
 			}
 			ch <- prometheus.MustNewConstMetric(
-				pgReplicationSlotCurrentFlushDesc,
-				prometheus.GaugeValue, flushLSNMetric, slotNameLabel,
+				pgReplicationSlotCurrentWalDesc,
+				prometheus.GaugeValue, walLSNMetric, slotNameLabel,
 			)
 		}
+		if isActive.Valid && isActive.Bool {
+			if flushLSN.Valid || !c.omitNull {
+				var flushLSNMetric float64
+				if flushLSN.Valid {
+					flushLSNMetric = flushLSN.Float64
+				}
+				ch <- prometheus.MustNewConstMetric(
+					pgReplicationSlotCurrentFlushDesc,
+					prometheus.GaugeValue, flushLSNMetric, slotNameLabel,
+				)
+			}
+		}
 		ch <- prometheus.MustNewConstMetric(
 			pgReplicationSlotIsActiveDesc,
 			prometheus.GaugeValue, isActiveValue, slotNameLabel,
 		)
+
+		// The lag is NULL for physical slots.
+		if flushLag.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgReplicationSlotConfirmedFlushLagDesc,
+				prometheus.GaugeValue, flushLag.Float64, slotNameLabel,
+			)
+		}
+
+		// wal_status is NULL for slots which have never reserved WAL.
+		if walStatus.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgReplicationSlotWalStatusDesc,
+				prometheus.GaugeValue, 1, slotNameLabel, walStatus.String,
+			)
+			walLostValue := 0.0
+			if walStatus.String == "lost" {
+				walLostValue = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(
+				pgReplicationSlotWalLostDesc,
+				prometheus.GaugeValue, walLostValue, slotNameLabel,
+			)
+		}
+
+		if hasInactiveSince {
+			// inactive_since is NULL while the slot is in use.
+			switch {
+			case isActiveValue == 1:
+				ch <- prometheus.MustNewConstMetric(
+					pgReplicationSlotInactiveSecondsDesc,
+					prometheus.GaugeValue, 0, slotNameLabel,
+				)
+			case inactiveSeconds.Valid:
+				ch <- prometheus.MustNewConstMetric(
+					pgReplicationSlotInactiveSecondsDesc,
+					prometheus.GaugeValue, inactiveSeconds.Float64, slotNameLabel,
+				)
+			}
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return err
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/smartystreets/goconvey/convey"
@@ -31,9 +32,9 @@ func TestPgReplicationSlotCollectorActive(t *testing.T) {
 
 	inst := &instance{db: db}
 
-	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active"}
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag"}
 	rows := sqlmock.NewRows(columns).
-		AddRow("test_slot", 5, 3, true)
+		AddRow("test_slot", 5, 3, true, nil)
 	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotQuery)).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
@@ -72,9 +73,9 @@ func TestPgReplicationSlotCollectorInActive(t *testing.T) {
 
 	inst := &instance{db: db}
 
-	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active"}
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag"}
 	rows := sqlmock.NewRows(columns).
-		AddRow("test_slot", 6, 12, false)
+		AddRow("test_slot", 6, 12, false, nil)
 	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotQuery)).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
@@ -113,9 +114,9 @@ func TestPgReplicationSlotCollectorActiveNil(t *testing.T) {
 
 	inst := &instance{db: db}
 
-	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active"}
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag"}
 	rows := sqlmock.NewRows(columns).
-		AddRow("test_slot", 6, 12, nil)
+		AddRow("test_slot", 6, 12, nil, nil)
 	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotQuery)).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
@@ -153,9 +154,9 @@ func TestPgReplicationSlotCollectorTestNilValues(t *testing.T) {
 
 	inst := &instance{db: db}
 
-	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active"}
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag"}
 	rows := sqlmock.NewRows(columns).
-		AddRow(nil, nil, nil, true)
+		AddRow(nil, nil, nil, true, nil)
 	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotQuery)).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
@@ -184,3 +185,161 @@ func TestPgReplicationSlotCollectorTestNilValues(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestPgReplicationSlotCollectorConfirmedFlushLag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// Physical slots have no confirmed_flush_lsn, so no lag is reported.
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("logical_slot", 5000, 3000, true, 2000).
+		AddRow("physical_slot", 5000, nil, true, nil)
+	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGReplicationSlotCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGReplicationSlotCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"slot_name": "logical_slot"}, value: 5000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "logical_slot"}, value: 3000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "logical_slot"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "logical_slot"}, value: 2000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "physical_slot"}, value: 5000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "physical_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "physical_slot"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPgReplicationSlotCollectorWalStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("13.0.0")}
+
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag", "wal_status"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("reserved_slot", 10, 8, true, nil, "reserved").
+		AddRow("extended_slot", 10, 2, true, nil, "extended").
+		AddRow("lost_slot", 10, 1, false, nil, "lost")
+	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotWalStatusQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGReplicationSlotCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGReplicationSlotCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"slot_name": "reserved_slot"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "reserved_slot"}, value: 8, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "reserved_slot"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "reserved_slot", "wal_status": "reserved"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "reserved_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "extended_slot"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "extended_slot"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "extended_slot"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "extended_slot", "wal_status": "extended"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "extended_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "lost_slot"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "lost_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "lost_slot", "wal_status": "lost"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "lost_slot"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPgReplicationSlotCollectorInactiveSince(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("17.0.0")}
+
+	columns := []string{"slot_name", "current_wal_lsn", "confirmed_flush_lsn", "active", "confirmed_flush_lag", "wal_status", "inactive_seconds"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("active_slot", 10, 8, true, nil, "reserved", nil).
+		// Inactive for two days.
+		AddRow("stale_slot", 10, 1, false, nil, "extended", 172800)
+	mock.ExpectQuery(sanitizeQuery(pgReplicationSlotInactiveSinceQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGReplicationSlotCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGReplicationSlotCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"slot_name": "active_slot"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "active_slot"}, value: 8, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "active_slot"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "active_slot", "wal_status": "reserved"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "active_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "active_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "stale_slot"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "stale_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "stale_slot", "wal_status": "extended"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "stale_slot"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"slot_name": "stale_slot"}, value: 172800, metricType: dto.MetricType_GAUGE},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
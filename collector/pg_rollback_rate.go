@@ -0,0 +1,134 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const rollbackRateSubsystem = "rollback_rate"
+
+func init() {
+	registerCollector(rollbackRateSubsystem, defaultDisabled, ScopeGlobal, NewPGRollbackRateCollector)
+}
+
+// PGRollbackRateCollector reports the rate of rolled back transactions of
+// each database between scrapes, as a proxy for application errors since
+// PostgreSQL doesn't count errors raised by functions or triggers.
+type PGRollbackRateCollector struct {
+	log               log.Logger
+	excludedDatabases []string
+}
+
+func NewPGRollbackRateCollector(config collectorConfig) (Collector, error) {
+	return &PGRollbackRateCollector{
+		log:               config.logger,
+		excludedDatabases: config.excludeDatabases,
+	}, nil
+}
+
+var (
+	pgDatabaseRollbacksPerSecond = newDesc(
+		prometheus.BuildFQName(namespace, databaseSubsystem, "rollbacks_per_second"),
+		"Rate of rolled back transactions in the database per second since the previous scrape",
+		[]string{"datname"}, nil,
+		"", "pg_stat_database.xact_rollback",
+	)
+
+	pgRollbackRateQuery = `SELECT
+		datname,
+		xact_rollback
+	FROM pg_stat_database
+	WHERE datname IS NOT NULL`
+)
+
+func (c PGRollbackRateCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgRollbackRateQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	rollbacks := make(map[string]float64)
+	for rows.Next() {
+		var datname sql.NullString
+		var rollback sql.NullFloat64
+		if err := rows.Scan(&datname, &rollback); err != nil {
+			return err
+		}
+		if !datname.Valid || !rollback.Valid || sliceContains(c.excludedDatabases, datname.String) {
+			continue
+		}
+		rollbacks[datname.String] = rollback.Float64
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rates := instance.getSamples().rollbackSample.rates(rollbacks, time.Now())
+	datnames := make([]string, 0, len(rates))
+	for datname := range rates {
+		datnames = append(datnames, datname)
+	}
+	sort.Strings(datnames)
+	for _, datname := range datnames {
+		ch <- prometheus.MustNewConstMetric(
+			pgDatabaseRollbacksPerSecond,
+			prometheus.GaugeValue, rates[datname], datname,
+		)
+	}
+	return nil
+}
+
+// rollbackSample holds the rollback counters of the databases of an instance
+// at the previous scrape.
+type rollbackSample struct {
+	mtx       sync.Mutex
+	rollbacks map[string]float64
+	time      time.Time
+}
+
+// rates records the current rollback counters and returns the rate of
+// rollbacks per second of each database since the previous sample. Databases
+// which weren't sampled before, or whose statistics were reset, are omitted.
+func (s *rollbackSample) rates(rollbacks map[string]float64, now time.Time) map[string]float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	lastRollbacks, lastTime := s.rollbacks, s.time
+	s.rollbacks, s.time = rollbacks, now
+
+	rates := make(map[string]float64)
+	elapsed := now.Sub(lastTime).Seconds()
+	if lastTime.IsZero() || elapsed <= 0 {
+		return rates
+	}
+	for datname, rollback := range rollbacks {
+		last, ok := lastRollbacks[datname]
+		if !ok || rollback < last {
+			continue
+		}
+		rates[datname] = (rollback - last) / elapsed
+	}
+	return rates
+}
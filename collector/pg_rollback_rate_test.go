@@ -0,0 +1,88 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGRollbackRateCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "xact_rollback"}
+	mock.ExpectQuery(sanitizeQuery(pgRollbackRateQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("app", 100).AddRow("reports", 50))
+	mock.ExpectQuery(sanitizeQuery(pgRollbackRateQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("app", 160).AddRow("reports", 50))
+
+	scrape := func() map[string]float64 {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			c := PGRollbackRateCollector{}
+
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGRollbackRateCollector.Update: %s", err)
+			}
+		}()
+
+		rates := make(map[string]float64)
+		for m := range ch {
+			r := readMetric(m)
+			rates[r.labels["datname"]] = r.value
+		}
+		return rates
+	}
+
+	convey.Convey("Rollbacks are attributed to the database they happened in", t, func() {
+		convey.So(scrape(), convey.ShouldBeEmpty)
+		time.Sleep(time.Millisecond)
+
+		rates := scrape()
+		convey.So(rates, convey.ShouldHaveLength, 2)
+		convey.So(rates["app"], convey.ShouldBeGreaterThan, 0)
+		convey.So(rates["reports"], convey.ShouldEqual, 0)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestRollbackSampleRates(t *testing.T) {
+	s := &rollbackSample{}
+	start := time.Now()
+	s.rates(map[string]float64{"app": 100, "reports": 50}, start)
+
+	// The statistics of reports were reset and new_db was created.
+	rates := s.rates(map[string]float64{"app": 160, "reports": 5, "new_db": 3}, start.Add(10*time.Second))
+	if len(rates) != 1 || rates["app"] != 6 {
+		t.Errorf("Expected only app at 6 rollbacks per second, got %v", rates)
+	}
+
+	rates = s.rates(map[string]float64{"app": 160, "reports": 25, "new_db": 3}, start.Add(20*time.Second))
+	if len(rates) != 3 || rates["app"] != 0 || rates["reports"] != 2 || rates["new_db"] != 0 {
+		t.Errorf("Unexpected rates %v", rates)
+	}
+}
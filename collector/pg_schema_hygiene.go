@@ -0,0 +1,196 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const schemaHygieneSubsystem = "schema_hygiene"
+
+var schemaHygieneExcludeSchemas = kingpin.Flag(
+	"collector.schema_hygiene.exclude-schema",
+	"Schema to exclude from the schema_hygiene collector. Repeat the flag to exclude multiple schemas.",
+).Strings()
+
+func init() {
+	registerCollector(schemaHygieneSubsystem, defaultDisabled, ScopeDatabase, NewPGSchemaHygieneCollector)
+	preferReplica(schemaHygieneSubsystem)
+}
+
+// PGSchemaHygieneCollector reports schema problems which are found in the
+// catalogs of the database the exporter is connected to.
+type PGSchemaHygieneCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+}
+
+func NewPGSchemaHygieneCollector(config collectorConfig) (Collector, error) {
+	return &PGSchemaHygieneCollector{
+		log:            config.logger,
+		excludeSchemas: *schemaHygieneExcludeSchemas,
+	}, nil
+}
+
+var (
+	pgTablesWithoutPK = newDesc(
+		prometheus.BuildFQName(namespace, "", "tables_without_pk"),
+		"Ordinary table without a primary key",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_constraint.contype",
+	)
+
+	pgSchemaHygieneTablesWithoutPKQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind = 'r'
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'
+		AND NOT EXISTS (
+			SELECT 1
+			FROM pg_index i
+			WHERE i.indrelid = c.oid AND i.indisprimary
+		)`
+
+	pgInvalidIndexes = newDesc(
+		prometheus.BuildFQName(namespace, "", "invalid_indexes"),
+		"Index which is invalid or not ready, e.g. left behind by a failed CREATE INDEX CONCURRENTLY",
+		[]string{"datname", "schemaname", "indexrelname"},
+		prometheus.Labels{},
+		"", "pg_index.indisvalid",
+	)
+
+	pgSchemaHygieneInvalidIndexesQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname AS indexrelname
+	FROM pg_index i
+	JOIN pg_class c
+		ON c.oid = i.indexrelid
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE (NOT i.indisvalid OR NOT i.indisready)
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'`
+
+	pgNotValidConstraints = newDesc(
+		prometheus.BuildFQName(namespace, "", "not_valid_constraints"),
+		"Constraint which was added as NOT VALID and has not been validated since",
+		[]string{"datname", "schemaname", "conname"},
+		prometheus.Labels{},
+		"", "pg_constraint.convalidated",
+	)
+
+	pgSchemaHygieneNotValidConstraintsQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		con.conname
+	FROM pg_constraint con
+	JOIN pg_namespace n
+		ON n.oid = con.connamespace
+	WHERE NOT con.convalidated
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'`
+
+	pgTablesReplicaIdentityInsufficient = newDesc(
+		prometheus.BuildFQName(namespace, "tables", "replica_identity_insufficient"),
+		"Ordinary table whose replica identity doesn't identify its rows, so that UPDATE and DELETE fail once it is published for logical replication",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_class.relreplident",
+	)
+
+	// The default replica identity is the primary key, a table without one
+	// has no replica identity, like one whose replica identity is NOTHING.
+	pgSchemaHygieneReplicaIdentityQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind = 'r'
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'
+		AND (
+			c.relreplident = 'n'
+			OR (
+				c.relreplident = 'd'
+				AND NOT EXISTS (
+					SELECT 1
+					FROM pg_index i
+					WHERE i.indrelid = c.oid AND i.indisprimary
+				)
+			)
+		)`
+)
+
+func (c PGSchemaHygieneCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	if err := c.updateObjects(ctx, db, pgSchemaHygieneTablesWithoutPKQuery, pgTablesWithoutPK, ch); err != nil {
+		return err
+	}
+	if err := c.updateObjects(ctx, db, pgSchemaHygieneInvalidIndexesQuery, pgInvalidIndexes, ch); err != nil {
+		return err
+	}
+	if err := c.updateObjects(ctx, db, pgSchemaHygieneNotValidConstraintsQuery, pgNotValidConstraints, ch); err != nil {
+		return err
+	}
+	return c.updateObjects(ctx, db, pgSchemaHygieneReplicaIdentityQuery, pgTablesReplicaIdentityInsufficient, ch)
+}
+
+// updateObjects emits desc for every object returned by query, which must
+// return the database, schema and name of the object.
+func (c PGSchemaHygieneCollector) updateObjects(ctx context.Context, db *sql.DB, query string, desc *prometheus.Desc, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, name sql.NullString
+		if err := rows.Scan(&datname, &schemaname, &name); err != nil {
+			return err
+		}
+		if !datname.Valid || !schemaname.Valid || !name.Valid {
+			continue
+		}
+		// Filtering is done here instead of in the query to avoid a
+		// NOT IN query with a variable number of parameters.
+		if sliceContains(c.excludeSchemas, schemaname.String) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			desc,
+			prometheus.GaugeValue, 1,
+			datname.String, schemaname.String, name.String,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
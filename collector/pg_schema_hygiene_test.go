@@ -0,0 +1,168 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGSchemaHygieneCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// Tables with a primary key are filtered out by the query, so only the
+	// offending tables are returned.
+	columns := []string{"datname", "schemaname", "relname"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "public", "events").
+		AddRow("app", "audit", "log_entries").
+		AddRow("app", "staging", "import_tmp")
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneTablesWithoutPKQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneInvalidIndexesQuery)).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneNotValidConstraintsQuery)).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneReplicaIdentityQuery)).WillReturnRows(sqlmock.NewRows(columns))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGSchemaHygieneCollector{excludeSchemas: []string{"staging"}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGSchemaHygieneCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "app", "schemaname": "audit", "relname": "log_entries"}, metricType: dto.MetricType_GAUGE, value: 1},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGSchemaHygieneCollectorInvalidObjects(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "schemaname", "name"}
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneTablesWithoutPKQuery)).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneInvalidIndexesQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("app", "public", "orders_customer_id_idx"))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneNotValidConstraintsQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("app", "public", "orders_customer_id_fkey"))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneReplicaIdentityQuery)).WillReturnRows(sqlmock.NewRows(columns))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGSchemaHygieneCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGSchemaHygieneCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "app", "schemaname": "public", "indexrelname": "orders_customer_id_idx"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "conname": "orders_customer_id_fkey"}, metricType: dto.MetricType_GAUGE, value: 1},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGSchemaHygieneCollectorReplicaIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// events has the default replica identity and no primary key, so the
+	// query returns it as well as a table without a primary key.
+	columns := []string{"datname", "schemaname", "relname"}
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneTablesWithoutPKQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("app", "public", "events"))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneInvalidIndexesQuery)).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneNotValidConstraintsQuery)).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(sanitizeQuery(pgSchemaHygieneReplicaIdentityQuery)).WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("app", "public", "events"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGSchemaHygieneCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGSchemaHygieneCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []struct {
+		desc   *prometheus.Desc
+		result MetricResult
+	}{
+		{pgTablesWithoutPK, MetricResult{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: 1}},
+		{pgTablesReplicaIdentityInsufficient, MetricResult{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "events"}, metricType: dto.MetricType_GAUGE, value: 1}},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := <-ch
+			convey.So(m.Desc(), convey.ShouldEqual, expect.desc)
+			convey.So(expect.result, convey.ShouldResemble, readMetric(m))
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const schemaSizeSubsystem = "schema_size"
+
+var schemaSizeIncludeSystemSchemas = kingpin.Flag(
+	"collector.schema_size.include-system-schemas",
+	"Also report the size of pg_catalog, information_schema and the temporary schemas in the schema_size collector.",
+).Default("false").Bool()
+
+func init() {
+	registerCollector(schemaSizeSubsystem, defaultDisabled, ScopeDatabase, NewPGSchemaSizeCollector)
+	preferReplica(schemaSizeSubsystem)
+}
+
+// PGSchemaSizeCollector reports the total size of the tables of each schema,
+// between the size of the database and the size of its tables.
+type PGSchemaSizeCollector struct {
+	log                  log.Logger
+	includeSystemSchemas bool
+}
+
+func NewPGSchemaSizeCollector(config collectorConfig) (Collector, error) {
+	return &PGSchemaSizeCollector{
+		log:                  config.logger,
+		includeSystemSchemas: *schemaSizeIncludeSystemSchemas,
+	}, nil
+}
+
+var (
+	pgSchemaSizeBytes = newDesc(
+		prometheus.BuildFQName(namespace, "schema", "size_bytes"),
+		"Total size of the tables and materialized views of the schema, including their indexes and TOAST tables, in bytes",
+		[]string{"datname", "schemaname"},
+		prometheus.Labels{},
+		"bytes", "pg_total_relation_size",
+	)
+
+	// pg_total_relation_size already includes the indexes and TOAST table of
+	// a table, so only tables and materialized views are summed.
+	pgSchemaSizeQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		pg_total_relation_size(c.oid) AS size
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'm')
+		AND (
+			$1
+			OR (
+				n.nspname NOT IN ('pg_catalog', 'information_schema')
+				AND n.nspname !~ '^pg_temp_'
+			)
+		)`
+)
+
+func (c PGSchemaSizeCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgSchemaSizeQuery, c.includeSystemSchemas)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var datname string
+	var schemas []string
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var rowDatname, schemaname sql.NullString
+		var size sql.NullInt64
+		if err := rows.Scan(&rowDatname, &schemaname, &size); err != nil {
+			return err
+		}
+		// The size is NULL when the table was dropped concurrently.
+		if !rowDatname.Valid || !schemaname.Valid || !size.Valid {
+			continue
+		}
+		datname = rowDatname.String
+		if _, ok := sizes[schemaname.String]; !ok {
+			schemas = append(schemas, schemaname.String)
+		}
+		sizes[schemaname.String] += size.Int64
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, schemaname := range schemas {
+		ch <- prometheus.MustNewConstMetric(
+			pgSchemaSizeBytes,
+			prometheus.GaugeValue, float64(sizes[schemaname]),
+			datname, schemaname,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const serverTimeSubsystem = "server_time"
+
+func init() {
+	registerCollector(serverTimeSubsystem, defaultDisabled, ScopeGlobal, NewPGServerTimeCollector)
+}
+
+// PGServerTimeCollector reports the clock of the server, so that the skew
+// between the clocks of the server and of Prometheus, which breaks lag
+// calculations based on timestamps, shows as the difference to the time of
+// the scrape, i.e. time() - pg_server_now_seconds.
+type PGServerTimeCollector struct {
+	log log.Logger
+}
+
+func NewPGServerTimeCollector(config collectorConfig) (Collector, error) {
+	return &PGServerTimeCollector{log: config.logger}, nil
+}
+
+var (
+	pgServerNowSeconds = newDesc(
+		prometheus.BuildFQName(namespace, "server", "now_seconds"),
+		"Current time of the server as a Unix timestamp",
+		[]string{}, nil,
+		"seconds", "now",
+	)
+
+	pgServerTimeQuery = "SELECT extract(epoch from now()) AS now"
+)
+
+func (c PGServerTimeCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	row := db.QueryRowContext(ctx,
+		pgServerTimeQuery)
+
+	var now sql.NullFloat64
+	if err := row.Scan(&now); err != nil {
+		return err
+	}
+	if !now.Valid {
+		return ErrNoData
+	}
+	ch <- prometheus.MustNewConstMetric(
+		pgServerNowSeconds,
+		prometheus.GaugeValue, now.Float64,
+	)
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const sharedMemorySubsystem = "shared_memory"
+
+func init() {
+	registerCollector(sharedMemorySubsystem, defaultDisabled, ScopeGlobal, NewPGSharedMemoryCollector)
+}
+
+// PGSharedMemoryCollector reports the size of the shared memory of the
+// server and whether it is backed by huge pages.
+type PGSharedMemoryCollector struct {
+	log log.Logger
+}
+
+func NewPGSharedMemoryCollector(config collectorConfig) (Collector, error) {
+	return &PGSharedMemoryCollector{log: config.logger}, nil
+}
+
+var (
+	pgSharedBuffersBytes = newDesc(
+		prometheus.BuildFQName(namespace, "shared_buffers", "bytes"),
+		"Size of the shared buffers, the setting shared_buffers, in bytes",
+		[]string{}, nil,
+		"bytes", "pg_settings.shared_buffers",
+	)
+	pgSharedMemorySizeBytes = newDesc(
+		prometheus.BuildFQName(namespace, sharedMemorySubsystem, "size_bytes"),
+		"Size of the main shared memory area of the server, the setting shared_memory_size, in bytes",
+		[]string{}, nil,
+		"bytes", "pg_settings.shared_memory_size",
+	)
+	pgSharedMemorySizeHugePages = newDesc(
+		prometheus.BuildFQName(namespace, sharedMemorySubsystem, "size_huge_pages"),
+		"Number of huge pages needed for the main shared memory area, the setting shared_memory_size_in_huge_pages",
+		[]string{}, nil,
+		"", "pg_settings.shared_memory_size_in_huge_pages",
+	)
+	pgHugePagesStatus = newDesc(
+		prometheus.BuildFQName(namespace, "huge_pages", "status"),
+		"Whether huge pages are requested for the main shared memory area, the setting huge_pages is on, off or try",
+		[]string{"setting"}, nil,
+		"", "pg_settings.huge_pages",
+	)
+
+	pgSharedMemoryQuery = `SELECT name, setting, unit
+	FROM pg_settings
+	WHERE name = ANY($1)`
+)
+
+// sharedMemorySettings are the settings read by the collector, the
+// shared_memory_size settings were added in PostgreSQL 15.
+var (
+	sharedMemorySettings     = []string{"shared_buffers", "huge_pages"}
+	sharedMemorySizeSettings = []string{"shared_memory_size", "shared_memory_size_in_huge_pages"}
+)
+
+func (c PGSharedMemoryCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	names := pq.StringArray{}
+	names = append(names, sharedMemorySettings...)
+	if instance.version.GE(semver.MustParse("15.0.0")) {
+		names = append(names, sharedMemorySizeSettings...)
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgSharedMemoryQuery, names)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, setting, unit sql.NullString
+		if err := rows.Scan(&name, &setting, &unit); err != nil {
+			return err
+		}
+		if !setting.Valid {
+			continue
+		}
+
+		switch name.String {
+		case "shared_buffers", "shared_memory_size":
+			bytes, err := settingBytes(setting.String, unit.String)
+			if err != nil {
+				return err
+			}
+			desc := pgSharedBuffersBytes
+			if name.String == "shared_memory_size" {
+				desc = pgSharedMemorySizeBytes
+			}
+			ch <- prometheus.MustNewConstMetric(
+				desc,
+				prometheus.GaugeValue, bytes,
+			)
+		case "shared_memory_size_in_huge_pages":
+			pages, err := strconv.ParseFloat(setting.String, 64)
+			if err != nil {
+				return err
+			}
+			// -1 means huge pages aren't supported by the platform.
+			if pages < 0 {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				pgSharedMemorySizeHugePages,
+				prometheus.GaugeValue, pages,
+			)
+		case "huge_pages":
+			ch <- prometheus.MustNewConstMetric(
+				pgHugePagesStatus,
+				prometheus.GaugeValue, 1,
+				setting.String,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// settingUnitBytes are the sizes in bytes of the memory units of pg_settings.
+var settingUnitBytes = map[string]float64{
+	"B":    1,
+	"kB":   1 << 10,
+	"MB":   1 << 20,
+	"GB":   1 << 30,
+	"TB":   1 << 40,
+	"4kB":  4 << 10,
+	"8kB":  8 << 10,
+	"16kB": 16 << 10,
+	"32kB": 32 << 10,
+	"64kB": 64 << 10,
+	"16MB": 16 << 20,
+	"32MB": 32 << 20,
+	"64MB": 64 << 20,
+}
+
+// settingBytes converts a memory setting of pg_settings to bytes.
+func settingBytes(setting, unit string) (float64, error) {
+	value, err := strconv.ParseFloat(setting, 64)
+	if err != nil {
+		return 0, err
+	}
+	size, ok := settingUnitBytes[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown memory unit %q", unit)
+	}
+	return value * size, nil
+}
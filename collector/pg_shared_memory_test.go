@@ -0,0 +1,128 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGSharedMemoryCollector(t *testing.T) {
+	cases := []struct {
+		version  string
+		args     string
+		rows     [][]driver.Value
+		expected []MetricResult
+	}{
+		{
+			version: "15.0.0",
+			args:    `{"shared_buffers","huge_pages","shared_memory_size","shared_memory_size_in_huge_pages"}`,
+			rows: [][]driver.Value{
+				// 128MB of 8kB blocks.
+				{"shared_buffers", "16384", "8kB"},
+				{"huge_pages", "try", nil},
+				{"shared_memory_size", "143", "MB"},
+				{"shared_memory_size_in_huge_pages", "72", nil},
+			},
+			expected: []MetricResult{
+				{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 134217728},
+				{labels: labelMap{"setting": "try"}, metricType: dto.MetricType_GAUGE, value: 1},
+				{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 149946368},
+				{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 72},
+			},
+		},
+		{
+			version: "14.0.0",
+			args:    `{"shared_buffers","huge_pages"}`,
+			rows: [][]driver.Value{
+				{"shared_buffers", "16384", "8kB"},
+				{"huge_pages", "off", nil},
+			},
+			expected: []MetricResult{
+				{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 134217728},
+				{labels: labelMap{"setting": "off"}, metricType: dto.MetricType_GAUGE, value: 1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.version, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db, version: semver.MustParse(tc.version)}
+
+			rows := sqlmock.NewRows([]string{"name", "setting", "unit"})
+			for _, row := range tc.rows {
+				rows.AddRow(row...)
+			}
+			mock.ExpectQuery(sanitizeQuery(pgSharedMemoryQuery)).WithArgs(tc.args).WillReturnRows(rows)
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				defer close(ch)
+				c := PGSharedMemoryCollector{}
+
+				if err := c.Update(context.Background(), inst, ch); err != nil {
+					t.Errorf("Error calling PGSharedMemoryCollector.Update: %s", err)
+				}
+			}()
+
+			convey.Convey("Metrics comparison", t, func() {
+				for _, expect := range tc.expected {
+					m := readMetric(<-ch)
+					convey.So(expect, convey.ShouldResemble, m)
+				}
+				_, ok := <-ch
+				convey.So(ok, convey.ShouldBeFalse)
+			})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
+
+func TestSettingBytes(t *testing.T) {
+	cases := []struct {
+		setting, unit string
+		expected      float64
+	}{
+		{"16384", "8kB", 134217728},
+		{"143", "MB", 149946368},
+		{"4096", "kB", 4194304},
+		{"2", "GB", 2147483648},
+	}
+	for _, tc := range cases {
+		got, err := settingBytes(tc.setting, tc.unit)
+		if err != nil {
+			t.Fatalf("settingBytes(%q, %q): %s", tc.setting, tc.unit, err)
+		}
+		if got != tc.expected {
+			t.Errorf("settingBytes(%q, %q) = %v, want %v", tc.setting, tc.unit, got, tc.expected)
+		}
+	}
+	if _, err := settingBytes("1", "min"); err == nil {
+		t.Error("settingBytes accepted a unit which isn't a memory unit")
+	}
+}
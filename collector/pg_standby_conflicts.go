@@ -0,0 +1,113 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"math"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const standbyConflictsSubsystem = "standby_conflicts"
+
+func init() {
+	registerCollector(standbyConflictsSubsystem, defaultDisabled, ScopeGlobal, NewPGStandbyConflictsCollector)
+}
+
+// PGStandbyConflictsCollector reports how close the queries of a standby are
+// to being cancelled because of recovery conflicts. Once WAL replay has been
+// delayed by max_standby_streaming_delay, the queries holding it back are
+// cancelled.
+type PGStandbyConflictsCollector struct {
+	log log.Logger
+}
+
+func NewPGStandbyConflictsCollector(config collectorConfig) (Collector, error) {
+	return &PGStandbyConflictsCollector{log: config.logger}, nil
+}
+
+var (
+	pgStandbyConflictRisk = newDesc(
+		prometheus.BuildFQName(namespace, "standby", "conflict_risk_seconds"),
+		"Seconds left before the recovery apply delay reaches max_standby_streaming_delay and queries conflicting with recovery are cancelled",
+		[]string{}, nil,
+		"seconds", "max_standby_streaming_delay and pg_last_xact_replay_timestamp",
+	)
+	pgStandbyConflicts = newDesc(
+		prometheus.BuildFQName(namespace, "standby", "conflicts_total"),
+		"Number of queries cancelled because of conflicts with recovery in all databases",
+		[]string{}, nil,
+		"", "pg_stat_database_conflicts",
+	)
+
+	// The apply delay is 0 when everything received has been replayed, as
+	// pg_last_xact_replay_timestamp doesn't advance on an idle primary.
+	// max_standby_streaming_delay is in milliseconds.
+	pgStandbyConflictsQuery = `SELECT
+		pg_is_in_recovery() AS is_standby,
+		CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE GREATEST(0, EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()))
+		END AS apply_delay,
+		(SELECT setting::float8 FROM pg_settings WHERE name = 'max_standby_streaming_delay') AS max_delay,
+		(SELECT sum(confl_tablespace + confl_lock + confl_snapshot + confl_bufferpin + confl_deadlock)
+			FROM pg_stat_database_conflicts) AS conflicts`
+)
+
+func (c PGStandbyConflictsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// pg_last_wal_receive_lsn was added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "standby_conflicts collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	var isStandby bool
+	var applyDelay, maxDelay, conflicts sql.NullFloat64
+	if err := db.QueryRowContext(ctx, pgStandbyConflictsQuery).Scan(&isStandby, &applyDelay, &maxDelay, &conflicts); err != nil {
+		return err
+	}
+	if !isStandby {
+		level.Debug(c.log).Log("msg", "standby_conflicts collector only reports on standbys")
+		return ErrNoData
+	}
+
+	if conflicts.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgStandbyConflicts,
+			prometheus.CounterValue, conflicts.Float64,
+		)
+	}
+
+	// Queries are never cancelled with a delay of -1.
+	if !maxDelay.Valid || maxDelay.Float64 < 0 || !applyDelay.Valid {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(
+		pgStandbyConflictRisk,
+		prometheus.GaugeValue, standbyConflictRisk(applyDelay.Float64, maxDelay.Float64/1000),
+	)
+	return nil
+}
+
+// standbyConflictRisk returns the seconds left before an apply delay reaches
+// the limit, or 0 once it has.
+func standbyConflictRisk(applyDelay, limit float64) float64 {
+	return math.Max(0, limit-applyDelay)
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGStandbyConflictsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	// Replay is 12 seconds behind, with the default delay of 30 seconds.
+	rows := sqlmock.NewRows([]string{"is_standby", "apply_delay", "max_delay", "conflicts"}).
+		AddRow(true, 12.5, 30000, 7)
+	mock.ExpectQuery(sanitizeQuery(pgStandbyConflictsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStandbyConflictsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStandbyConflictsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, metricType: dto.MetricType_COUNTER, value: 7},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 17.5},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStandbyConflictsCollectorPrimary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	rows := sqlmock.NewRows([]string{"is_standby", "apply_delay", "max_delay", "conflicts"}).
+		AddRow(false, nil, 30000, 0)
+	mock.ExpectQuery(sanitizeQuery(pgStandbyConflictsQuery)).WillReturnRows(rows)
+
+	c := PGStandbyConflictsCollector{log: log.NewNopLogger()}
+	ch := make(chan prometheus.Metric)
+	if err := c.Update(context.Background(), inst, ch); !IsNoDataError(err) {
+		t.Errorf("got %v, want ErrNoData", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestStandbyConflictRisk(t *testing.T) {
+	tests := []struct {
+		applyDelay, limit, want float64
+	}{
+		{applyDelay: 0, limit: 30, want: 30},
+		{applyDelay: 12.5, limit: 30, want: 17.5},
+		{applyDelay: 30, limit: 30, want: 0},
+		{applyDelay: 45, limit: 30, want: 0},
+	}
+	for _, tt := range tests {
+		if got := standbyConflictRisk(tt.applyDelay, tt.limit); got != tt.want {
+			t.Errorf("standbyConflictRisk(%v, %v) = %v, want %v", tt.applyDelay, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"sort"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statActivitySubsystem = "stat_activity"
+
+var (
+	// Client addresses can be high cardinality, so they are only tracked on request.
+	statActivityTrackClientAddr = kingpin.Flag(
+		"collector.stat_activity.track-client-addr",
+		"Expose the number of connections per client address.",
+	).Default("false").Bool()
+	statActivityAggregateClientAddr = kingpin.Flag(
+		"collector.stat_activity.aggregate-client-addr",
+		"Aggregate client addresses to their /24 (IPv4) or /64 (IPv6) network.",
+	).Default("false").Bool()
+)
+
+func init() {
+	registerCollector(statActivitySubsystem, defaultDisabled, ScopeGlobal, NewPGStatActivityCollector)
+}
+
+type PGStatActivityCollector struct {
+	log                 log.Logger
+	trackClientAddr     bool
+	aggregateClientAddr bool
+	failFast            bool
+}
+
+func NewPGStatActivityCollector(config collectorConfig) (Collector, error) {
+	return &PGStatActivityCollector{
+		log:                 config.logger,
+		trackClientAddr:     *statActivityTrackClientAddr,
+		aggregateClientAddr: *statActivityAggregateClientAddr,
+		failFast:            config.failFast,
+	}, nil
+}
+
+var (
+	statActivityConnectionsByClient = newDesc(
+		prometheus.BuildFQName(namespace, "connections", "by_client"),
+		"Number of connections per client address. Connections without a client address (unix sockets and background processes) are reported as local",
+		[]string{"client_addr"},
+		prometheus.Labels{},
+		"", "pg_stat_activity.client_addr",
+	)
+
+	statActivityQueryAge = newDesc(
+		prometheus.BuildFQName(namespace, "query", "age_seconds"),
+		"Distribution of the time since the current query of active client backends started",
+		[]string{"datname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_activity.query_start",
+	)
+
+	statActivityBackendsByType = newDesc(
+		prometheus.BuildFQName(namespace, "backends", "by_type"),
+		"Number of backends per type, e.g. client backend, autovacuum worker or walsender",
+		[]string{"backend_type"},
+		prometheus.Labels{},
+		"", "pg_stat_activity.backend_type",
+	)
+
+	statActivityMaxXactSeconds = newDesc(
+		prometheus.BuildFQName(namespace, "activity", "max_xact_seconds"),
+		"Time since the oldest open transaction of the client backends in the state started",
+		[]string{"state"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_activity.xact_start",
+	)
+	statActivityMaxQuerySeconds = newDesc(
+		prometheus.BuildFQName(namespace, "activity", "max_query_seconds"),
+		"Time since the oldest running statement of the client backends in the state started",
+		[]string{"state"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_activity.query_start",
+	)
+
+	// statActivityQueryAgeBuckets are the upper bounds of the query age
+	// histogram buckets, in seconds.
+	statActivityQueryAgeBuckets = []float64{1, 10, 60, 600}
+
+	statActivityQueryAgeQuery = `SELECT
+		datname,
+		EXTRACT(EPOCH FROM now() - query_start) AS age
+	FROM pg_stat_activity
+	WHERE state = 'active'
+		AND backend_type = 'client backend'
+		AND query_start IS NOT NULL
+		AND pid <> pg_backend_pid()`
+
+	statActivityBackendTypeQuery = `SELECT
+		backend_type,
+		count(*) AS backends
+	FROM pg_stat_activity
+	WHERE backend_type IS NOT NULL
+	GROUP BY backend_type
+	ORDER BY backend_type`
+
+	// Only active backends are running a statement, for the other states
+	// query_start is the start of the last statement, which has finished.
+	statActivityXactQuery = `SELECT
+		state,
+		max(EXTRACT(EPOCH FROM now() - xact_start)) AS max_xact_seconds,
+		max(EXTRACT(EPOCH FROM now() - query_start)) FILTER (WHERE state = 'active') AS max_query_seconds
+	FROM pg_stat_activity
+	WHERE backend_type = 'client backend'
+		AND state IS NOT NULL
+		AND pid <> pg_backend_pid()
+	GROUP BY state
+	ORDER BY state`
+
+	statActivityClientAddrQuery = `SELECT
+		host(client_addr) AS client_addr,
+		count(*) AS connections
+	FROM pg_stat_activity
+	GROUP BY client_addr`
+)
+
+func (c PGStatActivityCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	errs := updateErrors{failFast: c.failFast}
+
+	// backend_type was added in PostgreSQL 10.
+	if instance.version.GE(semver.MustParse("10.0.0")) {
+		if errs.add(c.updateQueryAge(ctx, db, ch)) {
+			return errs.err()
+		}
+		if errs.add(c.updateBackendTypes(ctx, db, ch)) {
+			return errs.err()
+		}
+		if errs.add(c.updateXact(ctx, db, ch)) {
+			return errs.err()
+		}
+	}
+
+	if c.trackClientAddr {
+		errs.add(c.updateClientAddr(ctx, db, ch))
+	}
+	return errs.err()
+}
+
+// updateQueryAge emits a histogram of the age of the running queries per
+// database, which shows a pile-up of medium-long queries that the age of the
+// single longest query would hide.
+func (c PGStatActivityCollector) updateQueryAge(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx,
+		statActivityQueryAgeQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type histogram struct {
+		count   uint64
+		sum     float64
+		buckets map[float64]uint64
+	}
+	histograms := make(map[string]*histogram)
+	for rows.Next() {
+		var datname sql.NullString
+		var age sql.NullFloat64
+		if err := rows.Scan(&datname, &age); err != nil {
+			return err
+		}
+		if !datname.Valid || !age.Valid {
+			continue
+		}
+
+		h, ok := histograms[datname.String]
+		if !ok {
+			h = &histogram{buckets: make(map[float64]uint64, len(statActivityQueryAgeBuckets))}
+			for _, le := range statActivityQueryAgeBuckets {
+				h.buckets[le] = 0
+			}
+			histograms[datname.String] = h
+		}
+		h.count++
+		h.sum += age.Float64
+		for _, le := range statActivityQueryAgeBuckets {
+			if age.Float64 <= le {
+				h.buckets[le]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	datnames := make([]string, 0, len(histograms))
+	for datname := range histograms {
+		datnames = append(datnames, datname)
+	}
+	sort.Strings(datnames)
+
+	for _, datname := range datnames {
+		h := histograms[datname]
+		ch <- prometheus.MustNewConstHistogram(
+			statActivityQueryAge,
+			h.count, h.sum, h.buckets,
+			datname,
+		)
+	}
+	return nil
+}
+
+// updateBackendTypes emits the number of backends of each type, which shows
+// e.g. an unexpected number of walsenders or logical replication workers.
+func (c PGStatActivityCollector) updateBackendTypes(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx,
+		statActivityBackendTypeQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var backendType sql.NullString
+		var count sql.NullInt64
+		if err := rows.Scan(&backendType, &count); err != nil {
+			return err
+		}
+		if !backendType.Valid || !count.Valid {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statActivityBackendsByType,
+			prometheus.GaugeValue,
+			float64(count.Int64),
+			backendType.String,
+		)
+	}
+	return rows.Err()
+}
+
+// updateXact emits the age of the oldest transaction and of the oldest
+// running statement per state, which tells a slow statement from an
+// application holding a transaction open, e.g. idle in transaction with an
+// old transaction but no running statement.
+func (c PGStatActivityCollector) updateXact(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx,
+		statActivityXactQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var state sql.NullString
+		var xactSeconds, querySeconds sql.NullFloat64
+		if err := rows.Scan(&state, &xactSeconds, &querySeconds); err != nil {
+			return err
+		}
+		if !state.Valid {
+			continue
+		}
+		// Idle backends have no open transaction.
+		if xactSeconds.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				statActivityMaxXactSeconds,
+				prometheus.GaugeValue,
+				xactSeconds.Float64,
+				state.String,
+			)
+		}
+		if querySeconds.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				statActivityMaxQuerySeconds,
+				prometheus.GaugeValue,
+				querySeconds.Float64,
+				state.String,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+func (c PGStatActivityCollector) updateClientAddr(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx,
+		statActivityClientAddrQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Aggregating addresses can fold several rows into the same label, so
+	// sum them up before emitting.
+	connections := make(map[string]float64)
+	for rows.Next() {
+		var clientAddr sql.NullString
+		var count sql.NullInt64
+		if err := rows.Scan(&clientAddr, &count); err != nil {
+			return err
+		}
+
+		clientAddrLabel := "local"
+		if clientAddr.Valid {
+			clientAddrLabel = clientAddr.String
+			if c.aggregateClientAddr {
+				clientAddrLabel = clientNetwork(clientAddrLabel)
+			}
+		}
+
+		if count.Valid {
+			connections[clientAddrLabel] += float64(count.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	clientAddrs := make([]string, 0, len(connections))
+	for clientAddr := range connections {
+		clientAddrs = append(clientAddrs, clientAddr)
+	}
+	sort.Strings(clientAddrs)
+
+	for _, clientAddr := range clientAddrs {
+		ch <- prometheus.MustNewConstMetric(
+			statActivityConnectionsByClient,
+			prometheus.GaugeValue,
+			connections[clientAddr],
+			clientAddr,
+		)
+	}
+	return nil
+}
+
+// clientNetwork returns the /24 (IPv4) or /64 (IPv6) network of addr in CIDR
+// notation. Addresses which can't be parsed are returned unchanged.
+func clientNetwork(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return addr
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		n := net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}
+		return n.String()
+	}
+	n := net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}
+	return n.String()
+}
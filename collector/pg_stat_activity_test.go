@@ -0,0 +1,341 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGStatActivityCollectorClientAddr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"client_addr", "connections"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("10.0.0.1", 5).
+		AddRow("10.0.0.2", 3).
+		AddRow("2001:db8::1", 1).
+		AddRow(nil, 7)
+	mock.ExpectQuery(sanitizeQuery(statActivityClientAddrQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatActivityCollector{trackClientAddr: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatActivityCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"client_addr": "10.0.0.1"}, metricType: dto.MetricType_GAUGE, value: 5},
+		{labels: labelMap{"client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 3},
+		{labels: labelMap{"client_addr": "2001:db8::1"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"client_addr": "local"}, metricType: dto.MetricType_GAUGE, value: 7},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStatActivityCollectorClientAddrAggregated(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"client_addr", "connections"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("10.0.0.1", 5).
+		AddRow("10.0.0.2", 3).
+		AddRow("10.0.1.2", 2).
+		AddRow("2001:db8::1", 1).
+		AddRow(nil, 7)
+	mock.ExpectQuery(sanitizeQuery(statActivityClientAddrQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatActivityCollector{trackClientAddr: true, aggregateClientAddr: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatActivityCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"client_addr": "10.0.0.0/24"}, metricType: dto.MetricType_GAUGE, value: 8},
+		{labels: labelMap{"client_addr": "10.0.1.0/24"}, metricType: dto.MetricType_GAUGE, value: 2},
+		{labels: labelMap{"client_addr": "2001:db8::/64"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"client_addr": "local"}, metricType: dto.MetricType_GAUGE, value: 7},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStatActivityCollectorQueryAge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	columns := []string{"datname", "age"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", 0.5).
+		AddRow("app", 5).
+		AddRow("app", 30).
+		AddRow("app", 45).
+		AddRow("app", 300).
+		AddRow("app", 3600).
+		AddRow("reporting", 120).
+		AddRow(nil, 10)
+	mock.ExpectQuery(sanitizeQuery(statActivityQueryAgeQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(statActivityBackendTypeQuery)).WillReturnRows(sqlmock.NewRows([]string{"backend_type", "backends"}))
+	mock.ExpectQuery(sanitizeQuery(statActivityXactQuery)).WillReturnRows(sqlmock.NewRows([]string{"state", "max_xact_seconds", "max_query_seconds"}))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatActivityCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatActivityCollector.Update: %s", err)
+		}
+	}()
+
+	type histogram struct {
+		datname string
+		count   uint64
+		sum     float64
+		buckets map[float64]uint64
+	}
+	expected := []histogram{
+		{datname: "app", count: 6, sum: 3980.5, buckets: map[float64]uint64{1: 1, 10: 2, 60: 4, 600: 5}},
+		{datname: "reporting", count: 1, sum: 120, buckets: map[float64]uint64{1: 0, 10: 0, 60: 0, 600: 1}},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			pb := &dto.Metric{}
+			convey.So((<-ch).Write(pb), convey.ShouldBeNil)
+
+			buckets := map[float64]uint64{}
+			for _, b := range pb.GetHistogram().GetBucket() {
+				buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+			}
+			convey.So(pb.GetLabel()[0].GetValue(), convey.ShouldEqual, expect.datname)
+			convey.So(pb.GetHistogram().GetSampleCount(), convey.ShouldEqual, expect.count)
+			convey.So(pb.GetHistogram().GetSampleSum(), convey.ShouldEqual, expect.sum)
+			convey.So(buckets, convey.ShouldResemble, expect.buckets)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStatActivityCollectorBackendTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(statActivityQueryAgeQuery)).WillReturnRows(sqlmock.NewRows([]string{"datname", "age"}))
+	rows := sqlmock.NewRows([]string{"backend_type", "backends"}).
+		AddRow("autovacuum worker", 3).
+		AddRow("checkpointer", 1).
+		AddRow("client backend", 42).
+		AddRow("logical replication worker", 2).
+		AddRow("walsender", 5)
+	mock.ExpectQuery(sanitizeQuery(statActivityBackendTypeQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(statActivityXactQuery)).WillReturnRows(sqlmock.NewRows([]string{"state", "max_xact_seconds", "max_query_seconds"}))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatActivityCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatActivityCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"backend_type": "autovacuum worker"}, metricType: dto.MetricType_GAUGE, value: 3},
+		{labels: labelMap{"backend_type": "checkpointer"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"backend_type": "client backend"}, metricType: dto.MetricType_GAUGE, value: 42},
+		{labels: labelMap{"backend_type": "logical replication worker"}, metricType: dto.MetricType_GAUGE, value: 2},
+		{labels: labelMap{"backend_type": "walsender"}, metricType: dto.MetricType_GAUGE, value: 5},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStatActivityCollectorXact(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(statActivityQueryAgeQuery)).WillReturnRows(sqlmock.NewRows([]string{"datname", "age"}))
+	mock.ExpectQuery(sanitizeQuery(statActivityBackendTypeQuery)).WillReturnRows(sqlmock.NewRows([]string{"backend_type", "backends"}))
+	rows := sqlmock.NewRows([]string{"state", "max_xact_seconds", "max_query_seconds"}).
+		// A slow statement, most of the transaction is spent running it.
+		AddRow("active", 125.5, 120.25).
+		// No statement is running, but the transaction is an hour old.
+		AddRow("idle in transaction", 3600, nil).
+		AddRow("idle", nil, nil)
+	mock.ExpectQuery(sanitizeQuery(statActivityXactQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatActivityCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatActivityCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"state": "active"}, metricType: dto.MetricType_GAUGE, value: 125.5},
+		{labels: labelMap{"state": "active"}, metricType: dto.MetricType_GAUGE, value: 120.25},
+		{labels: labelMap{"state": "idle in transaction"}, metricType: dto.MetricType_GAUGE, value: 3600},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStatActivityCollectorPartialFailure(t *testing.T) {
+	cases := []struct {
+		name     string
+		failFast bool
+		expected []MetricResult
+	}{
+		{
+			name: "continue",
+			expected: []MetricResult{
+				{labels: labelMap{"client_addr": "10.0.0.1"}, metricType: dto.MetricType_GAUGE, value: 5},
+			},
+		},
+		{
+			name:     "fail fast",
+			failFast: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+			mock.ExpectQuery(sanitizeQuery(statActivityQueryAgeQuery)).WillReturnRows(sqlmock.NewRows([]string{"datname", "age"}))
+			mock.ExpectQuery(sanitizeQuery(statActivityBackendTypeQuery)).WillReturnError(errors.New("canceling statement due to statement timeout"))
+			if !tc.failFast {
+				mock.ExpectQuery(sanitizeQuery(statActivityXactQuery)).WillReturnRows(
+					sqlmock.NewRows([]string{"state", "max_xact_seconds", "max_query_seconds"}))
+				mock.ExpectQuery(sanitizeQuery(statActivityClientAddrQuery)).WillReturnRows(
+					sqlmock.NewRows([]string{"client_addr", "connections"}).AddRow("10.0.0.1", 5))
+			}
+
+			ch := make(chan prometheus.Metric)
+			errCh := make(chan error, 1)
+			go func() {
+				defer close(ch)
+				c := PGStatActivityCollector{trackClientAddr: true, failFast: tc.failFast}
+				errCh <- c.Update(context.Background(), inst, ch)
+			}()
+
+			convey.Convey("Metrics comparison", t, func() {
+				for _, expect := range tc.expected {
+					m := readMetric(<-ch)
+					convey.So(expect, convey.ShouldResemble, m)
+				}
+				_, ok := <-ch
+				convey.So(ok, convey.ShouldBeFalse)
+				convey.So(<-errCh, convey.ShouldNotBeNil)
+			})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -23,82 +24,111 @@ import (
 const bgWriterSubsystem = "stat_bgwriter"
 
 func init() {
-	registerCollector(bgWriterSubsystem, defaultEnabled, NewPGStatBGWriterCollector)
+	registerCollector(bgWriterSubsystem, defaultEnabled, ScopeGlobal, NewPGStatBGWriterCollector)
 }
 
 type PGStatBGWriterCollector struct {
+	omitNull bool
 }
 
-func NewPGStatBGWriterCollector(collectorConfig) (Collector, error) {
-	return &PGStatBGWriterCollector{}, nil
+func NewPGStatBGWriterCollector(config collectorConfig) (Collector, error) {
+	return &PGStatBGWriterCollector{omitNull: config.omitNull}, nil
 }
 
 var (
-	statBGWriterCheckpointsTimedDesc = prometheus.NewDesc(
+	statBGWriterCheckpointsTimedDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "checkpoints_timed_total"),
 		"Number of scheduled checkpoints that have been performed",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.checkpoints_timed",
 	)
-	statBGWriterCheckpointsReqDesc = prometheus.NewDesc(
+	statBGWriterCheckpointsReqDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "checkpoints_req_total"),
 		"Number of requested checkpoints that have been performed",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.checkpoints_req",
 	)
-	statBGWriterCheckpointsReqTimeDesc = prometheus.NewDesc(
+	statBGWriterCheckpointsReqTimeDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "checkpoint_write_time_total"),
 		"Total amount of time that has been spent in the portion of checkpoint processing where files are written to disk, in milliseconds",
 		[]string{},
 		prometheus.Labels{},
+		"milliseconds", "pg_stat_bgwriter.checkpoint_write_time",
 	)
-	statBGWriterCheckpointsSyncTimeDesc = prometheus.NewDesc(
+	statBGWriterCheckpointsSyncTimeDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "checkpoint_sync_time_total"),
 		"Total amount of time that has been spent in the portion of checkpoint processing where files are synchronized to disk, in milliseconds",
 		[]string{},
 		prometheus.Labels{},
+		"milliseconds", "pg_stat_bgwriter.checkpoint_sync_time",
 	)
-	statBGWriterBuffersCheckpointDesc = prometheus.NewDesc(
+	statBGWriterBuffersCheckpointDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "buffers_checkpoint_total"),
 		"Number of buffers written during checkpoints",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.buffers_checkpoint",
 	)
-	statBGWriterBuffersCleanDesc = prometheus.NewDesc(
+	statBGWriterBuffersCleanDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "buffers_clean_total"),
 		"Number of buffers written by the background writer",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.buffers_clean",
 	)
-	statBGWriterMaxwrittenCleanDesc = prometheus.NewDesc(
+	statBGWriterMaxwrittenCleanDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "maxwritten_clean_total"),
 		"Number of times the background writer stopped a cleaning scan because it had written too many buffers",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.maxwritten_clean",
 	)
-	statBGWriterBuffersBackendDesc = prometheus.NewDesc(
+	statBGWriterBuffersBackendDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "buffers_backend_total"),
 		"Number of buffers written directly by a backend",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.buffers_backend",
 	)
-	statBGWriterBuffersBackendFsyncDesc = prometheus.NewDesc(
+	statBGWriterBuffersBackendFsyncDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "buffers_backend_fsync_total"),
 		"Number of times a backend had to execute its own fsync call (normally the background writer handles those even when the backend does its own write)",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.buffers_backend_fsync",
 	)
-	statBGWriterBuffersAllocDesc = prometheus.NewDesc(
+	statBGWriterBuffersAllocDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "buffers_alloc_total"),
 		"Number of buffers allocated",
 		[]string{},
 		prometheus.Labels{},
+		"", "pg_stat_bgwriter.buffers_alloc",
 	)
-	statBGWriterStatsResetDesc = prometheus.NewDesc(
+	statBGWriterStatsResetDesc = newDesc(
 		prometheus.BuildFQName(namespace, bgWriterSubsystem, "stats_reset_total"),
 		"Time at which these statistics were last reset",
 		[]string{},
 		prometheus.Labels{},
+		"seconds", "pg_stat_bgwriter.stats_reset",
+	)
+
+	// The pressure on the background writer is derived from the increase of
+	// the counters between two scrapes.
+	bgWriterMaxwrittenCleanPerCheckpointDesc = newDesc(
+		prometheus.BuildFQName(namespace, "bgwriter", "maxwritten_clean_per_checkpoint"),
+		"Number of times the background writer stopped a cleaning scan because it had written too many buffers, per checkpoint since the previous scrape",
+		[]string{},
+		prometheus.Labels{},
+		"", "pg_stat_bgwriter.maxwritten_clean",
+	)
+	bgWriterBuffersBackendRatioDesc = newDesc(
+		prometheus.BuildFQName(namespace, "bgwriter", "buffers_backend_ratio"),
+		"Ratio of the buffers written directly by backends to all buffers written since the previous scrape",
+		[]string{},
+		prometheus.Labels{},
+		"", "pg_stat_bgwriter.buffers_backend",
 	)
 
 	statBGWriterQuery = `SELECT
@@ -116,7 +146,7 @@ var (
 	FROM pg_stat_bgwriter;`
 )
 
-func (PGStatBGWriterCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+func (c PGStatBGWriterCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
 	db := instance.getDB()
 	row := db.QueryRowContext(ctx,
 		statBGWriterQuery)
@@ -130,105 +160,209 @@ func (PGStatBGWriterCollector) Update(ctx context.Context, instance *instance, c
 		return err
 	}
 
-	cptMetric := 0.0
-	if cpt.Valid {
-		cptMetric = float64(cpt.Int64)
+	if cpt.Valid || !c.omitNull {
+		cptMetric := 0.0
+		if cpt.Valid {
+			cptMetric = float64(cpt.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterCheckpointsTimedDesc,
+			prometheus.CounterValue,
+			cptMetric,
+		)
+	}
+	if cpr.Valid || !c.omitNull {
+		cprMetric := 0.0
+		if cpr.Valid {
+			cprMetric = float64(cpr.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterCheckpointsReqDesc,
+			prometheus.CounterValue,
+			cprMetric,
+		)
+	}
+	if cpwt.Valid || !c.omitNull {
+		cpwtMetric := 0.0
+		if cpwt.Valid {
+			cpwtMetric = float64(cpwt.Float64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterCheckpointsReqTimeDesc,
+			prometheus.CounterValue,
+			cpwtMetric,
+		)
+	}
+	if cpst.Valid || !c.omitNull {
+		cpstMetric := 0.0
+		if cpst.Valid {
+			cpstMetric = float64(cpst.Float64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterCheckpointsSyncTimeDesc,
+			prometheus.CounterValue,
+			cpstMetric,
+		)
+	}
+	if bcp.Valid || !c.omitNull {
+		bcpMetric := 0.0
+		if bcp.Valid {
+			bcpMetric = float64(bcp.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterBuffersCheckpointDesc,
+			prometheus.CounterValue,
+			bcpMetric,
+		)
+	}
+	if bc.Valid || !c.omitNull {
+		bcMetric := 0.0
+		if bc.Valid {
+			bcMetric = float64(bc.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterBuffersCleanDesc,
+			prometheus.CounterValue,
+			bcMetric,
+		)
+	}
+	if mwc.Valid || !c.omitNull {
+		mwcMetric := 0.0
+		if mwc.Valid {
+			mwcMetric = float64(mwc.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterMaxwrittenCleanDesc,
+			prometheus.CounterValue,
+			mwcMetric,
+		)
+	}
+	if bb.Valid || !c.omitNull {
+		bbMetric := 0.0
+		if bb.Valid {
+			bbMetric = float64(bb.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterBuffersBackendDesc,
+			prometheus.CounterValue,
+			bbMetric,
+		)
+	}
+	if bbf.Valid || !c.omitNull {
+		bbfMetric := 0.0
+		if bbf.Valid {
+			bbfMetric = float64(bbf.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterBuffersBackendFsyncDesc,
+			prometheus.CounterValue,
+			bbfMetric,
+		)
+	}
+	if ba.Valid || !c.omitNull {
+		baMetric := 0.0
+		if ba.Valid {
+			baMetric = float64(ba.Int64)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterBuffersAllocDesc,
+			prometheus.CounterValue,
+			baMetric,
+		)
+	}
+	if sr.Valid || !c.omitNull {
+		srMetric := 0.0
+		if sr.Valid {
+			srMetric = float64(sr.Time.Unix())
+		}
+		ch <- prometheus.MustNewConstMetric(
+			statBGWriterStatsResetDesc,
+			prometheus.CounterValue,
+			srMetric,
+		)
+	}
+
+	if !cpt.Valid || !cpr.Valid || !bcp.Valid || !bc.Valid || !mwc.Valid || !bb.Valid {
+		return nil
+	}
+	pressure := instance.getSamples().bgWriterSample.update(bgWriterCounters{
+		checkpoints:       float64(cpt.Int64 + cpr.Int64),
+		maxwrittenClean:   float64(mwc.Int64),
+		buffersCheckpoint: float64(bcp.Int64),
+		buffersClean:      float64(bc.Int64),
+		buffersBackend:    float64(bb.Int64),
+	})
+	if pressure.hasPerCheckpoint {
+		ch <- prometheus.MustNewConstMetric(
+			bgWriterMaxwrittenCleanPerCheckpointDesc,
+			prometheus.GaugeValue,
+			pressure.maxwrittenCleanPerCheckpoint,
+		)
+	}
+	if pressure.hasBackendRatio {
+		ch <- prometheus.MustNewConstMetric(
+			bgWriterBuffersBackendRatioDesc,
+			prometheus.GaugeValue,
+			pressure.buffersBackendRatio,
+		)
 	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterCheckpointsTimedDesc,
-		prometheus.CounterValue,
-		cptMetric,
-	)
-	cprMetric := 0.0
-	if cpr.Valid {
-		cprMetric = float64(cpr.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterCheckpointsReqDesc,
-		prometheus.CounterValue,
-		cprMetric,
-	)
-	cpwtMetric := 0.0
-	if cpwt.Valid {
-		cpwtMetric = float64(cpwt.Float64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterCheckpointsReqTimeDesc,
-		prometheus.CounterValue,
-		cpwtMetric,
-	)
-	cpstMetric := 0.0
-	if cpst.Valid {
-		cpstMetric = float64(cpst.Float64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterCheckpointsSyncTimeDesc,
-		prometheus.CounterValue,
-		cpstMetric,
-	)
-	bcpMetric := 0.0
-	if bcp.Valid {
-		bcpMetric = float64(bcp.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterBuffersCheckpointDesc,
-		prometheus.CounterValue,
-		bcpMetric,
-	)
-	bcMetric := 0.0
-	if bc.Valid {
-		bcMetric = float64(bc.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterBuffersCleanDesc,
-		prometheus.CounterValue,
-		bcMetric,
-	)
-	mwcMetric := 0.0
-	if mwc.Valid {
-		mwcMetric = float64(mwc.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterMaxwrittenCleanDesc,
-		prometheus.CounterValue,
-		mwcMetric,
-	)
-	bbMetric := 0.0
-	if bb.Valid {
-		bbMetric = float64(bb.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterBuffersBackendDesc,
-		prometheus.CounterValue,
-		bbMetric,
-	)
-	bbfMetric := 0.0
-	if bbf.Valid {
-		bbfMetric = float64(bbf.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterBuffersBackendFsyncDesc,
-		prometheus.CounterValue,
-		bbfMetric,
-	)
-	baMetric := 0.0
-	if ba.Valid {
-		baMetric = float64(ba.Int64)
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterBuffersAllocDesc,
-		prometheus.CounterValue,
-		baMetric,
-	)
-	srMetric := 0.0
-	if sr.Valid {
-		srMetric = float64(sr.Time.Unix())
-	}
-	ch <- prometheus.MustNewConstMetric(
-		statBGWriterStatsResetDesc,
-		prometheus.CounterValue,
-		srMetric,
-	)
 
 	return nil
 }
+
+// bgWriterCounters are the counters of pg_stat_bgwriter the pressure on the
+// background writer is derived from.
+type bgWriterCounters struct {
+	checkpoints       float64
+	maxwrittenClean   float64
+	buffersCheckpoint float64
+	buffersClean      float64
+	buffersBackend    float64
+}
+
+// bgWriterPressure is derived from the increase of bgWriterCounters between
+// two samples.
+type bgWriterPressure struct {
+	maxwrittenCleanPerCheckpoint float64
+	hasPerCheckpoint             bool
+	buffersBackendRatio          float64
+	hasBackendRatio              bool
+}
+
+// bgWriterSample holds the pg_stat_bgwriter counters of an instance at the
+// previous scrape.
+type bgWriterSample struct {
+	mtx         sync.Mutex
+	initialized bool
+	counters    bgWriterCounters
+}
+
+// update records the current counters and returns the pressure since the
+// previous sample. Nothing is derived from the first sample or after the
+// statistics were reset, nor per checkpoint when no checkpoint happened.
+func (s *bgWriterSample) update(counters bgWriterCounters) bgWriterPressure {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	last, initialized := s.counters, s.initialized
+	s.counters, s.initialized = counters, true
+
+	var p bgWriterPressure
+	checkpoints := counters.checkpoints - last.checkpoints
+	maxwrittenClean := counters.maxwrittenClean - last.maxwrittenClean
+	buffersCheckpoint := counters.buffersCheckpoint - last.buffersCheckpoint
+	buffersClean := counters.buffersClean - last.buffersClean
+	buffersBackend := counters.buffersBackend - last.buffersBackend
+	if !initialized || checkpoints < 0 || maxwrittenClean < 0 || buffersCheckpoint < 0 || buffersClean < 0 || buffersBackend < 0 {
+		return p
+	}
+	if checkpoints > 0 {
+		p.maxwrittenCleanPerCheckpoint = maxwrittenClean / checkpoints
+		p.hasPerCheckpoint = true
+	}
+	if written := buffersCheckpoint + buffersClean + buffersBackend; written > 0 {
+		p.buffersBackendRatio = buffersBackend / written
+		p.hasBackendRatio = true
+	}
+	return p
+}
@@ -149,3 +149,77 @@ func TestPGStatBGWriterCollectorNullValues(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestPGStatBGWriterCollectorPressure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{
+		"checkpoints_timed",
+		"checkpoints_req",
+		"checkpoint_write_time",
+		"checkpoint_sync_time",
+		"buffers_checkpoint",
+		"buffers_clean",
+		"maxwritten_clean",
+		"buffers_backend",
+		"buffers_backend_fsync",
+		"buffers_alloc",
+		"stats_reset"}
+	mock.ExpectQuery(sanitizeQuery(statBGWriterQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(100, 20, 0, 0, 50000, 10000, 300, 4000, 0, 90000, nil))
+	// Four checkpoints during which the background writer stopped 30 times,
+	// while backends wrote 1500 of 6000 buffers.
+	mock.ExpectQuery(sanitizeQuery(statBGWriterQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(103, 21, 0, 0, 53000, 11500, 330, 5500, 0, 99000, nil))
+
+	scrape := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			c := PGStatBGWriterCollector{omitNull: true}
+
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGStatBGWriterCollector.Update: %s", err)
+			}
+		}()
+		var results []MetricResult
+		for m := range ch {
+			results = append(results, readMetric(m))
+		}
+		return results
+	}
+
+	convey.Convey("Pressure is derived from the deltas between scrapes", t, func() {
+		// Only the counters are reported by the first scrape.
+		convey.So(scrape(), convey.ShouldHaveLength, 10)
+
+		second := scrape()
+		convey.So(second, convey.ShouldHaveLength, 12)
+		convey.So(second[10], convey.ShouldResemble, MetricResult{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 7.5})
+		convey.So(second[11], convey.ShouldResemble, MetricResult{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 0.25})
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestBGWriterSampleReset(t *testing.T) {
+	s := &bgWriterSample{}
+	s.update(bgWriterCounters{checkpoints: 10, maxwrittenClean: 50, buffersCheckpoint: 1000, buffersClean: 500, buffersBackend: 500})
+	// The statistics were reset.
+	p := s.update(bgWriterCounters{checkpoints: 1, maxwrittenClean: 2, buffersCheckpoint: 10, buffersClean: 5, buffersBackend: 5})
+	if p.hasPerCheckpoint || p.hasBackendRatio {
+		t.Errorf("Expected no pressure after a reset, got %+v", p)
+	}
+	// No checkpoint happened, so only the backend ratio is known.
+	p = s.update(bgWriterCounters{checkpoints: 1, maxwrittenClean: 4, buffersCheckpoint: 10, buffersClean: 15, buffersBackend: 15})
+	if p.hasPerCheckpoint || !p.hasBackendRatio || p.buffersBackendRatio != 0.5 {
+		t.Errorf("Expected only a backend ratio of 0.5, got %+v", p)
+	}
+}
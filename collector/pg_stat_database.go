@@ -17,23 +17,26 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/blang/semver/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const statDatabaseSubsystem = "stat_database"
 
 func init() {
-	registerCollector(statDatabaseSubsystem, defaultEnabled, NewPGStatDatabaseCollector)
+	registerCollector(statDatabaseSubsystem, defaultEnabled, ScopeGlobal, NewPGStatDatabaseCollector)
 }
 
-type PGStatDatabaseCollector struct{}
+type PGStatDatabaseCollector struct {
+	omitNull bool
+}
 
 func NewPGStatDatabaseCollector(config collectorConfig) (Collector, error) {
-	return &PGStatDatabaseCollector{}, nil
+	return &PGStatDatabaseCollector{omitNull: config.omitNull}, nil
 }
 
 var (
-	statDatabaseNumbackends = prometheus.NewDesc(
+	statDatabaseNumbackends = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -42,8 +45,9 @@ var (
 		"Number of backends currently connected to this database. This is the only column in this view that returns a value reflecting current state; all other columns return the accumulated values since the last reset.",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.numbackends",
 	)
-	statDatabaseXactCommit = prometheus.NewDesc(
+	statDatabaseXactCommit = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -52,8 +56,9 @@ var (
 		"Number of transactions in this database that have been committed",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.xact_commit",
 	)
-	statDatabaseXactRollback = prometheus.NewDesc(
+	statDatabaseXactRollback = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -62,8 +67,9 @@ var (
 		"Number of transactions in this database that have been rolled back",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.xact_rollback",
 	)
-	statDatabaseBlksRead = prometheus.NewDesc(
+	statDatabaseBlksRead = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -72,8 +78,9 @@ var (
 		"Number of disk blocks read in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.blks_read",
 	)
-	statDatabaseBlksHit = prometheus.NewDesc(
+	statDatabaseBlksHit = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -82,8 +89,9 @@ var (
 		"Number of times disk blocks were found already in the buffer cache, so that a read was not necessary (this only includes hits in the PostgreSQL buffer cache, not the operating system's file system cache)",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.blks_hit",
 	)
-	statDatabaseTupReturned = prometheus.NewDesc(
+	statDatabaseTupReturned = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -92,8 +100,9 @@ var (
 		"Number of rows returned by queries in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.tup_returned",
 	)
-	statDatabaseTupFetched = prometheus.NewDesc(
+	statDatabaseTupFetched = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -102,8 +111,9 @@ var (
 		"Number of rows fetched by queries in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.tup_fetched",
 	)
-	statDatabaseTupInserted = prometheus.NewDesc(
+	statDatabaseTupInserted = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -112,8 +122,9 @@ var (
 		"Number of rows inserted by queries in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.tup_inserted",
 	)
-	statDatabaseTupUpdated = prometheus.NewDesc(
+	statDatabaseTupUpdated = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -122,8 +133,9 @@ var (
 		"Number of rows updated by queries in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.tup_updated",
 	)
-	statDatabaseTupDeleted = prometheus.NewDesc(
+	statDatabaseTupDeleted = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -132,8 +144,9 @@ var (
 		"Number of rows deleted by queries in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.tup_deleted",
 	)
-	statDatabaseConflicts = prometheus.NewDesc(
+	statDatabaseConflicts = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -142,8 +155,9 @@ var (
 		"Number of queries canceled due to conflicts with recovery in this database. (Conflicts occur only on standby servers; see pg_stat_database_conflicts for details.)",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.conflicts",
 	)
-	statDatabaseTempFiles = prometheus.NewDesc(
+	statDatabaseTempFiles = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -152,8 +166,9 @@ var (
 		"Number of temporary files created by queries in this database. All temporary files are counted, regardless of why the temporary file was created (e.g., sorting or hashing), and regardless of the log_temp_files setting.",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.temp_files",
 	)
-	statDatabaseTempBytes = prometheus.NewDesc(
+	statDatabaseTempBytes = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -162,8 +177,9 @@ var (
 		"Total amount of data written to temporary files by queries in this database. All temporary files are counted, regardless of why the temporary file was created, and regardless of the log_temp_files setting.",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"bytes", "pg_stat_database.temp_bytes",
 	)
-	statDatabaseDeadlocks = prometheus.NewDesc(
+	statDatabaseDeadlocks = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -172,8 +188,9 @@ var (
 		"Number of deadlocks detected in this database",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_database.deadlocks",
 	)
-	statDatabaseBlkReadTime = prometheus.NewDesc(
+	statDatabaseBlkReadTime = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -182,8 +199,9 @@ var (
 		"Time spent reading data file blocks by backends in this database, in milliseconds",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"milliseconds", "pg_stat_database.blk_read_time",
 	)
-	statDatabaseBlkWriteTime = prometheus.NewDesc(
+	statDatabaseBlkWriteTime = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			statDatabaseSubsystem,
@@ -192,8 +210,9 @@ var (
 		"Time spent writing data file blocks by backends in this database, in milliseconds",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"milliseconds", "pg_stat_database.blk_write_time",
 	)
-	statDatabaseStatsReset = prometheus.NewDesc(prometheus.BuildFQName(
+	statDatabaseStatsReset = newDesc(prometheus.BuildFQName(
 		namespace,
 		statDatabaseSubsystem,
 		"stats_reset",
@@ -201,6 +220,107 @@ var (
 		"Time at which these statistics were last reset",
 		[]string{"datid", "datname"},
 		prometheus.Labels{},
+		"seconds", "pg_stat_database.stats_reset",
+	)
+	statsResetTimestampSeconds = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			"stats_reset",
+			"timestamp_seconds",
+		),
+		"Unix timestamp of the last statistics reset for this database. Not reported if the statistics were never reset",
+		[]string{"datname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_database.stats_reset",
+	)
+	transactionRollbackRatio = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			"transaction",
+			"rollback_ratio",
+		),
+		"Ratio of rolled back transactions to all transactions of this database since the last statistics reset. Not reported for databases without transactions",
+		[]string{"datname"},
+		prometheus.Labels{},
+		"", "pg_stat_database.xact_rollback",
+	)
+
+	statDatabaseSessionTime = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"session_time_seconds_total",
+		),
+		"Time spent by database sessions in this database, in seconds",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_database.session_time",
+	)
+	statDatabaseActiveTime = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"active_time_seconds_total",
+		),
+		"Time spent executing SQL statements in this database, in seconds",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_database.active_time",
+	)
+	statDatabaseIdleInTransactionTime = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"idle_in_transaction_time_seconds_total",
+		),
+		"Time spent idling while in a transaction in this database, in seconds",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_database.idle_in_transaction_time",
+	)
+	statDatabaseSessions = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"sessions_total",
+		),
+		"Total number of sessions established to this database",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"", "pg_stat_database.sessions",
+	)
+	statDatabaseSessionsAbandoned = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"sessions_abandoned_total",
+		),
+		"Number of database sessions to this database that were terminated because connection to the client was lost",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"", "pg_stat_database.sessions_abandoned",
+	)
+	statDatabaseSessionsFatal = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"sessions_fatal_total",
+		),
+		"Number of database sessions to this database that were terminated by fatal errors",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"", "pg_stat_database.sessions_fatal",
+	)
+	statDatabaseSessionsKilled = newDesc(
+		prometheus.BuildFQName(
+			namespace,
+			statDatabaseSubsystem,
+			"sessions_killed_total",
+		),
+		"Number of database sessions to this database that were terminated by operator intervention",
+		[]string{"datid", "datname"},
+		prometheus.Labels{},
+		"", "pg_stat_database.sessions_killed",
 	)
 
 	statDatabaseQuery = `
@@ -226,9 +346,24 @@ var (
 			,stats_reset
 		FROM pg_stat_database;
 	`
+
+	// Session accounting was added in PostgreSQL 14.
+	statDatabaseSessionsQuery = `
+		SELECT
+			datid
+			,datname
+			,session_time / 1000.0
+			,active_time / 1000.0
+			,idle_in_transaction_time / 1000.0
+			,sessions
+			,sessions_abandoned
+			,sessions_fatal
+			,sessions_killed
+		FROM pg_stat_database;
+	`
 )
 
-func (PGStatDatabaseCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+func (c PGStatDatabaseCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
 	db := instance.getDB()
 	rows, err := db.QueryContext(ctx,
 		statDatabaseQuery,
@@ -276,209 +411,408 @@ func (PGStatDatabaseCollector) Update(ctx context.Context, instance *instance, c
 			datnameLabel = datname.String
 		}
 
-		numBackendsMetric := 0.0
-		if numBackends.Valid {
-			numBackendsMetric = numBackends.Float64
+		if numBackends.Valid || !c.omitNull {
+			numBackendsMetric := 0.0
+			if numBackends.Valid {
+				numBackendsMetric = numBackends.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseNumbackends,
+				prometheus.GaugeValue,
+				numBackendsMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseNumbackends,
-			prometheus.GaugeValue,
-			numBackendsMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		xactCommitMetric := 0.0
-		if xactCommit.Valid {
-			xactCommitMetric = xactCommit.Float64
+		if xactCommit.Valid || !c.omitNull {
+			xactCommitMetric := 0.0
+			if xactCommit.Valid {
+				xactCommitMetric = xactCommit.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseXactCommit,
+				prometheus.CounterValue,
+				xactCommitMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseXactCommit,
-			prometheus.CounterValue,
-			xactCommitMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		xactRollbackMetric := 0.0
-		if xactRollback.Valid {
-			xactRollbackMetric = xactRollback.Float64
+		if xactRollback.Valid || !c.omitNull {
+			xactRollbackMetric := 0.0
+			if xactRollback.Valid {
+				xactRollbackMetric = xactRollback.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseXactRollback,
+				prometheus.CounterValue,
+				xactRollbackMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseXactRollback,
-			prometheus.CounterValue,
-			xactRollbackMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		blksReadMetric := 0.0
-		if blksRead.Valid {
-			blksReadMetric = blksRead.Float64
+		if blksRead.Valid || !c.omitNull {
+			blksReadMetric := 0.0
+			if blksRead.Valid {
+				blksReadMetric = blksRead.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseBlksRead,
+				prometheus.CounterValue,
+				blksReadMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseBlksRead,
-			prometheus.CounterValue,
-			blksReadMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		blksHitMetric := 0.0
-		if blksHit.Valid {
-			blksHitMetric = blksHit.Float64
+		if blksHit.Valid || !c.omitNull {
+			blksHitMetric := 0.0
+			if blksHit.Valid {
+				blksHitMetric = blksHit.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseBlksHit,
+				prometheus.CounterValue,
+				blksHitMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseBlksHit,
-			prometheus.CounterValue,
-			blksHitMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tupReturnedMetric := 0.0
-		if tupReturned.Valid {
-			tupReturnedMetric = tupReturned.Float64
+		if tupReturned.Valid || !c.omitNull {
+			tupReturnedMetric := 0.0
+			if tupReturned.Valid {
+				tupReturnedMetric = tupReturned.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTupReturned,
+				prometheus.CounterValue,
+				tupReturnedMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTupReturned,
-			prometheus.CounterValue,
-			tupReturnedMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tupFetchedMetric := 0.0
-		if tupFetched.Valid {
-			tupFetchedMetric = tupFetched.Float64
+		if tupFetched.Valid || !c.omitNull {
+			tupFetchedMetric := 0.0
+			if tupFetched.Valid {
+				tupFetchedMetric = tupFetched.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTupFetched,
+				prometheus.CounterValue,
+				tupFetchedMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTupFetched,
-			prometheus.CounterValue,
-			tupFetchedMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tupInsertedMetric := 0.0
-		if tupInserted.Valid {
-			tupInsertedMetric = tupInserted.Float64
+		if tupInserted.Valid || !c.omitNull {
+			tupInsertedMetric := 0.0
+			if tupInserted.Valid {
+				tupInsertedMetric = tupInserted.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTupInserted,
+				prometheus.CounterValue,
+				tupInsertedMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTupInserted,
-			prometheus.CounterValue,
-			tupInsertedMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tupUpdatedMetric := 0.0
-		if tupUpdated.Valid {
-			tupUpdatedMetric = tupUpdated.Float64
+		if tupUpdated.Valid || !c.omitNull {
+			tupUpdatedMetric := 0.0
+			if tupUpdated.Valid {
+				tupUpdatedMetric = tupUpdated.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTupUpdated,
+				prometheus.CounterValue,
+				tupUpdatedMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTupUpdated,
-			prometheus.CounterValue,
-			tupUpdatedMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tupDeletedMetric := 0.0
-		if tupDeleted.Valid {
-			tupDeletedMetric = tupDeleted.Float64
+		if tupDeleted.Valid || !c.omitNull {
+			tupDeletedMetric := 0.0
+			if tupDeleted.Valid {
+				tupDeletedMetric = tupDeleted.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTupDeleted,
+				prometheus.CounterValue,
+				tupDeletedMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTupDeleted,
-			prometheus.CounterValue,
-			tupDeletedMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		conflictsMetric := 0.0
-		if conflicts.Valid {
-			conflictsMetric = conflicts.Float64
+		if conflicts.Valid || !c.omitNull {
+			conflictsMetric := 0.0
+			if conflicts.Valid {
+				conflictsMetric = conflicts.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseConflicts,
+				prometheus.CounterValue,
+				conflictsMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseConflicts,
-			prometheus.CounterValue,
-			conflictsMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tempFilesMetric := 0.0
-		if tempFiles.Valid {
-			tempFilesMetric = tempFiles.Float64
+		if tempFiles.Valid || !c.omitNull {
+			tempFilesMetric := 0.0
+			if tempFiles.Valid {
+				tempFilesMetric = tempFiles.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTempFiles,
+				prometheus.CounterValue,
+				tempFilesMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTempFiles,
-			prometheus.CounterValue,
-			tempFilesMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		tempBytesMetric := 0.0
-		if tempBytes.Valid {
-			tempBytesMetric = tempBytes.Float64
+		if tempBytes.Valid || !c.omitNull {
+			tempBytesMetric := 0.0
+			if tempBytes.Valid {
+				tempBytesMetric = tempBytes.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseTempBytes,
+				prometheus.CounterValue,
+				tempBytesMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseTempBytes,
-			prometheus.CounterValue,
-			tempBytesMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		deadlocksMetric := 0.0
-		if deadlocks.Valid {
-			deadlocksMetric = deadlocks.Float64
+		if deadlocks.Valid || !c.omitNull {
+			deadlocksMetric := 0.0
+			if deadlocks.Valid {
+				deadlocksMetric = deadlocks.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseDeadlocks,
+				prometheus.CounterValue,
+				deadlocksMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseDeadlocks,
-			prometheus.CounterValue,
-			deadlocksMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		blkReadTimeMetric := 0.0
-		if blkReadTime.Valid {
-			blkReadTimeMetric = blkReadTime.Float64
+		if blkReadTime.Valid || !c.omitNull {
+			blkReadTimeMetric := 0.0
+			if blkReadTime.Valid {
+				blkReadTimeMetric = blkReadTime.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseBlkReadTime,
+				prometheus.CounterValue,
+				blkReadTimeMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseBlkReadTime,
-			prometheus.CounterValue,
-			blkReadTimeMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		blkWriteTimeMetric := 0.0
-		if blkWriteTime.Valid {
-			blkWriteTimeMetric = blkWriteTime.Float64
+		if blkWriteTime.Valid || !c.omitNull {
+			blkWriteTimeMetric := 0.0
+			if blkWriteTime.Valid {
+				blkWriteTimeMetric = blkWriteTime.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseBlkWriteTime,
+				prometheus.CounterValue,
+				blkWriteTimeMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if statsReset.Valid || !c.omitNull {
+			statsResetMetric := 0.0
+			if statsReset.Valid {
+				statsResetMetric = float64(statsReset.Time.Unix())
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseStatsReset,
+				prometheus.CounterValue,
+				statsResetMetric,
+				datidLabel,
+				datnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseBlkWriteTime,
-			prometheus.CounterValue,
-			blkWriteTimeMetric,
-			datidLabel,
-			datnameLabel,
-		)
 
-		statsResetMetric := 0.0
 		if statsReset.Valid {
-			statsResetMetric = float64(statsReset.Time.Unix())
-		}
-		ch <- prometheus.MustNewConstMetric(
-			statDatabaseStatsReset,
-			prometheus.CounterValue,
-			statsResetMetric,
-			datidLabel,
-			datnameLabel,
+			ch <- prometheus.MustNewConstMetric(
+				statsResetTimestampSeconds,
+				prometheus.GaugeValue,
+				float64(statsReset.Time.Unix()),
+				datnameLabel,
+			)
+		}
+
+		if datname.Valid && xactCommit.Float64+xactRollback.Float64 > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				transactionRollbackRatio,
+				prometheus.GaugeValue,
+				xactRollback.Float64/(xactCommit.Float64+xactRollback.Float64),
+				datnameLabel,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if instance.version.GE(semver.MustParse("14.0.0")) {
+		return c.updateSessions(ctx, db, ch)
+	}
+	return nil
+}
+
+func (c PGStatDatabaseCollector) updateSessions(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx,
+		statDatabaseSessionsQuery,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datid, datname sql.NullString
+		var sessionTime, activeTime, idleInTransactionTime, sessions, sessionsAbandoned, sessionsFatal, sessionsKilled sql.NullFloat64
+
+		err := rows.Scan(
+			&datid,
+			&datname,
+			&sessionTime,
+			&activeTime,
+			&idleInTransactionTime,
+			&sessions,
+			&sessionsAbandoned,
+			&sessionsFatal,
+			&sessionsKilled,
 		)
+		if err != nil {
+			return err
+		}
+		datidLabel := "unknown"
+		if datid.Valid {
+			datidLabel = datid.String
+		}
+		datnameLabel := "unknown"
+		if datname.Valid {
+			datnameLabel = datname.String
+		}
+
+		if sessionTime.Valid || !c.omitNull {
+			sessionTimeMetric := 0.0
+			if sessionTime.Valid {
+				sessionTimeMetric = sessionTime.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseSessionTime,
+				prometheus.CounterValue,
+				sessionTimeMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if activeTime.Valid || !c.omitNull {
+			activeTimeMetric := 0.0
+			if activeTime.Valid {
+				activeTimeMetric = activeTime.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseActiveTime,
+				prometheus.CounterValue,
+				activeTimeMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if idleInTransactionTime.Valid || !c.omitNull {
+			idleInTransactionTimeMetric := 0.0
+			if idleInTransactionTime.Valid {
+				idleInTransactionTimeMetric = idleInTransactionTime.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseIdleInTransactionTime,
+				prometheus.CounterValue,
+				idleInTransactionTimeMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if sessions.Valid || !c.omitNull {
+			sessionsMetric := 0.0
+			if sessions.Valid {
+				sessionsMetric = sessions.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseSessions,
+				prometheus.CounterValue,
+				sessionsMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if sessionsAbandoned.Valid || !c.omitNull {
+			sessionsAbandonedMetric := 0.0
+			if sessionsAbandoned.Valid {
+				sessionsAbandonedMetric = sessionsAbandoned.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseSessionsAbandoned,
+				prometheus.CounterValue,
+				sessionsAbandonedMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if sessionsFatal.Valid || !c.omitNull {
+			sessionsFatalMetric := 0.0
+			if sessionsFatal.Valid {
+				sessionsFatalMetric = sessionsFatal.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseSessionsFatal,
+				prometheus.CounterValue,
+				sessionsFatalMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+
+		if sessionsKilled.Valid || !c.omitNull {
+			sessionsKilledMetric := 0.0
+			if sessionsKilled.Valid {
+				sessionsKilledMetric = sessionsKilled.Float64
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statDatabaseSessionsKilled,
+				prometheus.CounterValue,
+				sessionsKilledMetric,
+				datidLabel,
+				datnameLabel,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,158 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statReplicationSubsystem = "stat_replication"
+
+func init() {
+	registerCollector(statReplicationSubsystem, defaultDisabled, ScopeGlobal, NewPGStatReplicationCollector)
+}
+
+// PGStatReplicationCollector splits the replication lag of each standby
+// connected to the primary into the WAL sent but not yet written, written but
+// not yet flushed and flushed but not yet replayed by the standby, which tell
+// network, disk and apply lag apart. The same lags are also reported in
+// seconds, as measured by the primary.
+type PGStatReplicationCollector struct {
+	log log.Logger
+}
+
+func NewPGStatReplicationCollector(config collectorConfig) (Collector, error) {
+	return &PGStatReplicationCollector{log: config.logger}, nil
+}
+
+var (
+	statReplicationSentWriteLagBytes = newDesc(
+		prometheus.BuildFQName(namespace, statReplicationSubsystem, "sent_write_lag_bytes"),
+		"WAL sent to the standby but not yet written to disk by it, in bytes",
+		[]string{"application_name", "client_addr"}, nil,
+		"bytes", "pg_stat_replication.write_lsn",
+	)
+	statReplicationWriteFlushLagBytes = newDesc(
+		prometheus.BuildFQName(namespace, statReplicationSubsystem, "write_flush_lag_bytes"),
+		"WAL written to disk by the standby but not yet flushed, in bytes",
+		[]string{"application_name", "client_addr"}, nil,
+		"bytes", "pg_stat_replication.flush_lsn",
+	)
+	statReplicationFlushReplayLagBytes = newDesc(
+		prometheus.BuildFQName(namespace, statReplicationSubsystem, "flush_replay_lag_bytes"),
+		"WAL flushed to disk by the standby but not yet replayed, in bytes",
+		[]string{"application_name", "client_addr"}, nil,
+		"bytes", "pg_stat_replication.replay_lsn",
+	)
+	statReplicationWriteLagSeconds = newDesc(
+		prometheus.BuildFQName(namespace, replicationSubsystem, "write_lag_seconds"),
+		"Time between flushing recent WAL locally and receiving notification that the standby has written it, in seconds",
+		[]string{"application_name", "client_addr"}, nil,
+		"seconds", "pg_stat_replication.write_lag",
+	)
+	statReplicationFlushLagSeconds = newDesc(
+		prometheus.BuildFQName(namespace, replicationSubsystem, "flush_lag_seconds"),
+		"Time between flushing recent WAL locally and receiving notification that the standby has written and flushed it, in seconds",
+		[]string{"application_name", "client_addr"}, nil,
+		"seconds", "pg_stat_replication.flush_lag",
+	)
+	statReplicationReplayLagSeconds = newDesc(
+		prometheus.BuildFQName(namespace, replicationSubsystem, "replay_lag_seconds"),
+		"Time between flushing recent WAL locally and receiving notification that the standby has written, flushed and applied it, in seconds",
+		[]string{"application_name", "client_addr"}, nil,
+		"seconds", "pg_stat_replication.replay_lag",
+	)
+
+	statReplicationQuery = `SELECT
+		application_name,
+		host(client_addr) AS client_addr,
+		sent_lsn - '0/0' AS sent_lsn,
+		write_lsn - '0/0' AS write_lsn,
+		flush_lsn - '0/0' AS flush_lsn,
+		replay_lsn - '0/0' AS replay_lsn,
+		EXTRACT(EPOCH FROM write_lag) AS write_lag,
+		EXTRACT(EPOCH FROM flush_lag) AS flush_lag,
+		EXTRACT(EPOCH FROM replay_lag) AS replay_lag
+	FROM pg_stat_replication`
+)
+
+func (c PGStatReplicationCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// The LSN columns were renamed from *_location and the lag columns were
+	// added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "pg_stat_replication lags are not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		statReplicationQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var applicationName, clientAddr sql.NullString
+		var sent, write, flush, replay sql.NullFloat64
+		var writeLag, flushLag, replayLag sql.NullFloat64
+		if err := rows.Scan(&applicationName, &clientAddr, &sent, &write, &flush, &replay, &writeLag, &flushLag, &replayLag); err != nil {
+			return err
+		}
+
+		// The LSNs of a standby are NULL until it has reported them, e.g.
+		// while it is still catching up.
+		emitDistance := func(desc *prometheus.Desc, from, to sql.NullFloat64) {
+			if !from.Valid || !to.Valid {
+				return
+			}
+			ch <- prometheus.MustNewConstMetric(
+				desc,
+				prometheus.GaugeValue, from.Float64-to.Float64,
+				applicationName.String, clientAddr.String,
+			)
+		}
+		emitDistance(statReplicationSentWriteLagBytes, sent, write)
+		emitDistance(statReplicationWriteFlushLagBytes, write, flush)
+		emitDistance(statReplicationFlushReplayLagBytes, flush, replay)
+
+		// The lags are NULL once an idle standby has caught up, they are 0
+		// then. They are also NULL until the standby has reported its
+		// position, and are omitted.
+		emitLag := func(desc *prometheus.Desc, lag, lsn sql.NullFloat64) {
+			value := lag.Float64
+			if !lag.Valid {
+				if !sent.Valid || !lsn.Valid || lsn.Float64 != sent.Float64 {
+					return
+				}
+				value = 0
+			}
+			ch <- prometheus.MustNewConstMetric(
+				desc,
+				prometheus.GaugeValue, value,
+				applicationName.String, clientAddr.String,
+			)
+		}
+		emitLag(statReplicationWriteLagSeconds, writeLag, write)
+		emitLag(statReplicationFlushLagSeconds, flushLag, flush)
+		emitLag(statReplicationReplayLagSeconds, replayLag, replay)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGStatReplicationCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	columns := []string{"application_name", "client_addr", "sent_lsn", "write_lsn", "flush_lsn", "replay_lsn", "write_lag", "flush_lag", "replay_lag"}
+	rows := sqlmock.NewRows(columns).
+		// 0/5000000, 0/4F00000, 0/4E00000 and 0/4A00000.
+		AddRow("standby1", "10.0.0.2", 83886080, 82837504, 81788928, 77594624, 0.002, 0.0035, 1.25).
+		// Still catching up, nothing written yet.
+		AddRow("standby2", "10.0.0.3", 83886080, nil, nil, nil, nil, nil, nil)
+	mock.ExpectQuery(sanitizeQuery(statReplicationQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatReplicationCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatReplicationCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 1048576},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 1048576},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 4194304},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0.002},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0.0035},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 1.25},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStatReplicationCollectorIdleStandby(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	// A synchronous standby which caught up while the primary is idle has
+	// NULL lags, the replay of another standby is still running.
+	columns := []string{"application_name", "client_addr", "sent_lsn", "write_lsn", "flush_lsn", "replay_lsn", "write_lag", "flush_lag", "replay_lag"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("standby1", "10.0.0.2", 83886080, 83886080, 83886080, 83886080, nil, nil, nil).
+		AddRow("standby2", "10.0.0.3", 83886080, 83886080, 83886080, 77594624, nil, nil, nil)
+	mock.ExpectQuery(sanitizeQuery(statReplicationQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatReplicationCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatReplicationCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby1", "client_addr": "10.0.0.2"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby2", "client_addr": "10.0.0.3"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby2", "client_addr": "10.0.0.3"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby2", "client_addr": "10.0.0.3"}, metricType: dto.MetricType_GAUGE, value: 6291456},
+		{labels: labelMap{"application_name": "standby2", "client_addr": "10.0.0.3"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"application_name": "standby2", "client_addr": "10.0.0.3"}, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
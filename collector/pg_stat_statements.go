@@ -16,69 +16,404 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
 
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/blang/semver/v4"
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const statStatementsSubsystem = "stat_statements"
 
+var statStatementsExcludeExporter = kingpin.Flag(
+	"collector.stat_statements.exclude-exporter",
+	"Exclude the statements run by the exporter's users from the connections with the exporter's application_name on PostgreSQL 14+.",
+).Default("false").Bool()
+
+var statStatementsIncludePlanID = kingpin.Flag(
+	"collector.stat_statements.include-planid",
+	"Add the planid label to the statement metrics on PostgreSQL 16+ where pg_stat_statements has a planid column.",
+).Default("false").Bool()
+
+var statStatementsAllDatabases = kingpin.Flag(
+	"collector.stat_statements.all-databases",
+	"Query pg_stat_statements from a connection to each database of the server, except excluded databases.",
+).Default("false").Bool()
+
+var statStatementsQueryInfo = kingpin.Flag(
+	"collector.stat_statements.query-info",
+	"Label the stat_statements metrics by queryid only, and report the query text, user and datname of each statement in pg_stat_statements_info.",
+).Default("false").Bool()
+
+var statStatementsRoundMicros = kingpin.Flag(
+	"collector.stat_statements.round-micros",
+	"Round the stat_statements time metrics to microsecond precision.",
+).Default("false").Bool()
+
+var statStatementsRawValues = kingpin.Flag(
+	"collector.stat_statements.raw-values",
+	"Report the stat_statements times as the milliseconds recorded by pg_stat_statements instead of converting them to seconds.",
+).Default("false").Bool()
+
+var statStatementsExemplars = kingpin.Flag(
+	"collector.stat_statements.exemplars",
+	"Attach an exemplar with the queryid and the mean time per call to the stat_statements total and execution time metrics. Exemplars are only exposed in the OpenMetrics format, which is negotiated when this is enabled.",
+).Default("false").Bool()
+
+var statStatementsTopN = kingpin.Flag(
+	"collector.stat_statements.top-n",
+	"Report the N statements with the highest total execution time in pg_stat_statements_top_exec_seconds, with their rank as a label. 0 disables it.",
+).Default("0").Int()
+
+var statStatementsSampleRatio = kingpin.Flag(
+	"collector.stat_statements.sample-ratio",
+	"Fraction greater than 0 and at most 1 of the statements reported by the stat_statements metrics. The statements are sampled by a hash of their queryid, so that the same statements are reported at every scrape.",
+).Default("1").Float64()
+
+// ExemplarsEnabled returns whether the collectors attach exemplars to their
+// metrics, which are only exposed when the OpenMetrics format is negotiated.
+func ExemplarsEnabled() bool {
+	return *statStatementsExemplars
+}
+
 func init() {
 	// WARNING:
 	//   Disabled by default because this set of metrics can be quite expensive on a busy server
 	//   Every unique query will cause a new timeseries to be created
-	registerCollector(statStatementsSubsystem, defaultDisabled, NewPGStatStatementsCollector)
+	registerCollector(statStatementsSubsystem, defaultDisabled, ScopeGlobal, NewPGStatStatementsCollector)
 }
 
 type PGStatStatementsCollector struct {
-	log log.Logger
+	log               log.Logger
+	omitNull          bool
+	excludeExporter   bool
+	includePlanID     bool
+	allDatabases      bool
+	excludedDatabases []string
+	roundMicros       bool
+	// queryInfo moves the labels other than queryid and planid to
+	// statStatementsInfo.
+	queryInfo bool
+	// rawValues reports the times in milliseconds.
+	rawValues bool
+	// exemplars attaches the queryid to the time metrics.
+	exemplars bool
+	// topN is the number of statements reported by
+	// statStatementsTopExecSeconds.
+	topN int
+	// sampleRatio is the fraction, greater than 0 and at most 1, of the
+	// queryids whose statements are reported. Statements are only sampled
+	// below 1.
+	sampleRatio float64
 }
 
 func NewPGStatStatementsCollector(config collectorConfig) (Collector, error) {
-	return &PGStatStatementsCollector{log: config.logger}, nil
+	if *statStatementsSampleRatio <= 0 || *statStatementsSampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio %v is not greater than 0 and at most 1", *statStatementsSampleRatio)
+	}
+	return &PGStatStatementsCollector{
+		log:               config.logger,
+		omitNull:          config.omitNull,
+		excludeExporter:   *statStatementsExcludeExporter,
+		includePlanID:     *statStatementsIncludePlanID,
+		allDatabases:      *statStatementsAllDatabases,
+		excludedDatabases: config.excludeDatabases,
+		roundMicros:       *statStatementsRoundMicros,
+		queryInfo:         *statStatementsQueryInfo,
+		rawValues:         *statStatementsRawValues,
+		exemplars:         *statStatementsExemplars,
+		topN:              *statStatementsTopN,
+		sampleRatio:       *statStatementsSampleRatio,
+	}, nil
+}
+
+// seconds converts a time in milliseconds to seconds, rounded to
+// microsecond precision if enabled. The conversion leaves float noise in the
+// values which changes across scrapes without the statistics changing.
+func (c PGStatStatementsCollector) seconds(v float64) float64 {
+	v /= 1000
+	if !c.roundMicros {
+		return v
+	}
+	return math.Round(v*1e6) / 1e6
+}
+
+// statStatementsDescs are the descriptors of the per-statement metrics.
+type statStatementsDescs struct {
+	callsTotal             *prometheus.Desc
+	secondsTotal           *prometheus.Desc
+	planSecondsTotal       *prometheus.Desc
+	execSecondsTotal       *prometheus.Desc
+	rowsTotal              *prometheus.Desc
+	blockReadSecondsTotal  *prometheus.Desc
+	blockWriteSecondsTotal *prometheus.Desc
+	sharedBlksDirtiedTotal *prometheus.Desc
+	sharedBlksWrittenTotal *prometheus.Desc
+	cacheHitRatio          *prometheus.Desc
+	rowsPerCall            *prometheus.Desc
+
+	jitFunctionsTotal           *prometheus.Desc
+	jitGenerationSecondsTotal   *prometheus.Desc
+	jitInliningSecondsTotal     *prometheus.Desc
+	jitOptimizationSecondsTotal *prometheus.Desc
+	jitEmissionSecondsTotal     *prometheus.Desc
+
+	// The time metrics of raw values mode.
+	timeMillisecondsTotal                *prometheus.Desc
+	planTimeMillisecondsTotal            *prometheus.Desc
+	execTimeMillisecondsTotal            *prometheus.Desc
+	blockReadTimeMillisecondsTotal       *prometheus.Desc
+	blockWriteTimeMillisecondsTotal      *prometheus.Desc
+	jitGenerationTimeMillisecondsTotal   *prometheus.Desc
+	jitInliningTimeMillisecondsTotal     *prometheus.Desc
+	jitOptimizationTimeMillisecondsTotal *prometheus.Desc
+	jitEmissionTimeMillisecondsTotal     *prometheus.Desc
+}
+
+func newStatStatementsDescs(labels []string) statStatementsDescs {
+	return statStatementsDescs{
+		callsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "calls_total"),
+			"Number of times executed",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.calls",
+		),
+		secondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "seconds_total"),
+			"Total time spent in the statement, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.total_plan_time and total_exec_time",
+		),
+		planSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "plan_seconds_total"),
+			"Total time spent planning the statement, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.total_plan_time",
+		),
+		execSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "exec_seconds_total"),
+			"Total time spent executing the statement, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.total_exec_time",
+		),
+		rowsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "rows_total"),
+			"Total number of rows retrieved or affected by the statement",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.rows",
+		),
+		blockReadSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "block_read_seconds_total"),
+			"Total time the statement spent reading blocks, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.blk_read_time",
+		),
+		blockWriteSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "block_write_seconds_total"),
+			"Total time the statement spent writing blocks, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.blk_write_time",
+		),
+		sharedBlksDirtiedTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "shared_blks_dirtied_total"),
+			"Total number of shared blocks dirtied by the statement",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.shared_blks_dirtied",
+		),
+		sharedBlksWrittenTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "shared_blks_written_total"),
+			"Total number of shared blocks written by the statement",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.shared_blks_written",
+		),
+		cacheHitRatio: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "cache_hit_ratio"),
+			"Ratio of shared blocks found in the buffer cache to all shared blocks accessed by the statement",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.shared_blks_hit and shared_blks_read",
+		),
+		rowsPerCall: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "rows_per_call"),
+			"Average number of rows retrieved or affected per execution of the statement",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.rows and calls",
+		),
+		jitFunctionsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_functions_total"),
+			"Total number of functions JIT-compiled by the statement",
+			labels,
+			prometheus.Labels{},
+			"", "pg_stat_statements.jit_functions",
+		),
+		jitGenerationSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_generation_seconds_total"),
+			"Total time the statement spent generating JIT code, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.jit_generation_time",
+		),
+		jitInliningSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_inlining_seconds_total"),
+			"Total time the statement spent inlining functions for JIT, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.jit_inlining_time",
+		),
+		jitOptimizationSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_optimization_seconds_total"),
+			"Total time the statement spent optimizing JIT code, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.jit_optimization_time",
+		),
+		jitEmissionSecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_emission_seconds_total"),
+			"Total time the statement spent emitting JIT code, in seconds",
+			labels,
+			prometheus.Labels{},
+			"seconds", "pg_stat_statements.jit_emission_time",
+		),
+		timeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "time_milliseconds_total"),
+			"Total time spent in the statement, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.total_plan_time and total_exec_time",
+		),
+		planTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "plan_time_milliseconds_total"),
+			"Total time spent planning the statement, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.total_plan_time",
+		),
+		execTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "exec_time_milliseconds_total"),
+			"Total time spent executing the statement, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.total_exec_time",
+		),
+		blockReadTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "block_read_time_milliseconds_total"),
+			"Total time the statement spent reading blocks, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.blk_read_time",
+		),
+		blockWriteTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "block_write_time_milliseconds_total"),
+			"Total time the statement spent writing blocks, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.blk_write_time",
+		),
+		jitGenerationTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_generation_time_milliseconds_total"),
+			"Total time the statement spent generating JIT code, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.jit_generation_time",
+		),
+		jitInliningTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_inlining_time_milliseconds_total"),
+			"Total time the statement spent inlining functions for JIT, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.jit_inlining_time",
+		),
+		jitOptimizationTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_optimization_time_milliseconds_total"),
+			"Total time the statement spent optimizing JIT code, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.jit_optimization_time",
+		),
+		jitEmissionTimeMillisecondsTotal: newDesc(
+			prometheus.BuildFQName(namespace, statStatementsSubsystem, "jit_emission_time_milliseconds_total"),
+			"Total time the statement spent emitting JIT code, in milliseconds",
+			labels,
+			prometheus.Labels{},
+			"milliseconds", "pg_stat_statements.jit_emission_time",
+		),
+	}
 }
 
 var (
-	statSTatementsCallsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, statStatementsSubsystem, "calls_total"),
-		"Number of times executed",
-		[]string{"user", "datname", "queryid"},
-		prometheus.Labels{},
-	)
-	statStatementsSecondsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, statStatementsSubsystem, "seconds_total"),
-		"Total time spent in the statement, in seconds",
-		[]string{"user", "datname", "queryid"},
+	statStatementsMetrics = newStatStatementsDescs([]string{"user", "datname", "queryid"})
+	// With the planid label, a statement whose plan changed is reported as a
+	// new series, which makes plan regressions visible.
+	statStatementsPlanMetrics = newStatStatementsDescs([]string{"user", "datname", "queryid", "planid"})
+
+	// In query info mode, the statements are identified by queryid only,
+	// and their text lives on a single series of statStatementsInfo.
+	statStatementsQueryIDMetrics     = newStatStatementsDescs([]string{"queryid"})
+	statStatementsQueryIDPlanMetrics = newStatStatementsDescs([]string{"queryid", "planid"})
+	statStatementsInfo               = newDesc(
+		prometheus.BuildFQName(namespace, statStatementsSubsystem, "info"),
+		"Information about a statement reported by the stat_statements metrics with the same queryid",
+		[]string{"queryid", "query", "user", "datname"},
 		prometheus.Labels{},
+		"", "pg_stat_statements.query",
 	)
-	statStatementsRowsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, statStatementsSubsystem, "rows_total"),
-		"Total number of rows retrieved or affected by the statement",
-		[]string{"user", "datname", "queryid"},
+
+	// The rank of a statement changes with the other statements, so its
+	// total execution time is a gauge.
+	statStatementsTopExecSeconds = newDesc(
+		prometheus.BuildFQName(namespace, statStatementsSubsystem, "top_exec_seconds"),
+		"Total time spent executing the statement, in seconds, for the statements with the highest total execution time ranked from 1",
+		[]string{"rank", "user", "datname", "queryid", "query"},
 		prometheus.Labels{},
+		"seconds", "pg_stat_statements.total_exec_time",
 	)
-	statStatementsBlockReadSecondsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, statStatementsSubsystem, "block_read_seconds_total"),
-		"Total time the statement spent reading blocks, in seconds",
-		[]string{"user", "datname", "queryid"},
+
+	pgDatabaseScrapeError = newDesc(
+		prometheus.BuildFQName(namespace, databaseSubsystem, "scrape_error"),
+		"Whether querying the statements of the database failed in all-databases mode (1 for error)",
+		[]string{"datname"},
 		prometheus.Labels{},
+		"", "",
 	)
-	statStatementsBlockWriteSecondsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, statStatementsSubsystem, "block_write_seconds_total"),
-		"Total time the statement spent writing blocks, in seconds",
-		[]string{"user", "datname", "queryid"},
+
+	statStatementsStatsReset = newDesc(
+		prometheus.BuildFQName(namespace, statStatementsSubsystem, "stats_reset_timestamp_seconds"),
+		"Unix timestamp of the last pg_stat_statements_reset() call. Not reported if the statistics were never reset",
+		[]string{},
 		prometheus.Labels{},
+		"seconds", "pg_stat_statements_info.stats_reset",
 	)
 
-	pgStatStatementsQuery = `SELECT
+	pgStatStatementsQueryTemplate = `SELECT
 		pg_get_userbyid(userid) as user,
 		pg_database.datname,
 		pg_stat_statements.queryid,
 		pg_stat_statements.calls as calls_total,
-		pg_stat_statements.total_time / 1000.0 as seconds_total,
+		pg_stat_statements.total_time,
 		pg_stat_statements.rows as rows_total,
-		pg_stat_statements.blk_read_time / 1000.0 as block_read_seconds_total,
-		pg_stat_statements.blk_write_time / 1000.0 as block_write_seconds_total
+		pg_stat_statements.blk_read_time,
+		pg_stat_statements.blk_write_time,
+		pg_stat_statements.shared_blks_hit,
+		pg_stat_statements.shared_blks_read,
+		pg_stat_statements.shared_blks_dirtied,
+		pg_stat_statements.shared_blks_written%s
 		FROM pg_stat_statements
 	JOIN pg_database
 		ON pg_database.oid = pg_stat_statements.dbid
@@ -88,98 +423,697 @@ var (
 			WITHIN GROUP (ORDER BY total_time)
 			FROM pg_stat_statements
 		)
-	ORDER BY seconds_total DESC
+		%s
+	ORDER BY total_time DESC
 	LIMIT 100;`
+
+	// PostgreSQL 13 split total_time into the planning and execution times.
+	pgStatStatementsPlanTimeQueryTemplate = `SELECT
+		pg_get_userbyid(userid) as user,
+		pg_database.datname,
+		pg_stat_statements.queryid,
+		pg_stat_statements.calls as calls_total,
+		pg_stat_statements.total_plan_time + pg_stat_statements.total_exec_time as total_time,
+		pg_stat_statements.rows as rows_total,
+		pg_stat_statements.blk_read_time,
+		pg_stat_statements.blk_write_time,
+		pg_stat_statements.shared_blks_hit,
+		pg_stat_statements.shared_blks_read,
+		pg_stat_statements.shared_blks_dirtied,
+		pg_stat_statements.shared_blks_written,
+		pg_stat_statements.total_plan_time,
+		pg_stat_statements.total_exec_time%s
+		FROM pg_stat_statements
+	JOIN pg_database
+		ON pg_database.oid = pg_stat_statements.dbid
+	WHERE
+		total_exec_time > (
+		SELECT percentile_cont(0.1)
+			WITHIN GROUP (ORDER BY total_exec_time)
+			FROM pg_stat_statements
+		)
+		%s
+	ORDER BY total_time DESC
+	LIMIT 100;`
+
+	// The top statements are ranked among all statements, without the
+	// cutoff, limit and sampling of the statement metrics. Before
+	// PostgreSQL 13, total_time is the execution time.
+	pgStatStatementsTopQueryTemplate = `SELECT
+		pg_get_userbyid(userid) as user,
+		pg_database.datname,
+		pg_stat_statements.queryid,
+		pg_stat_statements.%[1]s AS exec_time,
+		pg_stat_statements.query
+	FROM pg_stat_statements
+	JOIN pg_database
+		ON pg_database.oid = pg_stat_statements.dbid
+	WHERE
+		pg_stat_statements.%[1]s IS NOT NULL
+		%[2]s
+	ORDER BY pg_stat_statements.%[1]s DESC, pg_stat_statements.queryid
+	LIMIT $1`
+
+	// pg_stat_statements doesn't record the application_name, so statements
+	// are attributed to the exporter through the user and query_id of the
+	// statements of its connections in pg_stat_activity, added in PostgreSQL
+	// 14. The application_name is read from the connection, as one set in the
+	// DSN takes precedence over --db.application-name.
+	pgStatStatementsExporterStatementsQuery = `SELECT DISTINCT usesysid, query_id
+		FROM pg_stat_activity
+		WHERE application_name = current_setting('application_name')
+			AND usesysid IS NOT NULL AND query_id IS NOT NULL`
+	pgStatStatementsExcludeExporterFilter = `
+		AND (pg_stat_statements.userid, pg_stat_statements.queryid) NOT IN (
+			SELECT * FROM unnest($%d::oid[], $%d::bigint[]))`
+	// The queryids are hashed into statStatementsSampleBucketCount buckets,
+	// and the statements in the buckets below the parameter are sampled.
+	pgStatStatementsSampleFilter = `
+		AND (hashint8(pg_stat_statements.queryid)::bigint + 2147483648) %% %d < $%d`
+	// The JIT statistics were added in PostgreSQL 15.
+	pgStatStatementsJITColumns = `,
+		pg_stat_statements.jit_functions,
+		pg_stat_statements.jit_generation_time,
+		pg_stat_statements.jit_inlining_time,
+		pg_stat_statements.jit_optimization_time,
+		pg_stat_statements.jit_emission_time`
+	pgStatStatementsPlanIDColumn = `,
+		pg_stat_statements.planid`
+	pgStatStatementsQueryTextColumn = `,
+		pg_stat_statements.query`
+
+	pgStatStatementsQuery = fmt.Sprintf(pgStatStatementsQueryTemplate, "", "")
+
+	// Only some versions and forks of pg_stat_statements track plans.
+	pgStatStatementsHasPlanIDQuery = `SELECT EXISTS (
+		SELECT 1
+		FROM pg_attribute
+		WHERE attrelid = 'pg_stat_statements'::regclass
+			AND attname = 'planid'
+			AND NOT attisdropped
+	)`
+
+	// In all-databases mode, each connection only reports the statements of
+	// its own database.
+	pgStatStatementsCurrentDatabaseFilter = `AND pg_database.datname = current_database()`
+
+	// pg_stat_statements_info was added in PostgreSQL 14.
+	pgStatStatementsInfoQuery = `SELECT stats_reset FROM pg_stat_statements_info;`
 )
 
-func (PGStatStatementsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+func (c PGStatStatementsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	if c.allDatabases {
+		return c.updateAllDatabases(ctx, instance, ch)
+	}
+
 	db := instance.getDB()
-	rows, err := db.QueryContext(ctx,
-		pgStatStatementsQuery)
+	exporterStatements, err := c.queryExporterStatements(ctx, instance)
+	if err != nil {
+		return err
+	}
+	statements, err := c.queryStatements(ctx, db, instance.version, "", exporterStatements)
+	if err != nil {
+		return err
+	}
+	c.emitStatements(statements, ch)
+	if c.topN > 0 {
+		top, err := c.queryTopStatements(ctx, db, instance.version, "", exporterStatements)
+		if err != nil {
+			return err
+		}
+		c.emitTopStatements(top, ch)
+	}
+	return c.updateStatsReset(ctx, db, instance.version, ch)
+}
 
+// updateAllDatabases queries the statements of each database of the server
+// from a connection to that database. Databases without the extension are
+// skipped. Whether each database failed is reported by pgDatabaseScrapeError,
+// so that the others are still reported. The collector only fails when all
+// databases failed.
+func (c PGStatStatementsCollector) updateAllDatabases(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	databases, err := instance.connectableDatabases(ctx, c.excludedDatabases)
+	if err != nil {
+		return err
+	}
+	exporterStatements, err := c.queryExporterStatements(ctx, instance)
 	if err != nil {
 		return err
 	}
+
+	var statements []statStatement
+	var top []topStatement
+	var statsResetDB *sql.DB
+	var errs updateErrors
+	for _, datname := range databases {
+		db, err := instance.getDatabaseDB(datname)
+		var dbStatements []statStatement
+		var dbTop []topStatement
+		if err == nil {
+			dbStatements, err = c.queryStatements(ctx, db, instance.version, pgStatStatementsCurrentDatabaseFilter, exporterStatements)
+		}
+		if err == nil && c.topN > 0 {
+			dbTop, err = c.queryTopStatements(ctx, db, instance.version, pgStatStatementsCurrentDatabaseFilter, exporterStatements)
+		}
+		switch {
+		case isUndefinedTable(err):
+			level.Debug(c.log).Log("msg", "pg_stat_statements is not installed, skipping database", "datname", datname)
+		case err != nil:
+			level.Warn(c.log).Log("msg", "Failed to query pg_stat_statements, skipping database", "datname", datname, "err", err)
+			errs.add(fmt.Errorf("database %s: %w", datname, err))
+		default:
+			statements = append(statements, dbStatements...)
+			top = append(top, dbTop...)
+			if statsResetDB == nil {
+				statsResetDB = db
+			}
+		}
+
+		scrapeError := 0.0
+		if err != nil && !isUndefinedTable(err) {
+			scrapeError = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgDatabaseScrapeError,
+			prometheus.GaugeValue, scrapeError, datname,
+		)
+	}
+	if len(databases) > 0 && len(errs.errs) == len(databases) {
+		return errs.err()
+	}
+	c.emitStatements(statements, ch)
+	c.emitTopStatements(top, ch)
+
+	// The reset time is the same for all databases.
+	if statsResetDB == nil {
+		return nil
+	}
+	return c.updateStatsReset(ctx, statsResetDB, instance.version, ch)
+}
+
+// statStatement is a row of pg_stat_statements.
+type statStatement struct {
+	user, datname, queryid, query sql.NullString
+	// planid is only set when hasPlanID is.
+	planid    sql.NullString
+	hasPlanID bool
+	// The planning and execution times are only set when hasPlanTime is.
+	planTime, execTime sql.NullFloat64
+	hasPlanTime        bool
+	// The JIT statistics are only set when hasJIT is, the times are in
+	// milliseconds.
+	jitFunctions                                                             sql.NullInt64
+	jitGenerationTime, jitInliningTime, jitOptimizationTime, jitEmissionTime sql.NullFloat64
+	hasJIT                                                                   bool
+
+	callsTotal, rowsTotal, sharedBlksHit, sharedBlksRead sql.NullInt64
+	sharedBlksDirtied, sharedBlksWritten                 sql.NullInt64
+	// The times are in milliseconds.
+	totalTime, blkReadTime, blkWriteTime sql.NullFloat64
+}
+
+// add adds the counters of o to s.
+func (s *statStatement) add(o statStatement) {
+	s.callsTotal = addNullInt64(s.callsTotal, o.callsTotal)
+	s.rowsTotal = addNullInt64(s.rowsTotal, o.rowsTotal)
+	s.sharedBlksHit = addNullInt64(s.sharedBlksHit, o.sharedBlksHit)
+	s.sharedBlksRead = addNullInt64(s.sharedBlksRead, o.sharedBlksRead)
+	s.sharedBlksDirtied = addNullInt64(s.sharedBlksDirtied, o.sharedBlksDirtied)
+	s.sharedBlksWritten = addNullInt64(s.sharedBlksWritten, o.sharedBlksWritten)
+	s.totalTime = addNullFloat64(s.totalTime, o.totalTime)
+	s.blkReadTime = addNullFloat64(s.blkReadTime, o.blkReadTime)
+	s.blkWriteTime = addNullFloat64(s.blkWriteTime, o.blkWriteTime)
+	s.planTime = addNullFloat64(s.planTime, o.planTime)
+	s.execTime = addNullFloat64(s.execTime, o.execTime)
+	s.jitFunctions = addNullInt64(s.jitFunctions, o.jitFunctions)
+	s.jitGenerationTime = addNullFloat64(s.jitGenerationTime, o.jitGenerationTime)
+	s.jitInliningTime = addNullFloat64(s.jitInliningTime, o.jitInliningTime)
+	s.jitOptimizationTime = addNullFloat64(s.jitOptimizationTime, o.jitOptimizationTime)
+	s.jitEmissionTime = addNullFloat64(s.jitEmissionTime, o.jitEmissionTime)
+}
+
+func addNullInt64(a, b sql.NullInt64) sql.NullInt64 {
+	return sql.NullInt64{Int64: a.Int64 + b.Int64, Valid: a.Valid || b.Valid}
+}
+
+func addNullFloat64(a, b sql.NullFloat64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: a.Float64 + b.Float64, Valid: a.Valid || b.Valid}
+}
+
+func statStatementsLabel(v sql.NullString) string {
+	if v.Valid {
+		return v.String
+	}
+	return "unknown"
+}
+
+// queryExporterStatements returns the statements run by the exporter's
+// connections so far. pg_stat_activity only shows the latest statement of
+// each connection, so the statements are accumulated across scrapes.
+func (c PGStatStatementsCollector) queryExporterStatements(ctx context.Context, instance *instance) ([]exporterStatement, error) {
+	if !c.excludeExporter {
+		return nil, nil
+	}
+	if !instance.version.GE(semver.MustParse("14.0.0")) {
+		level.Debug(c.log).Log("msg", "pg_stat_activity has no query_id before PostgreSQL 14, not excluding the exporter's statements")
+		return nil, nil
+	}
+
+	rows, err := instance.getDB().QueryContext(ctx, pgStatStatementsExporterStatementsQuery)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
+
+	var statements []exporterStatement
 	for rows.Next() {
-		var user, datname, queryid sql.NullString
-		var callsTotal, rowsTotal sql.NullInt64
-		var secondsTotal, blockReadSecondsTotal, blockWriteSecondsTotal sql.NullFloat64
+		var s exporterStatement
+		if err := rows.Scan(&s.userid, &s.queryid); err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return instance.getSamples().exporterStatements.add(statements), nil
+}
 
-		if err := rows.Scan(&user, &datname, &queryid, &callsTotal, &secondsTotal, &rowsTotal, &blockReadSecondsTotal, &blockWriteSecondsTotal); err != nil {
-			return err
+// exporterStatement identifies a statement of the exporter in
+// pg_stat_statements. The queryid alone would also match the statements of
+// other users with the same normalized query.
+type exporterStatement struct {
+	userid  int64
+	queryid int64
+}
+
+// exporterStatements holds the statements of the exporter's connections seen
+// so far.
+type exporterStatements struct {
+	mtx        sync.Mutex
+	statements map[exporterStatement]struct{}
+}
+
+// add records statements and returns all the statements seen so far, sorted.
+func (s *exporterStatements) add(statements []exporterStatement) []exporterStatement {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.statements == nil {
+		s.statements = make(map[exporterStatement]struct{})
+	}
+	for _, statement := range statements {
+		s.statements[statement] = struct{}{}
+	}
+
+	all := make([]exporterStatement, 0, len(s.statements))
+	for statement := range s.statements {
+		all = append(all, statement)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].userid != all[j].userid {
+			return all[i].userid < all[j].userid
 		}
+		return all[i].queryid < all[j].queryid
+	})
+	return all
+}
 
-		userLabel := "unknown"
-		if user.Valid {
-			userLabel = user.String
+// excludeExporterFilter returns the filter excluding exporterStatements, and
+// args with its parameters appended.
+func excludeExporterFilter(args []interface{}, exporterStatements []exporterStatement) (string, []interface{}) {
+	if len(exporterStatements) == 0 {
+		return "", args
+	}
+	userids := make(pq.Int64Array, len(exporterStatements))
+	queryids := make(pq.Int64Array, len(exporterStatements))
+	for i, s := range exporterStatements {
+		userids[i] = s.userid
+		queryids[i] = s.queryid
+	}
+	filter := fmt.Sprintf(pgStatStatementsExcludeExporterFilter, len(args)+1, len(args)+2)
+	return filter, append(args, userids, queryids)
+}
+
+// queryStatements returns the statements returned by db. databaseFilter is
+// added to the conditions of the query, and exporterStatements are excluded.
+func (c PGStatStatementsCollector) queryStatements(ctx context.Context, db *sql.DB, version semver.Version, databaseFilter string, exporterStatements []exporterStatement) ([]statStatement, error) {
+	planID := false
+	if c.includePlanID && version.GE(semver.MustParse("16.0.0")) {
+		if err := db.QueryRowContext(ctx, pgStatStatementsHasPlanIDQuery).Scan(&planID); err != nil {
+			return nil, err
 		}
-		datnameLabel := "unknown"
-		if datname.Valid {
-			datnameLabel = datname.String
+	}
+
+	planTime := version.GE(semver.MustParse("13.0.0"))
+	template := pgStatStatementsQueryTemplate
+	if planTime {
+		template = pgStatStatementsPlanTimeQueryTemplate
+	}
+
+	jit := version.GE(semver.MustParse("15.0.0"))
+
+	var columns string
+	var args []interface{}
+	filter := databaseFilter
+	if jit {
+		columns += pgStatStatementsJITColumns
+	}
+	if planID {
+		columns += pgStatStatementsPlanIDColumn
+	}
+	queryText := c.queryInfo
+	if queryText {
+		columns += pgStatStatementsQueryTextColumn
+	}
+	exclude, args := excludeExporterFilter(args, exporterStatements)
+	filter += exclude
+	if c.sampleRatio > 0 && c.sampleRatio < 1 {
+		// The sample is taken before the statements are limited, so
+		// that it is representative of all queryids.
+		filter += fmt.Sprintf(pgStatStatementsSampleFilter, statStatementsSampleBucketCount, len(args)+1)
+		args = append(args, statStatementsSampleBuckets(c.sampleRatio))
+	}
+
+	rows, err := db.QueryContext(ctx,
+		fmt.Sprintf(template, columns, filter), args...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []statStatement
+	for rows.Next() {
+		s := statStatement{hasPlanID: planID, hasPlanTime: planTime, hasJIT: jit}
+		dest := []interface{}{&s.user, &s.datname, &s.queryid, &s.callsTotal, &s.totalTime, &s.rowsTotal, &s.blkReadTime, &s.blkWriteTime, &s.sharedBlksHit, &s.sharedBlksRead, &s.sharedBlksDirtied, &s.sharedBlksWritten}
+		if planTime {
+			dest = append(dest, &s.planTime, &s.execTime)
 		}
-		queryidLabel := "unknown"
-		if queryid.Valid {
-			queryidLabel = queryid.String
+		if jit {
+			dest = append(dest, &s.jitFunctions, &s.jitGenerationTime, &s.jitInliningTime, &s.jitOptimizationTime, &s.jitEmissionTime)
 		}
+		if planID {
+			dest = append(dest, &s.planid)
+		}
+		if queryText {
+			dest = append(dest, &s.query)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
 
-		callsTotalMetric := 0.0
-		if callsTotal.Valid {
-			callsTotalMetric = float64(callsTotal.Int64)
+// statStatementsSampleBucketCount is the number of buckets the queryids are
+// hashed into by pgStatStatementsSampleFilter.
+const statStatementsSampleBucketCount = 1000000
+
+// statStatementsSampleBuckets returns the number of buckets whose queryids
+// are sampled with ratio. The queryids sampled with a ratio are also sampled
+// with any higher ratio.
+func statStatementsSampleBuckets(ratio float64) int {
+	return int(math.Round(ratio * statStatementsSampleBucketCount))
+}
+
+// emitStatements emits the metrics of statements. In query info mode, the
+// user, datname and query text of each statement are moved to an info metric,
+// and the counters of the statements sharing a queryid are summed.
+func (c PGStatStatementsCollector) emitStatements(statements []statStatement, ch chan<- prometheus.Metric) {
+	if !c.queryInfo {
+		for _, s := range statements {
+			labels := []string{statStatementsLabel(s.user), statStatementsLabel(s.datname), statStatementsLabel(s.queryid)}
+			metrics := statStatementsMetrics
+			if s.hasPlanID {
+				labels = append(labels, statStatementsLabel(s.planid))
+				metrics = statStatementsPlanMetrics
+			}
+			c.emitStatement(s, metrics, labels, ch)
 		}
+		return
+	}
+
+	type statementKey struct {
+		queryid, planid string
+		hasPlanID       bool
+	}
+	var keys []statementKey
+	merged := make(map[statementKey]*statStatement)
+	infos := make(map[[4]string]bool)
+	for _, s := range statements {
+		info := [4]string{statStatementsLabel(s.queryid), statStatementsLabel(s.query), statStatementsLabel(s.user), statStatementsLabel(s.datname)}
+		if !infos[info] {
+			infos[info] = true
+			ch <- prometheus.MustNewConstMetric(
+				statStatementsInfo,
+				prometheus.GaugeValue,
+				1,
+				info[:]...,
+			)
+		}
+
+		key := statementKey{queryid: statStatementsLabel(s.queryid), hasPlanID: s.hasPlanID}
+		if s.hasPlanID {
+			key.planid = statStatementsLabel(s.planid)
+		}
+		if m, ok := merged[key]; ok {
+			m.add(s)
+			continue
+		}
+		s := s
+		merged[key] = &s
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		labels := []string{key.queryid}
+		metrics := statStatementsQueryIDMetrics
+		if key.hasPlanID {
+			labels = append(labels, key.planid)
+			metrics = statStatementsQueryIDPlanMetrics
+		}
+		c.emitStatement(*merged[key], metrics, labels, ch)
+	}
+}
+
+// topStatement is a statement ranked by statStatementsTopExecSeconds.
+type topStatement struct {
+	user, datname, queryid, query sql.NullString
+	// execTime is in milliseconds.
+	execTime float64
+}
+
+// queryTopStatements returns the topN statements of db with the highest total
+// execution time. databaseFilter is added to the conditions of the query, and
+// exporterStatements are excluded.
+func (c PGStatStatementsCollector) queryTopStatements(ctx context.Context, db *sql.DB, version semver.Version, databaseFilter string, exporterStatements []exporterStatement) ([]topStatement, error) {
+	column := "total_time"
+	if version.GE(semver.MustParse("13.0.0")) {
+		column = "total_exec_time"
+	}
+
+	filter := databaseFilter
+	args := []interface{}{c.topN}
+	exclude, args := excludeExporterFilter(args, exporterStatements)
+	filter += exclude
+
+	rows, err := db.QueryContext(ctx,
+		fmt.Sprintf(pgStatStatementsTopQueryTemplate, column, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var top []topStatement
+	for rows.Next() {
+		var s topStatement
+		if err := rows.Scan(&s.user, &s.datname, &s.queryid, &s.execTime, &s.query); err != nil {
+			return nil, err
+		}
+		top = append(top, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+// emitTopStatements ranks top, the top statements of one or more databases,
+// and emits the total execution time of the topN statements with their rank.
+func (c PGStatStatementsCollector) emitTopStatements(top []topStatement, ch chan<- prometheus.Metric) {
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].execTime != top[j].execTime {
+			return top[i].execTime > top[j].execTime
+		}
+		return top[i].queryid.String < top[j].queryid.String
+	})
+	if len(top) > c.topN {
+		top = top[:c.topN]
+	}
+
+	for i, s := range top {
 		ch <- prometheus.MustNewConstMetric(
-			statSTatementsCallsTotal,
-			prometheus.CounterValue,
-			callsTotalMetric,
-			userLabel, datnameLabel, queryidLabel,
+			statStatementsTopExecSeconds,
+			prometheus.GaugeValue,
+			c.seconds(s.execTime),
+			strconv.Itoa(i+1), statStatementsLabel(s.user), statStatementsLabel(s.datname), statStatementsLabel(s.queryid), statStatementsLabel(s.query),
 		)
+	}
+}
 
-		secondsTotalMetric := 0.0
-		if secondsTotal.Valid {
-			secondsTotalMetric = secondsTotal.Float64
+// emitStatement emits the metrics of a statement with the given labels.
+func (c PGStatStatementsCollector) emitStatement(s statStatement, metrics statStatementsDescs, labels []string, ch chan<- prometheus.Metric) {
+	if s.callsTotal.Valid || !c.omitNull {
+		callsTotalMetric := 0.0
+		if s.callsTotal.Valid {
+			callsTotalMetric = float64(s.callsTotal.Int64)
 		}
 		ch <- prometheus.MustNewConstMetric(
-			statStatementsSecondsTotal,
+			metrics.callsTotal,
 			prometheus.CounterValue,
-			secondsTotalMetric,
-			userLabel, datnameLabel, queryidLabel,
+			callsTotalMetric,
+			labels...,
 		)
+	}
 
+	c.emitTime(metrics.secondsTotal, metrics.timeMillisecondsTotal, s.totalTime, labels, c.exemplar(s, s.totalTime), ch)
+	if s.hasPlanTime {
+		c.emitTime(metrics.planSecondsTotal, metrics.planTimeMillisecondsTotal, s.planTime, labels, nil, ch)
+		c.emitTime(metrics.execSecondsTotal, metrics.execTimeMillisecondsTotal, s.execTime, labels, c.exemplar(s, s.execTime), ch)
+	}
+
+	if s.rowsTotal.Valid || !c.omitNull {
 		rowsTotalMetric := 0.0
-		if rowsTotal.Valid {
-			rowsTotalMetric = float64(rowsTotal.Int64)
+		if s.rowsTotal.Valid {
+			rowsTotalMetric = float64(s.rowsTotal.Int64)
 		}
 		ch <- prometheus.MustNewConstMetric(
-			statStatementsRowsTotal,
+			metrics.rowsTotal,
 			prometheus.CounterValue,
 			rowsTotalMetric,
-			userLabel, datnameLabel, queryidLabel,
+			labels...,
+		)
+	}
+	// Statements which were never executed have no rows per call.
+	if s.rowsTotal.Valid && s.callsTotal.Valid && s.callsTotal.Int64 > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			metrics.rowsPerCall,
+			prometheus.GaugeValue,
+			float64(s.rowsTotal.Int64)/float64(s.callsTotal.Int64),
+			labels...,
 		)
+	}
+
+	c.emitTime(metrics.blockReadSecondsTotal, metrics.blockReadTimeMillisecondsTotal, s.blkReadTime, labels, nil, ch)
+	c.emitTime(metrics.blockWriteSecondsTotal, metrics.blockWriteTimeMillisecondsTotal, s.blkWriteTime, labels, nil, ch)
 
-		blockReadSecondsTotalMetric := 0.0
-		if blockReadSecondsTotal.Valid {
-			blockReadSecondsTotalMetric = blockReadSecondsTotal.Float64
+	for _, m := range []struct {
+		desc  *prometheus.Desc
+		value sql.NullInt64
+	}{
+		{metrics.sharedBlksDirtiedTotal, s.sharedBlksDirtied},
+		{metrics.sharedBlksWrittenTotal, s.sharedBlksWritten},
+	} {
+		if !m.value.Valid && c.omitNull {
+			continue
 		}
 		ch <- prometheus.MustNewConstMetric(
-			statStatementsBlockReadSecondsTotal,
+			m.desc,
 			prometheus.CounterValue,
-			blockReadSecondsTotalMetric,
-			userLabel, datnameLabel, queryidLabel,
+			float64(m.value.Int64),
+			labels...,
 		)
+	}
 
-		blockWriteSecondsTotalMetric := 0.0
-		if blockWriteSecondsTotal.Valid {
-			blockWriteSecondsTotalMetric = blockWriteSecondsTotal.Float64
+	if s.hasJIT {
+		if s.jitFunctions.Valid || !c.omitNull {
+			ch <- prometheus.MustNewConstMetric(
+				metrics.jitFunctionsTotal,
+				prometheus.CounterValue,
+				float64(s.jitFunctions.Int64),
+				labels...,
+			)
 		}
+		c.emitTime(metrics.jitGenerationSecondsTotal, metrics.jitGenerationTimeMillisecondsTotal, s.jitGenerationTime, labels, nil, ch)
+		c.emitTime(metrics.jitInliningSecondsTotal, metrics.jitInliningTimeMillisecondsTotal, s.jitInliningTime, labels, nil, ch)
+		c.emitTime(metrics.jitOptimizationSecondsTotal, metrics.jitOptimizationTimeMillisecondsTotal, s.jitOptimizationTime, labels, nil, ch)
+		c.emitTime(metrics.jitEmissionSecondsTotal, metrics.jitEmissionTimeMillisecondsTotal, s.jitEmissionTime, labels, nil, ch)
+	}
+
+	// Statements which never touched a shared block have no meaningful ratio.
+	if s.sharedBlksHit.Valid && s.sharedBlksRead.Valid && s.sharedBlksHit.Int64+s.sharedBlksRead.Int64 > 0 {
 		ch <- prometheus.MustNewConstMetric(
-			statStatementsBlockWriteSecondsTotal,
+			metrics.cacheHitRatio,
+			prometheus.GaugeValue,
+			float64(s.sharedBlksHit.Int64)/float64(s.sharedBlksHit.Int64+s.sharedBlksRead.Int64),
+			labels...,
+		)
+	}
+}
+
+// emitTime emits a time given in milliseconds as the seconds metric, or as
+// the milliseconds metric in raw values mode. The exemplar, if any, is
+// attached to the metric.
+func (c PGStatStatementsCollector) emitTime(seconds, milliseconds *prometheus.Desc, v sql.NullFloat64, labels []string, exemplar *prometheus.Exemplar, ch chan<- prometheus.Metric) {
+	if !v.Valid && c.omitNull {
+		return
+	}
+	var m prometheus.Metric
+	if c.rawValues {
+		m = prometheus.MustNewConstMetric(
+			milliseconds,
+			prometheus.CounterValue,
+			v.Float64,
+			labels...,
+		)
+	} else {
+		m = prometheus.MustNewConstMetric(
+			seconds,
 			prometheus.CounterValue,
-			blockWriteSecondsTotalMetric,
-			userLabel, datnameLabel, queryidLabel,
+			c.seconds(v.Float64),
+			labels...,
 		)
 	}
-	if err := rows.Err(); err != nil {
-		return err
+	if exemplar != nil {
+		m = prometheus.MustNewMetricWithExemplars(m, *exemplar)
+	}
+	ch <- m
+}
+
+// exemplar returns the exemplar of a time metric of the statement, which
+// carries its queryid and the mean time per call, or nil if exemplars are
+// disabled or the statement has no calls.
+func (c PGStatStatementsCollector) exemplar(s statStatement, v sql.NullFloat64) *prometheus.Exemplar {
+	if !c.exemplars || !s.queryid.Valid || !v.Valid || !s.callsTotal.Valid || s.callsTotal.Int64 <= 0 {
+		return nil
+	}
+	mean := v.Float64 / float64(s.callsTotal.Int64)
+	if !c.rawValues {
+		mean = c.seconds(mean)
+	}
+	return &prometheus.Exemplar{
+		Value:  mean,
+		Labels: prometheus.Labels{"queryid": s.queryid.String},
+	}
+}
+
+func (c PGStatStatementsCollector) updateStatsReset(ctx context.Context, db *sql.DB, version semver.Version, ch chan<- prometheus.Metric) error {
+	if version.GE(semver.MustParse("14.0.0")) {
+		var statsReset sql.NullTime
+		if err := db.QueryRowContext(ctx, pgStatStatementsInfoQuery).Scan(&statsReset); err != nil {
+			return err
+		}
+		if statsReset.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				statStatementsStatsReset,
+				prometheus.GaugeValue,
+				float64(statsReset.Time.Unix()),
+			)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,74 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statStatementsEntriesSubsystem = "stat_statements_entries"
+
+func init() {
+	registerCollector(statStatementsEntriesSubsystem, defaultDisabled, ScopeGlobal, NewPGStatStatementsEntriesCollector)
+}
+
+// PGStatStatementsEntriesCollector counts the entries of pg_stat_statements,
+// i.e. the distinct statements the stat_statements collector would report a
+// series per metric for. It is cheap enough to gauge the cardinality before
+// enabling the stat_statements collector.
+type PGStatStatementsEntriesCollector struct {
+	log log.Logger
+}
+
+func NewPGStatStatementsEntriesCollector(config collectorConfig) (Collector, error) {
+	return &PGStatStatementsEntriesCollector{log: config.logger}, nil
+}
+
+var (
+	pgStatStatementsEntriesCount = newDesc(
+		prometheus.BuildFQName(namespace, statStatementsSubsystem, "entries_count"),
+		"Number of distinct statements tracked by pg_stat_statements",
+		[]string{}, nil,
+		"", "pg_stat_statements",
+	)
+
+	// The query texts aren't needed to count the entries, and reading them
+	// from the file they are kept in is the expensive part.
+	pgStatStatementsEntriesQuery = "SELECT count(*) FROM pg_stat_statements(false)"
+)
+
+func (c PGStatStatementsEntriesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	row := db.QueryRowContext(ctx,
+		pgStatStatementsEntriesQuery)
+
+	var count sql.NullInt64
+	if err := row.Scan(&count); err != nil {
+		if isUndefinedFunction(err) {
+			level.Debug(c.log).Log("msg", "pg_stat_statements is not installed")
+			return ErrNoData
+		}
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		pgStatStatementsEntriesCount,
+		prometheus.GaugeValue, float64(count.Int64),
+	)
+	return nil
+}
@@ -14,9 +14,18 @@ package collector
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/smartystreets/goconvey/convey"
@@ -31,9 +40,9 @@ func TestPGStateStatementsCollector(t *testing.T) {
 
 	inst := &instance{db: db}
 
-	columns := []string{"user", "datname", "queryid", "calls_total", "seconds_total", "rows_total", "block_read_seconds_total", "block_write_seconds_total"}
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
 	rows := sqlmock.NewRows(columns).
-		AddRow("postgres", "postgres", 1500, 5, 0.4, 100, 0.1, 0.2)
+		AddRow("postgres", "postgres", 1500, 5, 400, 100, 100, 200, 75, 25, 12, 4)
 	mock.ExpectQuery(sanitizeQuery(pgStatStatementsQuery)).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
@@ -50,8 +59,12 @@ func TestPGStateStatementsCollector(t *testing.T) {
 		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
 		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.4},
 		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
 		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
 		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 0.75},
 	}
 
 	convey.Convey("Metrics comparison", t, func() {
@@ -74,9 +87,9 @@ func TestPGStateStatementsCollectorNull(t *testing.T) {
 
 	inst := &instance{db: db}
 
-	columns := []string{"user", "datname", "queryid", "calls_total", "seconds_total", "rows_total", "block_read_seconds_total", "block_write_seconds_total"}
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
 	rows := sqlmock.NewRows(columns).
-		AddRow(nil, nil, nil, nil, nil, nil, nil, nil)
+		AddRow(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	mock.ExpectQuery(sanitizeQuery(pgStatStatementsQuery)).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
@@ -95,6 +108,832 @@ func TestPGStateStatementsCollectorNull(t *testing.T) {
 		{labels: labelMap{"user": "unknown", "datname": "unknown", "queryid": "unknown"}, metricType: dto.MetricType_COUNTER, value: 0},
 		{labels: labelMap{"user": "unknown", "datname": "unknown", "queryid": "unknown"}, metricType: dto.MetricType_COUNTER, value: 0},
 		{labels: labelMap{"user": "unknown", "datname": "unknown", "queryid": "unknown"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "unknown", "datname": "unknown", "queryid": "unknown"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "unknown", "datname": "unknown", "queryid": "unknown"}, metricType: dto.MetricType_COUNTER, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorStatsReset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("14.0.0")}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time"}
+	rows := sqlmock.NewRows(columns)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", ""))).WillReturnRows(rows)
+
+	srT, err := time.Parse("2006-01-02 15:04:05.00000-07", "2023-05-25 17:10:42.81132-07")
+	if err != nil {
+		t.Fatalf("Error parsing time: %s", err)
+	}
+	infoRows := sqlmock.NewRows([]string{"stats_reset"}).
+		AddRow(srT)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsInfoQuery)).WillReturnRows(infoRows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 1685059842},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorOmitNull(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 5, nil, 100, 100, 200, 0, 0, 12, 4)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{omitNull: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	// seconds_total is NULL, so it's skipped entirely.
+	expected := []MetricResult{
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorCacheHitRatio(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 5, 400, 100, 100, 200, 9900, 100, 12, 4).
+		AddRow("postgres", "postgres", 1501, 1, 2500, 10, 2000, 0, 10, 990, 12, 4).
+		AddRow("postgres", "postgres", 1502, 1, 100, 1, 0, 0, 0, 0, 12, 4)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	ratios := map[string]float64{}
+	for m := range ch {
+		if m.Desc() != statStatementsMetrics.cacheHitRatio {
+			continue
+		}
+		r := readMetric(m)
+		ratios[r.labels["queryid"]] = r.value
+	}
+
+	convey.Convey("Cache hit ratios", t, func() {
+		convey.So(ratios, convey.ShouldResemble, map[string]float64{
+			"1500": 0.99,
+			"1501": 0.01,
+		})
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorRowsPerCall(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	rows := sqlmock.NewRows(columns).
+		// A report which aggregates a sequential scan on every call.
+		AddRow("postgres", "postgres", 1500, 4, 400000, 2000000, 0, 0, 0, 0, 0, 0).
+		AddRow("postgres", "postgres", 1501, 1000, 500, 1000, 0, 0, 0, 0, 0, 0).
+		// Only planned so far, e.g. with pg_stat_statements.track_planning.
+		AddRow("postgres", "postgres", 1502, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	rowsPerCall := map[string]float64{}
+	for m := range ch {
+		if m.Desc() != statStatementsMetrics.rowsPerCall {
+			continue
+		}
+		r := readMetric(m)
+		rowsPerCall[r.labels["queryid"]] = r.value
+	}
+
+	convey.Convey("Rows per call", t, func() {
+		convey.So(rowsPerCall, convey.ShouldResemble, map[string]float64{
+			"1500": 500000,
+			"1501": 1,
+		})
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorExcludeExporter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("14.0.0")}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "plan_time", "exec_time"}
+	query := sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", fmt.Sprintf(pgStatStatementsExcludeExporterFilter, 1, 2)))
+
+	// The exporter's statements accumulate across scrapes, and are excluded
+	// by user and queryid so that the statements of other users with the
+	// same queryids are still reported.
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsExporterStatementsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"usesysid", "query_id"}).AddRow(10, 1501))
+	mock.ExpectQuery(query).
+		WithArgs(pq.Int64Array{10}, pq.Int64Array{1501}).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("app", "postgres", 1500, 5, 400, 100, 100, 200, 0, 0, 12, 4, 100, 300))
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsInfoQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"stats_reset"}).AddRow(nil))
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsExporterStatementsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"usesysid", "query_id"}).AddRow(10, 1502).AddRow(11, 1501))
+	mock.ExpectQuery(query).
+		WithArgs(pq.Int64Array{10, 10, 11}, pq.Int64Array{1501, 1502, 1501}).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsInfoQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"stats_reset"}).AddRow(nil))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{excludeExporter: true}
+
+		for i := 0; i < 2; i++ {
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+			}
+		}
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		var queryids []string
+		for m := range ch {
+			queryids = append(queryids, readMetric(m).labels["queryid"])
+		}
+		convey.So(queryids, convey.ShouldNotBeEmpty)
+		for _, queryid := range queryids {
+			convey.So(queryid, convey.ShouldEqual, "1500")
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorExcludeExporterBefore14(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("13.0.0")}
+
+	// pg_stat_activity has no query_id, so nothing is excluded.
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "plan_time", "exec_time"}
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", ""))).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{log: log.NewNopLogger(), excludeExporter: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestExporterStatementsAdd(t *testing.T) {
+	var s exporterStatements
+	if got := s.add(nil); len(got) != 0 {
+		t.Errorf("add(nil) = %v, want none", got)
+	}
+	s.add([]exporterStatement{{userid: 10, queryid: 3}, {userid: 11, queryid: 1}})
+	got := s.add([]exporterStatement{{userid: 10, queryid: 1}, {userid: 10, queryid: 3}})
+	want := []exporterStatement{{userid: 10, queryid: 1}, {userid: 10, queryid: 3}, {userid: 11, queryid: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("add() = %v, want %v", got, want)
+	}
+}
+
+func TestPGStateStatementsCollectorPlanID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsHasPlanIDQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time", "jit_functions", "jit_generation_time", "jit_inlining_time", "jit_optimization_time", "jit_emission_time", "planid"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 5, 400, 100, 100, 200, 0, 0, 12, 4, 100, 300, 0, 0, 0, 0, 0, 9001).
+		AddRow("postgres", "postgres", 1500, 2, 3500, 40, 500, 0, 0, 0, 12, 4, 500, 3000, 0, 0, 0, 0, 0, 9002)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, pgStatStatementsJITColumns+pgStatStatementsPlanIDColumn, ""))).WillReturnRows(rows)
+
+	infoRows := sqlmock.NewRows([]string{"stats_reset"}).AddRow(nil)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsInfoQuery)).WillReturnRows(infoRows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{includePlanID: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0.4},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0.3},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9001"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 2},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 3.5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 3},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 40},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500", "planid": "9002"}, metricType: dto.MetricType_COUNTER, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorAllDatabases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+	postgresDB, postgresMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer postgresDB.Close()
+	appDB, appMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer appDB.Close()
+
+	inst := &instance{
+		db: db,
+		databases: map[string]*sql.DB{
+			"postgres": postgresDB,
+			"app":      appDB,
+		},
+	}
+
+	mock.ExpectQuery(sanitizeQuery(connectableDatabasesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"datname"}).AddRow("postgres").AddRow("app").AddRow("excluded"))
+
+	query := sanitizeQuery(fmt.Sprintf(pgStatStatementsQueryTemplate, "", pgStatStatementsCurrentDatabaseFilter))
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	postgresMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 5, 400, 100, 100, 200, 0, 0, 12, 4))
+	appMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("app", "app", 2500, 50, 4000, 10, 300, 100, 0, 0, 12, 4))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{allDatabases: true, excludedDatabases: []string{"excluded"}}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "postgres"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"datname": "app"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.4},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 50},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 10},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_GAUGE, value: 0.2},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 0.3},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "app", "datname": "app", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	for _, m := range []sqlmock.Sqlmock{mock, postgresMock, appMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	}
+}
+
+func TestPGStateStatementsCollectorRoundMicros(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 5, 123.4567891, 100, 300.00000000000004, 0.0004, 0, 0, 12, 4)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{roundMicros: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.123457},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.3},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorRawValues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("13.0.0")}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 5, 512.25, 100, 12.5, 3.75, 0, 0, 12, 4, 2.25, 510)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", ""))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{rawValues: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []struct {
+		name  string
+		value float64
+	}{
+		{"pg_stat_statements_calls_total", 5},
+		{"pg_stat_statements_time_milliseconds_total", 512.25},
+		{"pg_stat_statements_plan_time_milliseconds_total", 2.25},
+		{"pg_stat_statements_exec_time_milliseconds_total", 510},
+		{"pg_stat_statements_rows_total", 100},
+		{"pg_stat_statements_rows_per_call", 20},
+		{"pg_stat_statements_block_read_time_milliseconds_total", 12.5},
+		{"pg_stat_statements_block_write_time_milliseconds_total", 3.75},
+		{"pg_stat_statements_shared_blks_dirtied_total", 12},
+		{"pg_stat_statements_shared_blks_written_total", 4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := <-ch
+			convey.So(m.Desc().String(), convey.ShouldContainSubstring, `"`+expect.name+`"`)
+			convey.So(readMetric(m).value, convey.ShouldEqual, expect.value)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorExemplars(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("13.0.0")}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "postgres", 1500, 4, 5000, 100, 0, 0, 0, 0, 0, 0, 1000, 4000)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", ""))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{exemplars: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	// The exemplars carry the mean time per call.
+	expected := map[string]float64{
+		"pg_stat_statements_seconds_total":      1.25,
+		"pg_stat_statements_exec_seconds_total": 1,
+	}
+
+	convey.Convey("Exemplars carry the queryid", t, func() {
+		exemplars := 0
+		for m := range ch {
+			pb := &dto.Metric{}
+			convey.So(m.Write(pb), convey.ShouldBeNil)
+			exemplar := pb.GetCounter().GetExemplar()
+			var name string
+			for n := range expected {
+				if strings.Contains(m.Desc().String(), `"`+n+`"`) {
+					name = n
+				}
+			}
+			if name == "" {
+				convey.So(exemplar, convey.ShouldBeNil)
+				continue
+			}
+			exemplars++
+			convey.So(exemplar, convey.ShouldNotBeNil)
+			convey.So(exemplar.GetValue(), convey.ShouldEqual, expected[name])
+			convey.So(exemplar.GetLabel(), convey.ShouldHaveLength, 1)
+			convey.So(exemplar.GetLabel()[0].GetName(), convey.ShouldEqual, "queryid")
+			convey.So(exemplar.GetLabel()[0].GetValue(), convey.ShouldEqual, "1500")
+		}
+		convey.So(exemplars, convey.ShouldEqual, len(expected))
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorQueryInfo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "query"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "postgres", 1500, 5, 400, 100, 100, 200, 75, 25, 12, 4, "SELECT * FROM orders WHERE id = $1").
+		AddRow("reporting", "postgres", 1500, 3, 200, 60, 100, 100, 25, 75, 12, 4, "SELECT * FROM orders WHERE id = $1").
+		AddRow("app", "postgres", 1600, 1, 500, 1, 0, 0, 0, 0, 12, 4, "VACUUM orders")
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsQueryTemplate, pgStatStatementsQueryTextColumn, ""))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{queryInfo: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"queryid": "1500", "query": "SELECT * FROM orders WHERE id = $1", "user": "app", "datname": "postgres"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"queryid": "1500", "query": "SELECT * FROM orders WHERE id = $1", "user": "reporting", "datname": "postgres"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"queryid": "1600", "query": "VACUUM orders", "user": "app", "datname": "postgres"}, metricType: dto.MetricType_GAUGE, value: 1},
+		// The counters of both users of queryid 1500 are summed.
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 8},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.6},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 160},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.3},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 24},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 8},
+		{labels: labelMap{"queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 0.5},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 1},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 1},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"queryid": "1600"}, metricType: dto.MetricType_COUNTER, value: 4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorAllDatabasesPartialFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+	firstDB, firstMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer firstDB.Close()
+	secondDB, secondMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer secondDB.Close()
+	thirdDB, thirdMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer thirdDB.Close()
+
+	inst := &instance{
+		db: db,
+		databases: map[string]*sql.DB{
+			"first":  firstDB,
+			"second": secondDB,
+			"third":  thirdDB,
+		},
+	}
+
+	mock.ExpectQuery(sanitizeQuery(connectableDatabasesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"datname"}).AddRow("first").AddRow("second").AddRow("third"))
+
+	query := sanitizeQuery(fmt.Sprintf(pgStatStatementsQueryTemplate, "", pgStatStatementsCurrentDatabaseFilter))
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	firstMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("app", "first", 1500, 5, 400, 100, 100, 200, 0, 0, 12, 4))
+	secondMock.ExpectQuery(query).WillReturnError(
+		&pq.Error{Code: "57P03", Message: "the database system is starting up"})
+	thirdMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("app", "third", 2500, 50, 4000, 10, 300, 100, 0, 0, 12, 4))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{log: log.NewNopLogger(), allDatabases: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "first"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"datname": "second"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "third"}, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.4},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "app", "datname": "first", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 50},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 4},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 10},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_GAUGE, value: 0.2},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 0.3},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 12},
+		{labels: labelMap{"user": "app", "datname": "third", "queryid": "2500"}, metricType: dto.MetricType_COUNTER, value: 4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	for _, m := range []sqlmock.Sqlmock{mock, firstMock, secondMock, thirdMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	}
+}
+
+func TestPGStateStatementsCollectorAllDatabasesFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+	firstDB, firstMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer firstDB.Close()
+	secondDB, secondMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer secondDB.Close()
+
+	inst := &instance{
+		db: db,
+		databases: map[string]*sql.DB{
+			"first":  firstDB,
+			"second": secondDB,
+		},
+	}
+
+	mock.ExpectQuery(sanitizeQuery(connectableDatabasesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"datname"}).AddRow("first").AddRow("second"))
+
+	query := sanitizeQuery(fmt.Sprintf(pgStatStatementsQueryTemplate, "", pgStatStatementsCurrentDatabaseFilter))
+	permissionDenied := &pq.Error{Code: "42501", Message: "permission denied for view pg_stat_statements"}
+	firstMock.ExpectQuery(query).WillReturnError(permissionDenied)
+	secondMock.ExpectQuery(query).WillReturnError(permissionDenied)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{log: log.NewNopLogger(), allDatabases: true}
+
+		if err := c.Update(context.Background(), inst, ch); !isPermissionDenied(err) {
+			t.Errorf("Expected PGStatStatementsCollector.Update to fail when all databases fail, got: %v", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "first"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"datname": "second"}, metricType: dto.MetricType_GAUGE, value: 1},
 	}
 
 	convey.Convey("Metrics comparison", t, func() {
@@ -102,8 +941,411 @@ func TestPGStateStatementsCollectorNull(t *testing.T) {
 			m := readMetric(<-ch)
 			convey.So(expect, convey.ShouldResemble, m)
 		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	for _, m := range []sqlmock.Sqlmock{mock, firstMock, secondMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	}
+}
+
+func TestPGStateStatementsCollectorPlanTime(t *testing.T) {
+	for _, tc := range []struct {
+		version  string
+		query    string
+		columns  []string
+		row      []driver.Value
+		expected []MetricResult
+	}{
+		{
+			version: "13.0.0",
+			query:   fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", ""),
+			columns: []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time"},
+			row:     []driver.Value{"postgres", "postgres", 1500, 5, 500, 100, 100, 200, 0, 0, 12, 4, 125, 375},
+			expected: []MetricResult{
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.125},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.375},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+			},
+		},
+		{
+			// Before PostgreSQL 13 only the combined time is reported.
+			version: "12.0.0",
+			query:   pgStatStatementsQuery,
+			columns: []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"},
+			row:     []driver.Value{"postgres", "postgres", 1500, 5, 500, 100, 100, 200, 0, 0, 12, 4},
+			expected: []MetricResult{
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+			},
+		},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db, version: semver.MustParse(tc.version)}
+
+			mock.ExpectQuery(sanitizeQuery(tc.query)).WillReturnRows(
+				sqlmock.NewRows(tc.columns).AddRow(tc.row...))
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				defer close(ch)
+				c := PGStatStatementsCollector{}
+
+				if err := c.Update(context.Background(), inst, ch); err != nil {
+					t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+				}
+			}()
+
+			convey.Convey("Metrics comparison", t, func() {
+				for _, expect := range tc.expected {
+					m := readMetric(<-ch)
+					convey.So(expect, convey.ShouldResemble, m)
+				}
+				_, ok := <-ch
+				convey.So(ok, convey.ShouldBeFalse)
+			})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
+
+func TestPGStateStatementsCollectorJIT(t *testing.T) {
+	for _, tc := range []struct {
+		version  string
+		query    string
+		columns  []string
+		row      []driver.Value
+		expected []MetricResult
+	}{
+		{
+			version: "15.0.0",
+			query:   fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, pgStatStatementsJITColumns, ""),
+			columns: []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time", "jit_functions", "jit_generation_time", "jit_inlining_time", "jit_optimization_time", "jit_emission_time"},
+			row:     []driver.Value{"postgres", "postgres", 1500, 5, 500, 100, 100, 200, 0, 0, 12, 4, 125, 375, 42, 15, 50, 120, 65},
+			expected: []MetricResult{
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.125},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.375},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 42},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.015},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.05},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.12},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.065},
+			},
+		},
+		{
+			// The JIT statistics aren't queried before PostgreSQL 15.
+			version: "14.0.0",
+			query:   fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", ""),
+			columns: []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "total_plan_time", "total_exec_time"},
+			row:     []driver.Value{"postgres", "postgres", 1500, 5, 500, 100, 100, 200, 0, 0, 12, 4, 125, 375},
+			expected: []MetricResult{
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.5},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.125},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.375},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 100},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_GAUGE, value: 20},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.1},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 0.2},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 12},
+				{labels: labelMap{"user": "postgres", "datname": "postgres", "queryid": "1500"}, metricType: dto.MetricType_COUNTER, value: 4},
+			},
+		},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db, version: semver.MustParse(tc.version)}
+
+			mock.ExpectQuery(sanitizeQuery(tc.query)).WillReturnRows(
+				sqlmock.NewRows(tc.columns).AddRow(tc.row...))
+			mock.ExpectQuery(sanitizeQuery(pgStatStatementsInfoQuery)).WillReturnRows(
+				sqlmock.NewRows([]string{"stats_reset"}).AddRow(nil))
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				defer close(ch)
+				c := PGStatStatementsCollector{}
+
+				if err := c.Update(context.Background(), inst, ch); err != nil {
+					t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+				}
+			}()
+
+			convey.Convey("Metrics comparison", t, func() {
+				for _, expect := range tc.expected {
+					m := readMetric(<-ch)
+					convey.So(expect, convey.ShouldResemble, m)
+				}
+				_, ok := <-ch
+				convey.So(ok, convey.ShouldBeFalse)
+			})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
+
+func TestPGStateStatementsCollectorTopN(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("13.0.0")}
+
+	// Only 1500 is sampled for the statement metrics, which doesn't limit the
+	// statements which are ranked.
+	filter := fmt.Sprintf(pgStatStatementsSampleFilter, statStatementsSampleBucketCount, 1)
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "plan_time", "exec_time"}
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", filter))).WithArgs(500000).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow("app", "postgres", 1500, 5, 900, 100, 100, 200, 75, 25, 12, 4, 500, 400))
+
+	// The top statements are ranked by their execution time, not their
+	// planning and execution time.
+	topColumns := []string{"user", "datname", "queryid", "exec_time", "query"}
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsTopQueryTemplate, "total_exec_time", ""))).WithArgs(3).WillReturnRows(
+		sqlmock.NewRows(topColumns).
+			AddRow("app", "postgres", 1700, 2500, "VACUUM orders").
+			AddRow("app", "postgres", 1500, 400, "SELECT * FROM orders WHERE id = $1").
+			AddRow("app", "postgres", 1800, 400, "SELECT 1"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{topN: 3, sampleRatio: 0.5}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"rank": "1", "user": "app", "datname": "postgres", "queryid": "1700", "query": "VACUUM orders"}, metricType: dto.MetricType_GAUGE, value: 2.5},
+		{labels: labelMap{"rank": "2", "user": "app", "datname": "postgres", "queryid": "1500", "query": "SELECT * FROM orders WHERE id = $1"}, metricType: dto.MetricType_GAUGE, value: 0.4},
+		{labels: labelMap{"rank": "3", "user": "app", "datname": "postgres", "queryid": "1800", "query": "SELECT 1"}, metricType: dto.MetricType_GAUGE, value: 0.4},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		var top []MetricResult
+		for m := range ch {
+			if m.Desc() == statStatementsTopExecSeconds {
+				top = append(top, readMetric(m))
+			}
+		}
+		convey.So(top, convey.ShouldResemble, expected)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorTopNAllDatabases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+	postgresDB, postgresMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer postgresDB.Close()
+	appDB, appMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer appDB.Close()
+
+	inst := &instance{
+		db: db,
+		databases: map[string]*sql.DB{
+			"postgres": postgresDB,
+			"app":      appDB,
+		},
+	}
+
+	mock.ExpectQuery(sanitizeQuery(connectableDatabasesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"datname"}).AddRow("postgres").AddRow("app"))
+
+	query := sanitizeQuery(fmt.Sprintf(pgStatStatementsQueryTemplate, "", pgStatStatementsCurrentDatabaseFilter))
+	topQuery := sanitizeQuery(fmt.Sprintf(pgStatStatementsTopQueryTemplate, "total_time", pgStatStatementsCurrentDatabaseFilter))
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	topColumns := []string{"user", "datname", "queryid", "exec_time", "query"}
+	postgresMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns))
+	postgresMock.ExpectQuery(topQuery).WithArgs(2).WillReturnRows(sqlmock.NewRows(topColumns).
+		AddRow("postgres", "postgres", 1500, 300, "SELECT 1").
+		AddRow("postgres", "postgres", 1600, 100, "SELECT 2"))
+	appMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns))
+	appMock.ExpectQuery(topQuery).WithArgs(2).WillReturnRows(sqlmock.NewRows(topColumns).
+		AddRow("app", "app", 2500, 200, "SELECT 3"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{topN: 2, allDatabases: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	// The statements are ranked across the databases.
+	expected := []MetricResult{
+		{labels: labelMap{"rank": "1", "user": "postgres", "datname": "postgres", "queryid": "1500", "query": "SELECT 1"}, metricType: dto.MetricType_GAUGE, value: 0.3},
+		{labels: labelMap{"rank": "2", "user": "app", "datname": "app", "queryid": "2500", "query": "SELECT 3"}, metricType: dto.MetricType_GAUGE, value: 0.2},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		var top []MetricResult
+		for m := range ch {
+			if m.Desc() == statStatementsTopExecSeconds {
+				top = append(top, readMetric(m))
+			}
+		}
+		convey.So(top, convey.ShouldResemble, expected)
+	})
+	for _, m := range []sqlmock.Sqlmock{mock, postgresMock, appMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	}
+}
+
+func TestPGStateStatementsCollectorSampleRatio(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	// The sample is taken in the query, before the statements are limited,
+	// and the same buckets are sampled at every scrape.
+	filter := fmt.Sprintf(pgStatStatementsSampleFilter, statStatementsSampleBucketCount, 1)
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written"}
+	for i := 0; i < 2; i++ {
+		rows := sqlmock.NewRows(columns).
+			AddRow("app", "postgres", 1700, 1, 2500, 1, 0, 0, 0, 0, 12, 4)
+		mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsQueryTemplate, "", filter))).WithArgs(500000).WillReturnRows(rows)
+	}
+
+	for i := 0; i < 2; i++ {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			c := PGStatStatementsCollector{sampleRatio: 0.5}
+
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+			}
+		}()
+
+		convey.Convey("Sampled queryids", t, func() {
+			var queryids []string
+			for m := range ch {
+				if m.Desc() == statStatementsMetrics.callsTotal {
+					queryids = append(queryids, readMetric(m).labels["queryid"])
+				}
+			}
+			convey.So(queryids, convey.ShouldResemble, []string{"1700"})
+		})
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGStateStatementsCollectorSampleRatioExcludeExporter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("14.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsExporterStatementsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"usesysid", "query_id"}).AddRow(10, 1501))
+	// The sample parameter follows the statements of the exporter.
+	filter := fmt.Sprintf(pgStatStatementsExcludeExporterFilter, 1, 2) + fmt.Sprintf(pgStatStatementsSampleFilter, statStatementsSampleBucketCount, 3)
+	columns := []string{"user", "datname", "queryid", "calls_total", "total_time", "rows_total", "blk_read_time", "blk_write_time", "shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written", "plan_time", "exec_time"}
+	rows := sqlmock.NewRows(columns)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(pgStatStatementsPlanTimeQueryTemplate, "", filter))).WithArgs(pq.Int64Array{10}, pq.Int64Array{1501}, 250000).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(pgStatStatementsInfoQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"stats_reset"}).AddRow(nil))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatStatementsCollector{sampleRatio: 0.25, excludeExporter: true}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatStatementsCollector.Update: %s", err)
+		}
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
 	})
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestStatStatementsSampleBuckets(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  int
+	}{
+		{ratio: 0.000001, want: 1},
+		{ratio: 0.25, want: 250000},
+		{ratio: 0.5, want: 500000},
+		{ratio: 1, want: statStatementsSampleBucketCount},
+	}
+	for _, tt := range tests {
+		if got := statStatementsSampleBuckets(tt.ratio); got != tt.want {
+			t.Errorf("statStatementsSampleBuckets(%v) = %v, want %v", tt.ratio, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statUserIndexesSubsystem = "stat_user_indexes"
+
+func init() {
+	registerCollector(statUserIndexesSubsystem, defaultDisabled, ScopeDatabase, NewPGStatUserIndexesCollector)
+}
+
+type PGStatUserIndexesCollector struct {
+	log log.Logger
+}
+
+func NewPGStatUserIndexesCollector(config collectorConfig) (Collector, error) {
+	return &PGStatUserIndexesCollector{log: config.logger}, nil
+}
+
+var (
+	statUserIndexesUnusedSinceReset = newDesc(
+		prometheus.BuildFQName(namespace, "index", "unused_since_reset_seconds"),
+		"Seconds since the statistics of the database were last reset (or the server started, if they were never reset) for indexes which have not been scanned since",
+		[]string{"datname", "schemaname", "relname", "indexrelname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_user_indexes.idx_scan and pg_stat_database.stats_reset",
+	)
+
+	// An index with zero scans is only known to be unused since the
+	// statistics were last reset, so report how long that window is.
+	statUserIndexesUnusedQuery = `SELECT
+		current_database() datname,
+		s.schemaname,
+		s.relname,
+		s.indexrelname,
+		COALESCE(d.stats_reset, pg_postmaster_start_time()) AS since,
+		now() AS now
+	FROM pg_stat_user_indexes s
+	JOIN pg_stat_database d
+		ON d.datname = current_database()
+	WHERE s.idx_scan = 0`
+)
+
+func (c PGStatUserIndexesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		statUserIndexesUnusedQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname, indexrelname sql.NullString
+		var since, now sql.NullTime
+		if err := rows.Scan(&datname, &schemaname, &relname, &indexrelname, &since, &now); err != nil {
+			return err
+		}
+
+		if !since.Valid || !now.Valid {
+			continue
+		}
+
+		datnameLabel := "unknown"
+		if datname.Valid {
+			datnameLabel = datname.String
+		}
+		schemanameLabel := "unknown"
+		if schemaname.Valid {
+			schemanameLabel = schemaname.String
+		}
+		relnameLabel := "unknown"
+		if relname.Valid {
+			relnameLabel = relname.String
+		}
+		indexrelnameLabel := "unknown"
+		if indexrelname.Valid {
+			indexrelnameLabel = indexrelname.String
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			statUserIndexesUnusedSinceReset,
+			prometheus.GaugeValue,
+			now.Time.Sub(since.Time).Seconds(),
+			datnameLabel, schemanameLabel, relnameLabel, indexrelnameLabel,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGStatUserIndexesCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	recentReset := now.Add(-24 * time.Hour)
+	oldReset := now.Add(-90 * 24 * time.Hour)
+
+	columns := []string{"datname", "schemaname", "relname", "indexrelname", "since", "now"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("postgres", "public", "orders", "orders_created_idx", recentReset, now).
+		AddRow("app", "public", "users", "users_legacy_idx", oldReset, now)
+	mock.ExpectQuery(sanitizeQuery(statUserIndexesUnusedQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatUserIndexesCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatUserIndexesCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"datname": "postgres", "schemaname": "public", "relname": "orders", "indexrelname": "orders_created_idx"}, metricType: dto.MetricType_GAUGE, value: 86400},
+		{labels: labelMap{"datname": "app", "schemaname": "public", "relname": "users", "indexrelname": "users_legacy_idx"}, metricType: dto.MetricType_GAUGE, value: 7776000},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
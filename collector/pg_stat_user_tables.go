@@ -24,131 +24,151 @@ import (
 const userTableSubsystem = "stat_user_tables"
 
 func init() {
-	registerCollector(userTableSubsystem, defaultEnabled, NewPGStatUserTablesCollector)
+	registerCollector(userTableSubsystem, defaultEnabled, ScopeDatabase, NewPGStatUserTablesCollector)
 }
 
 type PGStatUserTablesCollector struct {
-	log log.Logger
+	log      log.Logger
+	omitNull bool
 }
 
 func NewPGStatUserTablesCollector(config collectorConfig) (Collector, error) {
-	return &PGStatUserTablesCollector{log: config.logger}, nil
+	return &PGStatUserTablesCollector{log: config.logger, omitNull: config.omitNull}, nil
 }
 
 var (
-	statUserTablesSeqScan = prometheus.NewDesc(
+	statUserTablesSeqScan = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "seq_scan"),
 		"Number of sequential scans initiated on this table",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.seq_scan",
 	)
-	statUserTablesSeqTupRead = prometheus.NewDesc(
+	statUserTablesSeqTupRead = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "seq_tup_read"),
 		"Number of live rows fetched by sequential scans",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.seq_tup_read",
 	)
-	statUserTablesIdxScan = prometheus.NewDesc(
+	statUserTablesIdxScan = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "idx_scan"),
 		"Number of index scans initiated on this table",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.idx_scan",
 	)
-	statUserTablesIdxTupFetch = prometheus.NewDesc(
+	statUserTablesIdxTupFetch = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "idx_tup_fetch"),
 		"Number of live rows fetched by index scans",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.idx_tup_fetch",
 	)
-	statUserTablesNTupIns = prometheus.NewDesc(
+	statUserTablesNTupIns = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_tup_ins"),
 		"Number of rows inserted",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_tup_ins",
 	)
-	statUserTablesNTupUpd = prometheus.NewDesc(
+	statUserTablesNTupUpd = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_tup_upd"),
 		"Number of rows updated",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_tup_upd",
 	)
-	statUserTablesNTupDel = prometheus.NewDesc(
+	statUserTablesNTupDel = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_tup_del"),
 		"Number of rows deleted",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_tup_del",
 	)
-	statUserTablesNTupHotUpd = prometheus.NewDesc(
+	statUserTablesNTupHotUpd = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_tup_hot_upd"),
 		"Number of rows HOT updated (i.e., with no separate index update required)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_tup_hot_upd",
 	)
-	statUserTablesNLiveTup = prometheus.NewDesc(
+	statUserTablesNLiveTup = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_live_tup"),
 		"Estimated number of live rows",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_live_tup",
 	)
-	statUserTablesNDeadTup = prometheus.NewDesc(
+	statUserTablesNDeadTup = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_dead_tup"),
 		"Estimated number of dead rows",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_dead_tup",
 	)
-	statUserTablesNModSinceAnalyze = prometheus.NewDesc(
+	statUserTablesNModSinceAnalyze = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "n_mod_since_analyze"),
 		"Estimated number of rows changed since last analyze",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_mod_since_analyze",
 	)
-	statUserTablesLastVacuum = prometheus.NewDesc(
+	statUserTablesLastVacuum = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "last_vacuum"),
 		"Last time at which this table was manually vacuumed (not counting VACUUM FULL)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"seconds", "pg_stat_user_tables.last_vacuum",
 	)
-	statUserTablesLastAutovacuum = prometheus.NewDesc(
+	statUserTablesLastAutovacuum = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "last_autovacuum"),
 		"Last time at which this table was vacuumed by the autovacuum daemon",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"seconds", "pg_stat_user_tables.last_autovacuum",
 	)
-	statUserTablesLastAnalyze = prometheus.NewDesc(
+	statUserTablesLastAnalyze = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "last_analyze"),
 		"Last time at which this table was manually analyzed",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"seconds", "pg_stat_user_tables.last_analyze",
 	)
-	statUserTablesLastAutoanalyze = prometheus.NewDesc(
+	statUserTablesLastAutoanalyze = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "last_autoanalyze"),
 		"Last time at which this table was analyzed by the autovacuum daemon",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"seconds", "pg_stat_user_tables.last_autoanalyze",
 	)
-	statUserTablesVacuumCount = prometheus.NewDesc(
+	statUserTablesVacuumCount = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "vacuum_count"),
 		"Number of times this table has been manually vacuumed (not counting VACUUM FULL)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.vacuum_count",
 	)
-	statUserTablesAutovacuumCount = prometheus.NewDesc(
+	statUserTablesAutovacuumCount = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "autovacuum_count"),
 		"Number of times this table has been vacuumed by the autovacuum daemon",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.autovacuum_count",
 	)
-	statUserTablesAnalyzeCount = prometheus.NewDesc(
+	statUserTablesAnalyzeCount = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "analyze_count"),
 		"Number of times this table has been manually analyzed",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.analyze_count",
 	)
-	statUserTablesAutoanalyzeCount = prometheus.NewDesc(
+	statUserTablesAutoanalyzeCount = newDesc(
 		prometheus.BuildFQName(namespace, userTableSubsystem, "autoanalyze_count"),
 		"Number of times this table has been analyzed by the autovacuum daemon",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_stat_user_tables.autoanalyze_count",
 	)
 
 	statUserTablesQuery = `SELECT
@@ -211,214 +231,252 @@ func (c *PGStatUserTablesCollector) Update(ctx context.Context, instance *instan
 			relnameLabel = relname.String
 		}
 
-		seqScanMetric := 0.0
-		if seqScan.Valid {
-			seqScanMetric = float64(seqScan.Int64)
+		if seqScan.Valid || !c.omitNull {
+			seqScanMetric := 0.0
+			if seqScan.Valid {
+				seqScanMetric = float64(seqScan.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesSeqScan,
+				prometheus.CounterValue,
+				seqScanMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesSeqScan,
-			prometheus.CounterValue,
-			seqScanMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		seqTupReadMetric := 0.0
-		if seqTupRead.Valid {
-			seqTupReadMetric = float64(seqTupRead.Int64)
+
+		if seqTupRead.Valid || !c.omitNull {
+			seqTupReadMetric := 0.0
+			if seqTupRead.Valid {
+				seqTupReadMetric = float64(seqTupRead.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesSeqTupRead,
+				prometheus.CounterValue,
+				seqTupReadMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesSeqTupRead,
-			prometheus.CounterValue,
-			seqTupReadMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		idxScanMetric := 0.0
-		if idxScan.Valid {
-			idxScanMetric = float64(idxScan.Int64)
+
+		if idxScan.Valid || !c.omitNull {
+			idxScanMetric := 0.0
+			if idxScan.Valid {
+				idxScanMetric = float64(idxScan.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesIdxScan,
+				prometheus.CounterValue,
+				idxScanMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesIdxScan,
-			prometheus.CounterValue,
-			idxScanMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		idxTupFetchMetric := 0.0
-		if idxTupFetch.Valid {
-			idxTupFetchMetric = float64(idxTupFetch.Int64)
+
+		if idxTupFetch.Valid || !c.omitNull {
+			idxTupFetchMetric := 0.0
+			if idxTupFetch.Valid {
+				idxTupFetchMetric = float64(idxTupFetch.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesIdxTupFetch,
+				prometheus.CounterValue,
+				idxTupFetchMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesIdxTupFetch,
-			prometheus.CounterValue,
-			idxTupFetchMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nTupInsMetric := 0.0
-		if nTupIns.Valid {
-			nTupInsMetric = float64(nTupIns.Int64)
+
+		if nTupIns.Valid || !c.omitNull {
+			nTupInsMetric := 0.0
+			if nTupIns.Valid {
+				nTupInsMetric = float64(nTupIns.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNTupIns,
+				prometheus.CounterValue,
+				nTupInsMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNTupIns,
-			prometheus.CounterValue,
-			nTupInsMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nTupUpdMetric := 0.0
-		if nTupUpd.Valid {
-			nTupUpdMetric = float64(nTupUpd.Int64)
+
+		if nTupUpd.Valid || !c.omitNull {
+			nTupUpdMetric := 0.0
+			if nTupUpd.Valid {
+				nTupUpdMetric = float64(nTupUpd.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNTupUpd,
+				prometheus.CounterValue,
+				nTupUpdMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNTupUpd,
-			prometheus.CounterValue,
-			nTupUpdMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nTupDelMetric := 0.0
-		if nTupDel.Valid {
-			nTupDelMetric = float64(nTupDel.Int64)
+
+		if nTupDel.Valid || !c.omitNull {
+			nTupDelMetric := 0.0
+			if nTupDel.Valid {
+				nTupDelMetric = float64(nTupDel.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNTupDel,
+				prometheus.CounterValue,
+				nTupDelMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNTupDel,
-			prometheus.CounterValue,
-			nTupDelMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nTupHotUpdMetric := 0.0
-		if nTupHotUpd.Valid {
-			nTupHotUpdMetric = float64(nTupHotUpd.Int64)
+
+		if nTupHotUpd.Valid || !c.omitNull {
+			nTupHotUpdMetric := 0.0
+			if nTupHotUpd.Valid {
+				nTupHotUpdMetric = float64(nTupHotUpd.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNTupHotUpd,
+				prometheus.CounterValue,
+				nTupHotUpdMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNTupHotUpd,
-			prometheus.CounterValue,
-			nTupHotUpdMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nLiveTupMetric := 0.0
-		if nLiveTup.Valid {
-			nLiveTupMetric = float64(nLiveTup.Int64)
+
+		if nLiveTup.Valid || !c.omitNull {
+			nLiveTupMetric := 0.0
+			if nLiveTup.Valid {
+				nLiveTupMetric = float64(nLiveTup.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNLiveTup,
+				prometheus.GaugeValue,
+				nLiveTupMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNLiveTup,
-			prometheus.GaugeValue,
-			nLiveTupMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nDeadTupMetric := 0.0
-		if nDeadTup.Valid {
-			nDeadTupMetric = float64(nDeadTup.Int64)
+
+		if nDeadTup.Valid || !c.omitNull {
+			nDeadTupMetric := 0.0
+			if nDeadTup.Valid {
+				nDeadTupMetric = float64(nDeadTup.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNDeadTup,
+				prometheus.GaugeValue,
+				nDeadTupMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNDeadTup,
-			prometheus.GaugeValue,
-			nDeadTupMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		nModSinceAnalyzeMetric := 0.0
-		if nModSinceAnalyze.Valid {
-			nModSinceAnalyzeMetric = float64(nModSinceAnalyze.Int64)
+
+		if nModSinceAnalyze.Valid || !c.omitNull {
+			nModSinceAnalyzeMetric := 0.0
+			if nModSinceAnalyze.Valid {
+				nModSinceAnalyzeMetric = float64(nModSinceAnalyze.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesNModSinceAnalyze,
+				prometheus.GaugeValue,
+				nModSinceAnalyzeMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesNModSinceAnalyze,
-			prometheus.GaugeValue,
-			nModSinceAnalyzeMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		lastVacuumMetric := 0.0
-		if lastVacuum.Valid {
-			lastVacuumMetric = float64(lastVacuum.Time.Unix())
+
+		if lastVacuum.Valid || !c.omitNull {
+			lastVacuumMetric := 0.0
+			if lastVacuum.Valid {
+				lastVacuumMetric = float64(lastVacuum.Time.Unix())
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesLastVacuum,
+				prometheus.GaugeValue,
+				lastVacuumMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesLastVacuum,
-			prometheus.GaugeValue,
-			lastVacuumMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		lastAutovacuumMetric := 0.0
-		if lastAutovacuum.Valid {
-			lastAutovacuumMetric = float64(lastAutovacuum.Time.Unix())
+
+		if lastAutovacuum.Valid || !c.omitNull {
+			lastAutovacuumMetric := 0.0
+			if lastAutovacuum.Valid {
+				lastAutovacuumMetric = float64(lastAutovacuum.Time.Unix())
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesLastAutovacuum,
+				prometheus.GaugeValue,
+				lastAutovacuumMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesLastAutovacuum,
-			prometheus.GaugeValue,
-			lastAutovacuumMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		lastAnalyzeMetric := 0.0
-		if lastAnalyze.Valid {
-			lastAnalyzeMetric = float64(lastAnalyze.Time.Unix())
+
+		if lastAnalyze.Valid || !c.omitNull {
+			lastAnalyzeMetric := 0.0
+			if lastAnalyze.Valid {
+				lastAnalyzeMetric = float64(lastAnalyze.Time.Unix())
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesLastAnalyze,
+				prometheus.GaugeValue,
+				lastAnalyzeMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesLastAnalyze,
-			prometheus.GaugeValue,
-			lastAnalyzeMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		lastAutoanalyzeMetric := 0.0
-		if lastAutoanalyze.Valid {
-			lastAutoanalyzeMetric = float64(lastAutoanalyze.Time.Unix())
+
+		if lastAutoanalyze.Valid || !c.omitNull {
+			lastAutoanalyzeMetric := 0.0
+			if lastAutoanalyze.Valid {
+				lastAutoanalyzeMetric = float64(lastAutoanalyze.Time.Unix())
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesLastAutoanalyze,
+				prometheus.GaugeValue,
+				lastAutoanalyzeMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesLastAutoanalyze,
-			prometheus.GaugeValue,
-			lastAutoanalyzeMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		vacuumCountMetric := 0.0
-		if vacuumCount.Valid {
-			vacuumCountMetric = float64(vacuumCount.Int64)
+
+		if vacuumCount.Valid || !c.omitNull {
+			vacuumCountMetric := 0.0
+			if vacuumCount.Valid {
+				vacuumCountMetric = float64(vacuumCount.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesVacuumCount,
+				prometheus.CounterValue,
+				vacuumCountMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesVacuumCount,
-			prometheus.CounterValue,
-			vacuumCountMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		autovacuumCountMetric := 0.0
-		if autovacuumCount.Valid {
-			autovacuumCountMetric = float64(autovacuumCount.Int64)
+
+		if autovacuumCount.Valid || !c.omitNull {
+			autovacuumCountMetric := 0.0
+			if autovacuumCount.Valid {
+				autovacuumCountMetric = float64(autovacuumCount.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesAutovacuumCount,
+				prometheus.CounterValue,
+				autovacuumCountMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesAutovacuumCount,
-			prometheus.CounterValue,
-			autovacuumCountMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		analyzeCountMetric := 0.0
-		if analyzeCount.Valid {
-			analyzeCountMetric = float64(analyzeCount.Int64)
+
+		if analyzeCount.Valid || !c.omitNull {
+			analyzeCountMetric := 0.0
+			if analyzeCount.Valid {
+				analyzeCountMetric = float64(analyzeCount.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesAnalyzeCount,
+				prometheus.CounterValue,
+				analyzeCountMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesAnalyzeCount,
-			prometheus.CounterValue,
-			analyzeCountMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		autoanalyzeCountMetric := 0.0
-		if autoanalyzeCount.Valid {
-			autoanalyzeCountMetric = float64(autoanalyzeCount.Int64)
+
+		if autoanalyzeCount.Valid || !c.omitNull {
+			autoanalyzeCountMetric := 0.0
+			if autoanalyzeCount.Valid {
+				autoanalyzeCountMetric = float64(autoanalyzeCount.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statUserTablesAutoanalyzeCount,
+				prometheus.CounterValue,
+				autoanalyzeCountMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statUserTablesAutoanalyzeCount,
-			prometheus.CounterValue,
-			autoanalyzeCountMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
 	}
 
 	if err := rows.Err(); err != nil {
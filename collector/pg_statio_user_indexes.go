@@ -0,0 +1,163 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statioUserIndexesSubsystem = "statio_user_indexes"
+
+var (
+	statioUserIndexesExcludeSchemas = kingpin.Flag(
+		"collector.statio_user_indexes.exclude-schema",
+		"Schema to exclude from the statio_user_indexes collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	statioUserIndexesLimit = kingpin.Flag(
+		"collector.statio_user_indexes.limit",
+		"Maximum number of indexes reported by the statio_user_indexes collector. The indexes with the most blocks read from disk are reported first.",
+	).Default("100").Int()
+)
+
+func init() {
+	registerCollector(statioUserIndexesSubsystem, defaultDisabled, ScopeDatabase, NewPGStatIOUserIndexesCollector)
+}
+
+// PGStatIOUserIndexesCollector reports the buffer cache effectiveness of the
+// indexes of the database.
+type PGStatIOUserIndexesCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGStatIOUserIndexesCollector(config collectorConfig) (Collector, error) {
+	return &PGStatIOUserIndexesCollector{
+		log:            config.logger,
+		excludeSchemas: *statioUserIndexesExcludeSchemas,
+		limit:          *statioUserIndexesLimit,
+	}, nil
+}
+
+var (
+	statioUserIndexesIdxBlksRead = newDesc(
+		prometheus.BuildFQName(namespace, statioUserIndexesSubsystem, "idx_blocks_read"),
+		"Number of disk blocks read from this index",
+		[]string{"datname", "schemaname", "relname", "indexrelname"},
+		prometheus.Labels{},
+		"", "pg_statio_user_indexes.idx_blks_read",
+	)
+	statioUserIndexesIdxBlksHit = newDesc(
+		prometheus.BuildFQName(namespace, statioUserIndexesSubsystem, "idx_blocks_hit"),
+		"Number of buffer hits in this index",
+		[]string{"datname", "schemaname", "relname", "indexrelname"},
+		prometheus.Labels{},
+		"", "pg_statio_user_indexes.idx_blks_hit",
+	)
+	statioIndexCacheHitRatio = newDesc(
+		prometheus.BuildFQName(namespace, "statio_index", "cache_hit_ratio"),
+		"Ratio of the blocks of this index found in the buffer cache to all blocks of it accessed",
+		[]string{"datname", "schemaname", "relname", "indexrelname"},
+		prometheus.Labels{},
+		"", "pg_statio_user_indexes.idx_blks_hit and idx_blks_read",
+	)
+
+	statioUserIndexesQuery = `SELECT
+		current_database() datname,
+		schemaname,
+		relname,
+		indexrelname,
+		idx_blks_read,
+		idx_blks_hit
+	FROM pg_statio_user_indexes
+	WHERE schemaname <> ALL($1)
+	ORDER BY idx_blks_read DESC
+	LIMIT $2`
+)
+
+func (c PGStatIOUserIndexesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		statioUserIndexesQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname, indexrelname sql.NullString
+		var idxBlksRead, idxBlksHit sql.NullInt64
+		if err := rows.Scan(&datname, &schemaname, &relname, &indexrelname, &idxBlksRead, &idxBlksHit); err != nil {
+			return err
+		}
+		if !idxBlksRead.Valid || !idxBlksHit.Valid {
+			continue
+		}
+
+		datnameLabel := "unknown"
+		if datname.Valid {
+			datnameLabel = datname.String
+		}
+		schemanameLabel := "unknown"
+		if schemaname.Valid {
+			schemanameLabel = schemaname.String
+		}
+		relnameLabel := "unknown"
+		if relname.Valid {
+			relnameLabel = relname.String
+		}
+		indexrelnameLabel := "unknown"
+		if indexrelname.Valid {
+			indexrelnameLabel = indexrelname.String
+		}
+		labels := []string{datnameLabel, schemanameLabel, relnameLabel, indexrelnameLabel}
+
+		ch <- prometheus.MustNewConstMetric(
+			statioUserIndexesIdxBlksRead,
+			prometheus.CounterValue,
+			float64(idxBlksRead.Int64),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			statioUserIndexesIdxBlksHit,
+			prometheus.CounterValue,
+			float64(idxBlksHit.Int64),
+			labels...,
+		)
+
+		// Indexes which were never accessed have no meaningful ratio.
+		if total := idxBlksRead.Int64 + idxBlksHit.Int64; total > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				statioIndexCacheHitRatio,
+				prometheus.GaugeValue,
+				float64(idxBlksHit.Int64)/float64(total),
+				labels...,
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGStatIOUserIndexesCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "schemaname", "relname", "indexrelname", "idx_blks_read", "idx_blks_hit"}
+	rows := sqlmock.NewRows(columns).
+		// A cold index, mostly read from disk.
+		AddRow("app", "public", "events", "events_created_at_idx", 900, 100).
+		// A hot index, mostly found in the buffer cache.
+		AddRow("app", "public", "orders", "orders_pkey", 10, 990).
+		// An index which was never accessed.
+		AddRow("app", "public", "orders", "orders_note_idx", 0, 0)
+	mock.ExpectQuery(sanitizeQuery(statioUserIndexesQuery)).WithArgs(`{"audit"}`, 10).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatIOUserIndexesCollector{excludeSchemas: []string{"audit"}, limit: 10}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatIOUserIndexesCollector.Update: %s", err)
+		}
+	}()
+
+	cold := labelMap{"datname": "app", "schemaname": "public", "relname": "events", "indexrelname": "events_created_at_idx"}
+	hot := labelMap{"datname": "app", "schemaname": "public", "relname": "orders", "indexrelname": "orders_pkey"}
+	unused := labelMap{"datname": "app", "schemaname": "public", "relname": "orders", "indexrelname": "orders_note_idx"}
+	expected := []MetricResult{
+		{labels: cold, metricType: dto.MetricType_COUNTER, value: 900},
+		{labels: cold, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: cold, metricType: dto.MetricType_GAUGE, value: 0.1},
+		{labels: hot, metricType: dto.MetricType_COUNTER, value: 10},
+		{labels: hot, metricType: dto.MetricType_COUNTER, value: 990},
+		{labels: hot, metricType: dto.MetricType_GAUGE, value: 0.99},
+		{labels: unused, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: unused, metricType: dto.MetricType_COUNTER, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -17,72 +17,108 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const statioUserTableSubsystem = "statio_user_tables"
 
+var (
+	statioUserTablesExcludeSchemas = kingpin.Flag(
+		"collector.statio_user_tables.exclude-schema",
+		"Schema to exclude from the statio_user_tables collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	statioUserTablesLimit = kingpin.Flag(
+		"collector.statio_user_tables.limit",
+		"Maximum number of tables reported by the statio_user_tables collector, 0 for no limit. The tables with the most blocks read from disk are reported first.",
+	).Default("0").Int()
+)
+
 func init() {
-	registerCollector(statioUserTableSubsystem, defaultEnabled, NewPGStatIOUserTablesCollector)
+	registerCollector(statioUserTableSubsystem, defaultEnabled, ScopeDatabase, NewPGStatIOUserTablesCollector)
 }
 
 type PGStatIOUserTablesCollector struct {
-	log log.Logger
+	log            log.Logger
+	omitNull       bool
+	excludeSchemas []string
+	limit          int
 }
 
 func NewPGStatIOUserTablesCollector(config collectorConfig) (Collector, error) {
-	return &PGStatIOUserTablesCollector{log: config.logger}, nil
+	return &PGStatIOUserTablesCollector{
+		log:            config.logger,
+		omitNull:       config.omitNull,
+		excludeSchemas: *statioUserTablesExcludeSchemas,
+		limit:          *statioUserTablesLimit,
+	}, nil
 }
 
 var (
-	statioUserTablesHeapBlksRead = prometheus.NewDesc(
+	statioUserTablesHeapBlksRead = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "heap_blocks_read"),
 		"Number of disk blocks read from this table",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.heap_blks_read",
 	)
-	statioUserTablesHeapBlksHit = prometheus.NewDesc(
+	statioUserTablesHeapBlksHit = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "heap_blocks_hit"),
 		"Number of buffer hits in this table",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.heap_blks_hit",
 	)
-	statioUserTablesIdxBlksRead = prometheus.NewDesc(
+	statioUserTablesIdxBlksRead = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "idx_blocks_read"),
 		"Number of disk blocks read from all indexes on this table",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.idx_blks_read",
 	)
-	statioUserTablesIdxBlksHit = prometheus.NewDesc(
+	statioUserTablesIdxBlksHit = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "idx_blocks_hit"),
 		"Number of buffer hits in all indexes on this table",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.idx_blks_hit",
 	)
-	statioUserTablesToastBlksRead = prometheus.NewDesc(
+	statioUserTablesToastBlksRead = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "toast_blocks_read"),
 		"Number of disk blocks read from this table's TOAST table (if any)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.toast_blks_read",
 	)
-	statioUserTablesToastBlksHit = prometheus.NewDesc(
+	statioUserTablesToastBlksHit = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "toast_blocks_hit"),
 		"Number of buffer hits in this table's TOAST table (if any)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.toast_blks_hit",
 	)
-	statioUserTablesTidxBlksRead = prometheus.NewDesc(
+	statioUserTablesTidxBlksRead = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "tidx_blocks_read"),
 		"Number of disk blocks read from this table's TOAST table indexes (if any)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.tidx_blks_read",
 	)
-	statioUserTablesTidxBlksHit = prometheus.NewDesc(
+	statioUserTablesTidxBlksHit = newDesc(
 		prometheus.BuildFQName(namespace, statioUserTableSubsystem, "tidx_blocks_hit"),
 		"Number of buffer hits in this table's TOAST table indexes (if any)",
 		[]string{"datname", "schemaname", "relname"},
 		prometheus.Labels{},
+		"", "pg_statio_user_tables.tidx_blks_hit",
+	)
+	statioTableCacheHitRatio = newDesc(
+		prometheus.BuildFQName(namespace, "statio_table", "cache_hit_ratio"),
+		"Ratio of the blocks of this table and its TOAST table found in the buffer cache to all blocks of them accessed",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_statio_user_tables.heap_blks_hit, heap_blks_read, toast_blks_hit and toast_blks_read",
 	)
 
 	statioUserTablesQuery = `SELECT
@@ -97,13 +133,20 @@ var (
 		toast_blks_hit,
 		tidx_blks_read,
 		tidx_blks_hit
-	FROM pg_statio_user_tables`
+	FROM pg_statio_user_tables
+	WHERE schemaname <> ALL($1)
+	ORDER BY heap_blks_read + COALESCE(toast_blks_read, 0) DESC
+	LIMIT NULLIF($2, 0)`
 )
 
-func (PGStatIOUserTablesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+func (c PGStatIOUserTablesCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
 	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
 	rows, err := db.QueryContext(ctx,
-		statioUserTablesQuery)
+		statioUserTablesQuery, excludeSchemas, c.limit)
 
 	if err != nil {
 		return err
@@ -130,93 +173,120 @@ func (PGStatIOUserTablesCollector) Update(ctx context.Context, instance *instanc
 			relnameLabel = relname.String
 		}
 
-		heapBlksReadMetric := 0.0
-		if heapBlksRead.Valid {
-			heapBlksReadMetric = float64(heapBlksRead.Int64)
+		if heapBlksRead.Valid || !c.omitNull {
+			heapBlksReadMetric := 0.0
+			if heapBlksRead.Valid {
+				heapBlksReadMetric = float64(heapBlksRead.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesHeapBlksRead,
+				prometheus.CounterValue,
+				heapBlksReadMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
+		}
+
+		if heapBlksHit.Valid || !c.omitNull {
+			heapBlksHitMetric := 0.0
+			if heapBlksHit.Valid {
+				heapBlksHitMetric = float64(heapBlksHit.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesHeapBlksHit,
+				prometheus.CounterValue,
+				heapBlksHitMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesHeapBlksRead,
-			prometheus.CounterValue,
-			heapBlksReadMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		heapBlksHitMetric := 0.0
-		if heapBlksHit.Valid {
-			heapBlksHitMetric = float64(heapBlksHit.Int64)
+
+		if idxBlksRead.Valid || !c.omitNull {
+			idxBlksReadMetric := 0.0
+			if idxBlksRead.Valid {
+				idxBlksReadMetric = float64(idxBlksRead.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesIdxBlksRead,
+				prometheus.CounterValue,
+				idxBlksReadMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesHeapBlksHit,
-			prometheus.CounterValue,
-			heapBlksHitMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		idxBlksReadMetric := 0.0
-		if idxBlksRead.Valid {
-			idxBlksReadMetric = float64(idxBlksRead.Int64)
+
+		if idxBlksHit.Valid || !c.omitNull {
+			idxBlksHitMetric := 0.0
+			if idxBlksHit.Valid {
+				idxBlksHitMetric = float64(idxBlksHit.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesIdxBlksHit,
+				prometheus.CounterValue,
+				idxBlksHitMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesIdxBlksRead,
-			prometheus.CounterValue,
-			idxBlksReadMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		idxBlksHitMetric := 0.0
-		if idxBlksHit.Valid {
-			idxBlksHitMetric = float64(idxBlksHit.Int64)
+
+		if toastBlksRead.Valid || !c.omitNull {
+			toastBlksReadMetric := 0.0
+			if toastBlksRead.Valid {
+				toastBlksReadMetric = float64(toastBlksRead.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesToastBlksRead,
+				prometheus.CounterValue,
+				toastBlksReadMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesIdxBlksHit,
-			prometheus.CounterValue,
-			idxBlksHitMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		toastBlksReadMetric := 0.0
-		if toastBlksRead.Valid {
-			toastBlksReadMetric = float64(toastBlksRead.Int64)
+
+		if toastBlksHit.Valid || !c.omitNull {
+			toastBlksHitMetric := 0.0
+			if toastBlksHit.Valid {
+				toastBlksHitMetric = float64(toastBlksHit.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesToastBlksHit,
+				prometheus.CounterValue,
+				toastBlksHitMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesToastBlksRead,
-			prometheus.CounterValue,
-			toastBlksReadMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		toastBlksHitMetric := 0.0
-		if toastBlksHit.Valid {
-			toastBlksHitMetric = float64(toastBlksHit.Int64)
+
+		if tidxBlksRead.Valid || !c.omitNull {
+			tidxBlksReadMetric := 0.0
+			if tidxBlksRead.Valid {
+				tidxBlksReadMetric = float64(tidxBlksRead.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesTidxBlksRead,
+				prometheus.CounterValue,
+				tidxBlksReadMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesToastBlksHit,
-			prometheus.CounterValue,
-			toastBlksHitMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		tidxBlksReadMetric := 0.0
-		if tidxBlksRead.Valid {
-			tidxBlksReadMetric = float64(tidxBlksRead.Int64)
+
+		if tidxBlksHit.Valid || !c.omitNull {
+			tidxBlksHitMetric := 0.0
+			if tidxBlksHit.Valid {
+				tidxBlksHitMetric = float64(tidxBlksHit.Int64)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				statioUserTablesTidxBlksHit,
+				prometheus.CounterValue,
+				tidxBlksHitMetric,
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesTidxBlksRead,
-			prometheus.CounterValue,
-			tidxBlksReadMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
-
-		tidxBlksHitMetric := 0.0
-		if tidxBlksHit.Valid {
-			tidxBlksHitMetric = float64(tidxBlksHit.Int64)
+
+		// Tables which were never accessed have no meaningful ratio.
+		hit := heapBlksHit.Int64 + toastBlksHit.Int64
+		if total := hit + heapBlksRead.Int64 + toastBlksRead.Int64; total > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				statioTableCacheHitRatio,
+				prometheus.GaugeValue,
+				float64(hit)/float64(total),
+				datnameLabel, schemanameLabel, relnameLabel,
+			)
 		}
-		ch <- prometheus.MustNewConstMetric(
-			statioUserTablesTidxBlksHit,
-			prometheus.CounterValue,
-			tidxBlksHitMetric,
-			datnameLabel, schemanameLabel, relnameLabel,
-		)
 	}
 	if err := rows.Err(); err != nil {
 		return err
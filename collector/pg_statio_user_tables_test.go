@@ -76,6 +76,7 @@ func TestPGStatIOUserTablesCollector(t *testing.T) {
 		{labels: labelMap{"datname": "postgres", "schemaname": "public", "relname": "a_table"}, metricType: dto.MetricType_COUNTER, value: 6},
 		{labels: labelMap{"datname": "postgres", "schemaname": "public", "relname": "a_table"}, metricType: dto.MetricType_COUNTER, value: 7},
 		{labels: labelMap{"datname": "postgres", "schemaname": "public", "relname": "a_table"}, metricType: dto.MetricType_COUNTER, value: 8},
+		{labels: labelMap{"datname": "postgres", "schemaname": "public", "relname": "a_table"}, metricType: dto.MetricType_GAUGE, value: 8.0 / 14},
 	}
 
 	convey.Convey("Metrics comparison", t, func() {
@@ -155,3 +156,74 @@ func TestPGStatIOUserTablesCollectorNullValues(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestPGStatIOUserTablesCollectorToast(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{
+		"datname",
+		"schemaname",
+		"relname",
+		"heap_blks_read",
+		"heap_blks_hit",
+		"idx_blks_read",
+		"idx_blks_hit",
+		"toast_blks_read",
+		"toast_blks_hit",
+		"tidx_blks_read",
+		"tidx_blks_hit",
+	}
+	rows := sqlmock.NewRows(columns).
+		// Large documents are stored out of line in the TOAST table.
+		AddRow("app", "public", "documents", 10, 90, 5, 95, 300, 100, 2, 48).
+		// A table without a TOAST table.
+		AddRow("app", "public", "counters", 0, 50, 0, 50, nil, nil, nil, nil)
+	mock.ExpectQuery(sanitizeQuery(statioUserTablesQuery)).WithArgs(`{"audit"}`, 10).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatIOUserTablesCollector{omitNull: true, excludeSchemas: []string{"audit"}, limit: 10}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatIOUserTablesCollector.Update: %s", err)
+		}
+	}()
+
+	documents := labelMap{"datname": "app", "schemaname": "public", "relname": "documents"}
+	counters := labelMap{"datname": "app", "schemaname": "public", "relname": "counters"}
+	expected := []MetricResult{
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 10},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 90},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 5},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 95},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 300},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 100},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 2},
+		{labels: documents, metricType: dto.MetricType_COUNTER, value: 48},
+		{labels: documents, metricType: dto.MetricType_GAUGE, value: 0.38},
+		{labels: counters, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: counters, metricType: dto.MetricType_COUNTER, value: 50},
+		{labels: counters, metricType: dto.MetricType_COUNTER, value: 0},
+		{labels: counters, metricType: dto.MetricType_COUNTER, value: 50},
+		{labels: counters, metricType: dto.MetricType_GAUGE, value: 1},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
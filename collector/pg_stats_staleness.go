@@ -0,0 +1,164 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"math"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statsStalenessSubsystem = "stats_staleness"
+
+var (
+	statsStalenessExcludeSchemas = kingpin.Flag(
+		"collector.stats_staleness.exclude-schema",
+		"Schema to exclude from the stats_staleness collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	statsStalenessLimit = kingpin.Flag(
+		"collector.stats_staleness.limit",
+		"Maximum number of tables reported by the stats_staleness collector. The tables whose row estimates differ the most are reported first.",
+	).Default("100").Int()
+)
+
+func init() {
+	registerCollector(statsStalenessSubsystem, defaultDisabled, ScopeDatabase, NewPGStatsStalenessCollector)
+}
+
+// PGStatsStalenessCollector compares the row count estimate of the planner,
+// pg_class.reltuples, with the live tuples counted by the statistics
+// collector. The planner estimate is only updated by VACUUM and ANALYZE, so
+// a large difference points to a table needing ANALYZE.
+type PGStatsStalenessCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGStatsStalenessCollector(config collectorConfig) (Collector, error) {
+	return &PGStatsStalenessCollector{
+		log:            config.logger,
+		excludeSchemas: *statsStalenessExcludeSchemas,
+		limit:          *statsStalenessLimit,
+	}, nil
+}
+
+var (
+	pgTableReltuples = newDesc(
+		prometheus.BuildFQName(namespace, "table", "reltuples"),
+		"Number of rows of the table estimated by the planner as of the last VACUUM or ANALYZE",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_class.reltuples",
+	)
+	pgTableLiveTuples = newDesc(
+		prometheus.BuildFQName(namespace, "table", "live_tuples"),
+		"Estimated number of live rows of the table counted by the statistics collector",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.n_live_tup",
+	)
+	pgTableStatsStalenessRatio = newDesc(
+		prometheus.BuildFQName(namespace, "table", "stats_staleness_ratio"),
+		"Difference between the planner estimate and the live rows of the table, as a fraction of the larger of both (0 when they agree)",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_class.reltuples and pg_stat_user_tables.n_live_tup",
+	)
+
+	// reltuples is -1 for tables which were never vacuumed or analyzed on
+	// PostgreSQL 14+, they are sorted last.
+	pgStatsStalenessQuery = `SELECT
+		current_database() datname,
+		s.schemaname,
+		s.relname,
+		c.reltuples,
+		s.n_live_tup
+	FROM pg_stat_user_tables s
+	JOIN pg_class c
+		ON c.oid = s.relid
+	WHERE s.schemaname <> ALL($1)
+	ORDER BY CASE WHEN c.reltuples < 0 THEN 0 ELSE abs(c.reltuples - s.n_live_tup) END DESC
+	LIMIT $2`
+)
+
+func (c PGStatsStalenessCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		pgStatsStalenessQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname sql.NullString
+		var reltuples sql.NullFloat64
+		var liveTuples sql.NullInt64
+		if err := rows.Scan(&datname, &schemaname, &relname, &reltuples, &liveTuples); err != nil {
+			return err
+		}
+		if !datname.Valid || !schemaname.Valid || !relname.Valid {
+			continue
+		}
+		labels := []string{datname.String, schemaname.String, relname.String}
+
+		if liveTuples.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				pgTableLiveTuples,
+				prometheus.GaugeValue, float64(liveTuples.Int64),
+				labels...,
+			)
+		}
+		// The planner has no estimate yet.
+		if !reltuples.Valid || reltuples.Float64 < 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgTableReltuples,
+			prometheus.GaugeValue, reltuples.Float64,
+			labels...,
+		)
+		if liveTuples.Valid {
+			if ratio, ok := statsStalenessRatio(reltuples.Float64, float64(liveTuples.Int64)); ok {
+				ch <- prometheus.MustNewConstMetric(
+					pgTableStatsStalenessRatio,
+					prometheus.GaugeValue, ratio,
+					labels...,
+				)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// statsStalenessRatio returns the difference between the planner estimate
+// and the live tuples as a fraction of the larger of both, so that it is
+// between 0 and 1 whichever way they diverge. Empty tables have no ratio.
+func statsStalenessRatio(reltuples, liveTuples float64) (float64, bool) {
+	larger := math.Max(reltuples, liveTuples)
+	if larger <= 0 {
+		return 0, false
+	}
+	return math.Abs(reltuples-liveTuples) / larger, true
+}
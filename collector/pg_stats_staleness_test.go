@@ -0,0 +1,78 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGStatsStalenessCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "schemaname", "relname", "reltuples", "n_live_tup"}
+	rows := sqlmock.NewRows(columns).
+		// Bulk loaded since the last ANALYZE.
+		AddRow("app", "public", "events", 1000, 4000).
+		// Up to date.
+		AddRow("app", "public", "users", 500, 500).
+		// Never analyzed.
+		AddRow("app", "public", "imports", -1, 20)
+	mock.ExpectQuery(sanitizeQuery(pgStatsStalenessQuery)).WithArgs(`{"audit"}`, 10).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGStatsStalenessCollector{excludeSchemas: []string{"audit"}, limit: 10}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGStatsStalenessCollector.Update: %s", err)
+		}
+	}()
+
+	events := labelMap{"datname": "app", "schemaname": "public", "relname": "events"}
+	users := labelMap{"datname": "app", "schemaname": "public", "relname": "users"}
+	imports := labelMap{"datname": "app", "schemaname": "public", "relname": "imports"}
+	expected := []MetricResult{
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 4000},
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 1000},
+		{labels: events, metricType: dto.MetricType_GAUGE, value: 0.75},
+		{labels: users, metricType: dto.MetricType_GAUGE, value: 500},
+		{labels: users, metricType: dto.MetricType_GAUGE, value: 500},
+		{labels: users, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: imports, metricType: dto.MetricType_GAUGE, value: 20},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
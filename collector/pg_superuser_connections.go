@@ -0,0 +1,102 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const superuserConnectionsSubsystem = "superuser_connections"
+
+func init() {
+	registerCollector(superuserConnectionsSubsystem, defaultDisabled, ScopeGlobal, NewPGSuperuserConnectionsCollector)
+}
+
+// PGSuperuserConnectionsCollector counts the connections of superuser roles,
+// which can exhaust superuser_reserved_connections. The connections of the
+// exporter, when it runs as a superuser, are counted separately.
+type PGSuperuserConnectionsCollector struct {
+	log log.Logger
+	// applicationName is the application_name of the exporter's connections.
+	applicationName string
+}
+
+func NewPGSuperuserConnectionsCollector(config collectorConfig) (Collector, error) {
+	return &PGSuperuserConnectionsCollector{
+		log:             config.logger,
+		applicationName: *dbApplicationName,
+	}, nil
+}
+
+var (
+	pgSuperuserConnections = newDesc(
+		prometheus.BuildFQName(namespace, "", superuserConnectionsSubsystem),
+		"Number of connections of superuser roles, exporter is true for the connections of the exporter",
+		[]string{"exporter"}, nil,
+		"", "pg_stat_activity.usesysid and pg_roles.rolsuper",
+	)
+
+	pgSuperuserConnectionsQuery = `SELECT
+		COALESCE(a.application_name = $1, false) OR a.pid = pg_backend_pid() AS exporter,
+		count(*) AS connections
+	FROM pg_stat_activity a
+	JOIN pg_roles r
+		ON r.oid = a.usesysid
+	WHERE r.rolsuper
+	GROUP BY 1`
+)
+
+func (c PGSuperuserConnectionsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgSuperuserConnectionsQuery, c.applicationName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Both series are reported, so that they drop to 0.
+	var others, exporter float64
+	for rows.Next() {
+		var isExporter bool
+		var connections sql.NullFloat64
+		if err := rows.Scan(&isExporter, &connections); err != nil {
+			return err
+		}
+		if isExporter {
+			exporter = connections.Float64
+		} else {
+			others = connections.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgSuperuserConnections,
+		prometheus.GaugeValue, others,
+		"false",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		pgSuperuserConnections,
+		prometheus.GaugeValue, exporter,
+		"true",
+	)
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const tableAccessMethodSubsystem = "table_access_method"
+
+func init() {
+	registerCollector(tableAccessMethodSubsystem, defaultDisabled, ScopeDatabase, NewPGTableAccessMethodCollector)
+	preferReplica(tableAccessMethodSubsystem)
+}
+
+// PGTableAccessMethodCollector reports the table access method, e.g. heap or
+// a columnar one provided by an extension, of each table of the database the
+// exporter is connected to.
+type PGTableAccessMethodCollector struct {
+	log log.Logger
+}
+
+func NewPGTableAccessMethodCollector(config collectorConfig) (Collector, error) {
+	return &PGTableAccessMethodCollector{log: config.logger}, nil
+}
+
+var (
+	pgTableAccessMethod = newDesc(
+		prometheus.BuildFQName(namespace, "", "table_access_method"),
+		"Table access method of the table",
+		[]string{"datname", "schemaname", "relname", "amname"},
+		prometheus.Labels{},
+		"", "pg_am.amname",
+	)
+
+	pgTableAccessMethodQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname,
+		am.amname
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	JOIN pg_am am
+		ON am.oid = c.relam
+	WHERE c.relkind = 'r'
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'`
+)
+
+func (c PGTableAccessMethodCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// Table access methods were added in PostgreSQL 12.
+	if instance.version.LT(semver.MustParse("12.0.0")) {
+		level.Debug(c.log).Log("msg", "Table access methods are not supported before PostgreSQL 12")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgTableAccessMethodQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname, amname sql.NullString
+		if err := rows.Scan(&datname, &schemaname, &relname, &amname); err != nil {
+			return err
+		}
+		if !datname.Valid || !schemaname.Valid || !relname.Valid || !amname.Valid {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgTableAccessMethod,
+			prometheus.GaugeValue, 1,
+			datname.String, schemaname.String, relname.String, amname.String,
+		)
+	}
+	return rows.Err()
+}
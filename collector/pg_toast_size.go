@@ -0,0 +1,117 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const toastSizeSubsystem = "toast_size"
+
+var (
+	toastSizeExcludeSchemas = kingpin.Flag(
+		"collector.toast_size.exclude-schema",
+		"Schema to exclude from the toast_size collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	toastSizeLimit = kingpin.Flag(
+		"collector.toast_size.limit",
+		"Maximum number of tables reported by the toast_size collector. The tables with the largest TOAST tables are reported first.",
+	).Default("100").Int()
+)
+
+func init() {
+	registerCollector(toastSizeSubsystem, defaultDisabled, ScopeDatabase, NewPGToastSizeCollector)
+	preferReplica(toastSizeSubsystem)
+}
+
+// PGToastSizeCollector reports the size of the TOAST table of each table,
+// where the values of large columns such as jsonb and text are stored out of
+// line.
+type PGToastSizeCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGToastSizeCollector(config collectorConfig) (Collector, error) {
+	return &PGToastSizeCollector{
+		log:            config.logger,
+		excludeSchemas: *toastSizeExcludeSchemas,
+		limit:          *toastSizeLimit,
+	}, nil
+}
+
+var (
+	pgToastSizeBytes = newDesc(
+		prometheus.BuildFQName(namespace, "toast", "size_bytes"),
+		"Size of the TOAST table of the table, including its index, in bytes",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"bytes", "pg_total_relation_size",
+	)
+
+	pgToastSizeQuery = `SELECT
+		current_database() datname,
+		n.nspname AS schemaname,
+		c.relname,
+		pg_total_relation_size(c.reltoastrelid) AS toast_size
+	FROM pg_class c
+	JOIN pg_namespace n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'm')
+		AND c.reltoastrelid <> 0
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname !~ '^pg_(toast|temp_)'
+		AND n.nspname <> ALL($1)
+	ORDER BY toast_size DESC NULLS LAST
+	LIMIT $2`
+)
+
+func (c PGToastSizeCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		pgToastSizeQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname sql.NullString
+		var size sql.NullInt64
+		if err := rows.Scan(&datname, &schemaname, &relname, &size); err != nil {
+			return err
+		}
+		// The size is NULL when the table was dropped concurrently.
+		if !datname.Valid || !schemaname.Valid || !relname.Valid || !size.Valid {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgToastSizeBytes,
+			prometheus.GaugeValue, float64(size.Int64),
+			datname.String, schemaname.String, relname.String,
+		)
+	}
+	return rows.Err()
+}
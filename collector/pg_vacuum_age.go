@@ -0,0 +1,149 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const vacuumAgeSubsystem = "vacuum_age"
+
+var (
+	vacuumAgeExcludeSchemas = kingpin.Flag(
+		"collector.vacuum_age.exclude-schema",
+		"Schema to exclude from the vacuum_age collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	vacuumAgeLimit = kingpin.Flag(
+		"collector.vacuum_age.limit",
+		"Maximum number of tables reported by the vacuum_age collector. The tables vacuumed the longest ago are reported first.",
+	).Default("100").Int()
+)
+
+func init() {
+	registerCollector(vacuumAgeSubsystem, defaultDisabled, ScopeDatabase, NewPGVacuumAgeCollector)
+}
+
+// PGVacuumAgeCollector reports the time since the tables of the database
+// were last vacuumed and analyzed, manually or by autovacuum, so that alerts
+// can fire on tables which haven't been vacuumed in too long.
+type PGVacuumAgeCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGVacuumAgeCollector(config collectorConfig) (Collector, error) {
+	return &PGVacuumAgeCollector{
+		log:            config.logger,
+		excludeSchemas: *vacuumAgeExcludeSchemas,
+		limit:          *vacuumAgeLimit,
+	}, nil
+}
+
+var (
+	pgTableSecondsSinceLastAutovacuum = newDesc(
+		prometheus.BuildFQName(namespace, "table", "seconds_since_last_autovacuum"),
+		"Time since the table was last vacuumed, manually or by autovacuum, in seconds",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_user_tables.last_vacuum and last_autovacuum",
+	)
+	pgTableSecondsSinceLastAutoanalyze = newDesc(
+		prometheus.BuildFQName(namespace, "table", "seconds_since_last_autoanalyze"),
+		"Time since the table was last analyzed, manually or by autovacuum, in seconds",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"seconds", "pg_stat_user_tables.last_analyze and last_autoanalyze",
+	)
+	pgTableNeverVacuumed = newDesc(
+		prometheus.BuildFQName(namespace, "table", "never_vacuumed"),
+		"Whether the table was never vacuumed since the statistics were reset (1 for never vacuumed)",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.last_vacuum and last_autovacuum",
+	)
+	pgTableNeverAnalyzed = newDesc(
+		prometheus.BuildFQName(namespace, "table", "never_analyzed"),
+		"Whether the table was never analyzed since the statistics were reset (1 for never analyzed)",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.last_analyze and last_autoanalyze",
+	)
+
+	// Tables which were never vacuumed are the oldest.
+	pgVacuumAgeQuery = `SELECT
+		current_database() datname,
+		schemaname,
+		relname,
+		EXTRACT(EPOCH FROM now() - GREATEST(last_vacuum, last_autovacuum)) AS since_vacuum,
+		EXTRACT(EPOCH FROM now() - GREATEST(last_analyze, last_autoanalyze)) AS since_analyze
+	FROM pg_stat_user_tables
+	WHERE schemaname <> ALL($1)
+	ORDER BY GREATEST(last_vacuum, last_autovacuum) ASC NULLS FIRST
+	LIMIT $2`
+)
+
+func (c PGVacuumAgeCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		pgVacuumAgeQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname sql.NullString
+		var sinceVacuum, sinceAnalyze sql.NullFloat64
+		if err := rows.Scan(&datname, &schemaname, &relname, &sinceVacuum, &sinceAnalyze); err != nil {
+			return err
+		}
+		if !datname.Valid || !schemaname.Valid || !relname.Valid {
+			continue
+		}
+		labels := []string{datname.String, schemaname.String, relname.String}
+
+		// Tables which were never vacuumed or analyzed have no age, they
+		// are flagged instead.
+		emitAge := func(age sql.NullFloat64, ageDesc, neverDesc *prometheus.Desc) {
+			never := 1.0
+			if age.Valid {
+				never = 0
+				ch <- prometheus.MustNewConstMetric(
+					ageDesc,
+					prometheus.GaugeValue, age.Float64,
+					labels...,
+				)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				neverDesc,
+				prometheus.GaugeValue, never,
+				labels...,
+			)
+		}
+		emitAge(sinceVacuum, pgTableSecondsSinceLastAutovacuum, pgTableNeverVacuumed)
+		emitAge(sinceAnalyze, pgTableSecondsSinceLastAutoanalyze, pgTableNeverAnalyzed)
+	}
+	return rows.Err()
+}
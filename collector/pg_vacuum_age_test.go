@@ -0,0 +1,75 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGVacuumAgeCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "schemaname", "relname", "since_vacuum", "since_analyze"}
+	rows := sqlmock.NewRows(columns).
+		// Never vacuumed, analyzed an hour ago.
+		AddRow("app", "public", "imports", nil, 3600).
+		// Vacuumed and analyzed a minute ago.
+		AddRow("app", "public", "orders", 60, 60.5)
+	mock.ExpectQuery(sanitizeQuery(pgVacuumAgeQuery)).WithArgs(`{"audit"}`, 10).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGVacuumAgeCollector{excludeSchemas: []string{"audit"}, limit: 10}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGVacuumAgeCollector.Update: %s", err)
+		}
+	}()
+
+	imports := labelMap{"datname": "app", "schemaname": "public", "relname": "imports"}
+	orders := labelMap{"datname": "app", "schemaname": "public", "relname": "orders"}
+	expected := []MetricResult{
+		{labels: imports, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: imports, metricType: dto.MetricType_GAUGE, value: 3600},
+		{labels: imports, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 60},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 0},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 60.5},
+		{labels: orders, metricType: dto.MetricType_GAUGE, value: 0},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
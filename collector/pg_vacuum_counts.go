@@ -0,0 +1,148 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const vacuumCountsSubsystem = "vacuum_counts"
+
+var (
+	vacuumCountsExcludeSchemas = kingpin.Flag(
+		"collector.vacuum_counts.exclude-schema",
+		"Schema to exclude from the vacuum_counts collector. Repeat the flag to exclude multiple schemas.",
+	).Strings()
+	vacuumCountsLimit = kingpin.Flag(
+		"collector.vacuum_counts.limit",
+		"Maximum number of tables reported by the vacuum_counts collector. The tables with the most dead tuples are reported first.",
+	).Default("100").Int()
+)
+
+func init() {
+	registerCollector(vacuumCountsSubsystem, defaultDisabled, ScopeDatabase, NewPGVacuumCountsCollector)
+}
+
+// PGVacuumCountsCollector reports how often the tables of the database were
+// vacuumed and analyzed, manually and by autovacuum. Unlike the
+// stat_user_tables collector, the tables are bounded and filtered by schema,
+// so that the rates of the counters can be graphed for large schemas.
+type PGVacuumCountsCollector struct {
+	log            log.Logger
+	excludeSchemas []string
+	limit          int
+}
+
+func NewPGVacuumCountsCollector(config collectorConfig) (Collector, error) {
+	return &PGVacuumCountsCollector{
+		log:            config.logger,
+		excludeSchemas: *vacuumCountsExcludeSchemas,
+		limit:          *vacuumCountsLimit,
+	}, nil
+}
+
+var (
+	pgVacuumCountTotal = newDesc(
+		prometheus.BuildFQName(namespace, "", "vacuum_count_total"),
+		"Number of times the table was manually vacuumed, not counting VACUUM FULL",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.vacuum_count",
+	)
+	pgAutovacuumCountTotal = newDesc(
+		prometheus.BuildFQName(namespace, "", "autovacuum_count_total"),
+		"Number of times the table was vacuumed by the autovacuum daemon",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.autovacuum_count",
+	)
+	pgAnalyzeCountTotal = newDesc(
+		prometheus.BuildFQName(namespace, "", "analyze_count_total"),
+		"Number of times the table was manually analyzed",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.analyze_count",
+	)
+	pgAutoanalyzeCountTotal = newDesc(
+		prometheus.BuildFQName(namespace, "", "autoanalyze_count_total"),
+		"Number of times the table was analyzed by the autovacuum daemon",
+		[]string{"datname", "schemaname", "relname"},
+		prometheus.Labels{},
+		"", "pg_stat_user_tables.autoanalyze_count",
+	)
+
+	pgVacuumCountsQuery = `SELECT
+		current_database() datname,
+		schemaname,
+		relname,
+		vacuum_count,
+		autovacuum_count,
+		analyze_count,
+		autoanalyze_count
+	FROM pg_stat_user_tables
+	WHERE schemaname <> ALL($1)
+	ORDER BY n_dead_tup DESC
+	LIMIT $2`
+)
+
+func (c PGVacuumCountsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+
+	// A NULL array would exclude all schemas.
+	excludeSchemas := pq.StringArray{}
+	excludeSchemas = append(excludeSchemas, c.excludeSchemas...)
+	rows, err := db.QueryContext(ctx,
+		pgVacuumCountsQuery, excludeSchemas, c.limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var datname, schemaname, relname sql.NullString
+		var vacuumCount, autovacuumCount, analyzeCount, autoanalyzeCount sql.NullInt64
+		if err := rows.Scan(&datname, &schemaname, &relname, &vacuumCount, &autovacuumCount, &analyzeCount, &autoanalyzeCount); err != nil {
+			return err
+		}
+		if !datname.Valid || !schemaname.Valid || !relname.Valid {
+			continue
+		}
+
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			value sql.NullInt64
+		}{
+			{pgVacuumCountTotal, vacuumCount},
+			{pgAutovacuumCountTotal, autovacuumCount},
+			{pgAnalyzeCountTotal, analyzeCount},
+			{pgAutoanalyzeCountTotal, autoanalyzeCount},
+		} {
+			if !m.value.Valid {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				m.desc,
+				prometheus.CounterValue, float64(m.value.Int64),
+				datname.String, schemaname.String, relname.String,
+			)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const waitEventSubsystem = "wait_event"
+
+var waitEventThreshold = kingpin.Flag(
+	"collector.wait_event.threshold",
+	"Minimum number of backends waiting on a wait event for the wait_event collector to report it.",
+).Default("1").Int()
+
+func init() {
+	registerCollector(waitEventSubsystem, defaultDisabled, ScopeGlobal, NewPGWaitEventCollector)
+}
+
+// PGWaitEventCollector counts the active backends waiting on each wait event,
+// to find the specific locks and LWLocks which are contended.
+type PGWaitEventCollector struct {
+	log       log.Logger
+	threshold int
+}
+
+func NewPGWaitEventCollector(config collectorConfig) (Collector, error) {
+	return &PGWaitEventCollector{
+		log:       config.logger,
+		threshold: *waitEventThreshold,
+	}, nil
+}
+
+var (
+	pgWaitEventBackends = newDesc(
+		prometheus.BuildFQName(namespace, waitEventSubsystem, "backends"),
+		"Number of active backends waiting on the wait event",
+		[]string{"wait_event_type", "wait_event"}, nil,
+		"", "pg_stat_activity.wait_event",
+	)
+	pgWaitEventTop = newDesc(
+		prometheus.BuildFQName(namespace, waitEventSubsystem, "top"),
+		"Wait event with the most active backends waiting on it",
+		[]string{"wait_event_type", "wait_event"}, nil,
+		"", "pg_stat_activity.wait_event",
+	)
+
+	// Idle connections wait on ClientRead and background processes on their
+	// main loop, only active backends are waiting on contention. The most
+	// contended event comes first.
+	pgWaitEventQuery = `SELECT
+		wait_event_type,
+		wait_event,
+		count(*) AS backends
+	FROM pg_stat_activity
+	WHERE state = 'active'
+		AND wait_event IS NOT NULL
+		AND pid <> pg_backend_pid()
+	GROUP BY wait_event_type, wait_event
+	HAVING count(*) >= $1
+	ORDER BY backends DESC, wait_event_type, wait_event`
+)
+
+func (c PGWaitEventCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// wait_event was added in PostgreSQL 9.6.
+	if instance.version.LT(semver.MustParse("9.6.0")) {
+		level.Debug(c.log).Log("msg", "wait_event collector is not supported before PostgreSQL 9.6")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgWaitEventQuery, c.threshold)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	top := true
+	for rows.Next() {
+		var waitEventType, waitEvent sql.NullString
+		var backends sql.NullFloat64
+		if err := rows.Scan(&waitEventType, &waitEvent, &backends); err != nil {
+			return err
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			pgWaitEventBackends,
+			prometheus.GaugeValue, backends.Float64,
+			waitEventType.String, waitEvent.String,
+		)
+		if top {
+			ch <- prometheus.MustNewConstMetric(
+				pgWaitEventTop,
+				prometheus.GaugeValue, 1,
+				waitEventType.String, waitEvent.String,
+			)
+			top = false
+		}
+	}
+	return rows.Err()
+}
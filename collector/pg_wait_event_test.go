@@ -0,0 +1,70 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGWaitEventCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	// Five backends wait on the same LWLock, which is the hotspot.
+	rows := sqlmock.NewRows([]string{"wait_event_type", "wait_event", "backends"}).
+		AddRow("LWLock", "WALWrite", 5).
+		AddRow("LWLock", "BufferContent", 3).
+		AddRow("Lock", "transactionid", 2)
+	mock.ExpectQuery(sanitizeQuery(pgWaitEventQuery)).WithArgs(2).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGWaitEventCollector{threshold: 2}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGWaitEventCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"wait_event_type": "LWLock", "wait_event": "WALWrite"}, metricType: dto.MetricType_GAUGE, value: 5},
+		{labels: labelMap{"wait_event_type": "LWLock", "wait_event": "WALWrite"}, metricType: dto.MetricType_GAUGE, value: 1},
+		{labels: labelMap{"wait_event_type": "LWLock", "wait_event": "BufferContent"}, metricType: dto.MetricType_GAUGE, value: 3},
+		{labels: labelMap{"wait_event_type": "Lock", "wait_event": "transactionid"}, metricType: dto.MetricType_GAUGE, value: 2},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -22,7 +22,7 @@ import (
 const walSubsystem = "wal"
 
 func init() {
-	registerCollector(walSubsystem, defaultEnabled, NewPGWALCollector)
+	registerCollector(walSubsystem, defaultEnabled, ScopeGlobal, NewPGWALCollector)
 }
 
 type PGWALCollector struct {
@@ -33,7 +33,7 @@ func NewPGWALCollector(config collectorConfig) (Collector, error) {
 }
 
 var (
-	pgWALSegments = prometheus.NewDesc(
+	pgWALSegments = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			walSubsystem,
@@ -41,8 +41,9 @@ var (
 		),
 		"Number of WAL segments",
 		[]string{}, nil,
+		"", "pg_ls_waldir",
 	)
-	pgWALSize = prometheus.NewDesc(
+	pgWALSize = newDesc(
 		prometheus.BuildFQName(
 			namespace,
 			walSubsystem,
@@ -50,6 +51,7 @@ var (
 		),
 		"Total size of WAL segments",
 		[]string{}, nil,
+		"bytes", "pg_ls_waldir.size",
 	)
 
 	pgWALQuery = `
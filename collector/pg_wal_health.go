@@ -0,0 +1,198 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const walHealthSubsystem = "wal_health"
+
+func init() {
+	registerCollector(walHealthSubsystem, defaultDisabled, ScopeGlobal, NewPGWALHealthCollector)
+}
+
+// PGWALHealthCollector reports on the health of the WAL pipeline: how fast
+// WAL is generated, how many WAL files exist and how many are still waiting
+// to be archived.
+type PGWALHealthCollector struct {
+	log      log.Logger
+	failFast bool
+}
+
+// walSample is the WAL position of an instance at a point in time. The WAL
+// generation rate is derived from the sample of the previous scrape.
+type walSample struct {
+	mtx  sync.Mutex
+	lsn  float64
+	time time.Time
+}
+
+func NewPGWALHealthCollector(config collectorConfig) (Collector, error) {
+	return &PGWALHealthCollector{log: config.logger, failFast: config.failFast}, nil
+}
+
+var (
+	pgWALBytesPerSecond = newDesc(
+		prometheus.BuildFQName(namespace, walSubsystem, "bytes_per_second"),
+		"Rate of WAL generation in bytes per second since the previous scrape",
+		[]string{}, nil,
+		"bytes", "pg_current_wal_lsn",
+	)
+	pgWALFilesCount = newDesc(
+		prometheus.BuildFQName(namespace, walSubsystem, "files_count"),
+		"Number of WAL files in pg_wal",
+		[]string{}, nil,
+		"", "pg_ls_waldir",
+	)
+	pgWALArchivePending = newDesc(
+		prometheus.BuildFQName(namespace, walSubsystem, "archive_pending"),
+		"Number of WAL files marked ready but not yet archived",
+		[]string{}, nil,
+		"", "pg_ls_archive_statusdir",
+	)
+
+	pgWALHealthLSNQuery = `SELECT
+		CASE WHEN pg_is_in_recovery() THEN
+			pg_last_wal_receive_lsn() - '0/0'
+		ELSE
+			pg_current_wal_lsn() - '0/0'
+		END AS current_wal_lsn`
+
+	pgWALHealthFilesQuery = `SELECT
+		COUNT(*) AS files
+	FROM pg_ls_waldir()
+	WHERE name ~ '^[0-9A-F]{24}$'`
+
+	// pg_ls_archive_statusdir was added in PostgreSQL 12 and is granted to
+	// pg_monitor, unlike pg_ls_dir which requires superuser.
+	pgWALHealthArchiveStatusQuery = `SELECT name FROM pg_ls_archive_statusdir()`
+)
+
+func (c PGWALHealthCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	errs := updateErrors{failFast: c.failFast}
+
+	var lsn sql.NullFloat64
+	err := db.QueryRowContext(ctx, pgWALHealthLSNQuery).Scan(&lsn)
+	if errs.add(err) {
+		return errs.err()
+	}
+	if err == nil && lsn.Valid {
+		if rate, ok := instance.getSamples().walSample.rate(lsn.Float64, time.Now()); ok {
+			ch <- prometheus.MustNewConstMetric(
+				pgWALBytesPerSecond,
+				prometheus.GaugeValue, rate,
+			)
+		}
+	}
+
+	var files int64
+	err = db.QueryRowContext(ctx, pgWALHealthFilesQuery).Scan(&files)
+	switch {
+	case isPermissionDenied(err):
+		level.Debug(c.log).Log("msg", "Permission denied listing WAL files, skipping", "err", err)
+	case err != nil:
+		if errs.add(err) {
+			return errs.err()
+		}
+	default:
+		ch <- prometheus.MustNewConstMetric(
+			pgWALFilesCount,
+			prometheus.GaugeValue, float64(files),
+		)
+	}
+
+	if instance.version.LT(semver.MustParse("12.0.0")) {
+		level.Debug(c.log).Log("msg", "pg_ls_archive_statusdir is not available before PostgreSQL 12, skipping archive status")
+		return errs.err()
+	}
+
+	pending, err := queryArchivePending(ctx, db)
+	switch {
+	case isPermissionDenied(err):
+		level.Debug(c.log).Log("msg", "Permission denied listing archive status, skipping", "err", err)
+	case err != nil:
+		errs.add(err)
+	default:
+		ch <- prometheus.MustNewConstMetric(
+			pgWALArchivePending,
+			prometheus.GaugeValue, float64(pending),
+		)
+	}
+	return errs.err()
+}
+
+// rate records the current LSN and returns the WAL generation rate since the
+// previous sample. The rate is unavailable for the first sample, or when the
+// LSN went backwards (e.g. after a failover).
+func (s *walSample) rate(lsn float64, now time.Time) (float64, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	lastLSN, lastTime := s.lsn, s.time
+	s.lsn, s.time = lsn, now
+
+	if lastTime.IsZero() || lsn < lastLSN {
+		return 0, false
+	}
+	elapsed := now.Sub(lastTime).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return (lsn - lastLSN) / elapsed, true
+}
+
+func queryArchivePending(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, pgWALHealthArchiveStatusQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return 0, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return countArchivePending(names), nil
+}
+
+// countArchivePending counts the archive_status files which are still waiting
+// to be archived. The archiver renames <segment>.ready to <segment>.done once
+// a segment has been archived successfully.
+func countArchivePending(names []string) int {
+	pending := 0
+	for _, name := range names {
+		if strings.HasSuffix(name, ".ready") {
+			pending++
+		}
+	}
+	return pending
+}
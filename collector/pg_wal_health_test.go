@@ -0,0 +1,223 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGWALHealthCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthLSNQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"current_wal_lsn"}).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthFilesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"files"}).AddRow(47))
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthArchiveStatusQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).
+			AddRow("000000010000000000000001.done").
+			AddRow("000000010000000000000002.ready").
+			AddRow("000000010000000000000003.ready").
+			AddRow("00000002.history.ready"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGWALHealthCollector{log: log.NewNopLogger()}
+		// Pretend the previous scrape happened 10 seconds ago.
+		inst.getSamples().walSample.rate(0, time.Now().Add(-10*time.Second))
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGWALHealthCollector.Update: %s", err)
+		}
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		m := readMetric(<-ch)
+		convey.So(m.value, convey.ShouldAlmostEqual, 16777216/10.0, 100000)
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 47, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 3, metricType: dto.MetricType_GAUGE},
+		}
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGWALHealthCollectorPermissionDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("16.0.0")}
+
+	permissionDenied := &pq.Error{Code: "42501", Message: "permission denied for function pg_ls_archive_statusdir"}
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthLSNQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"current_wal_lsn"}).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthFilesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"files"}).AddRow(47))
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthArchiveStatusQuery)).WillReturnError(permissionDenied)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGWALHealthCollector{log: log.NewNopLogger()}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGWALHealthCollector.Update: %s", err)
+		}
+	}()
+
+	// No rate on the first scrape and no archive_pending without permission.
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 47, metricType: dto.MetricType_GAUGE},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestPGWALHealthCollectorBefore12(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("11.0.0")}
+
+	// The archive status isn't queried without pg_ls_archive_statusdir.
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthLSNQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"current_wal_lsn"}).AddRow(16777216))
+	mock.ExpectQuery(sanitizeQuery(pgWALHealthFilesQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"files"}).AddRow(47))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGWALHealthCollector{log: log.NewNopLogger()}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGWALHealthCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 47, metricType: dto.MetricType_GAUGE},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestCountArchivePending(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  int
+	}{
+		{names: nil, want: 0},
+		{names: []string{"000000010000000000000001.done", "000000010000000000000002.done"}, want: 0},
+		{names: []string{"000000010000000000000001.done", "000000010000000000000002.ready"}, want: 1},
+		{names: []string{"000000010000000000000001.ready", "00000002.history.ready", "000000010000000000000001.partial.ready"}, want: 3},
+	}
+	for _, tt := range tests {
+		if got := countArchivePending(tt.names); got != tt.want {
+			t.Errorf("countArchivePending(%v) = %d, want %d", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestPGWALHealthCollectorProbe(t *testing.T) {
+	dsn := "host=wal-health-probe"
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGWALHealthCollector{log: log.NewNopLogger()}
+		// Every probe creates a new instance of the target.
+		for _, lsn := range []float64{16777216, 33554432} {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Errorf("Error opening a stub db connection: %s", err)
+				return
+			}
+			inst := &instance{dsn: dsn, db: db, version: semver.MustParse("16.0.0"), samples: samplesForDSN(dsn)}
+
+			mock.ExpectQuery(sanitizeQuery(pgWALHealthLSNQuery)).WillReturnRows(
+				sqlmock.NewRows([]string{"current_wal_lsn"}).AddRow(lsn))
+			mock.ExpectQuery(sanitizeQuery(pgWALHealthFilesQuery)).WillReturnRows(
+				sqlmock.NewRows([]string{"files"}).AddRow(47))
+			mock.ExpectQuery(sanitizeQuery(pgWALHealthArchiveStatusQuery)).WillReturnRows(
+				sqlmock.NewRows([]string{"name"}))
+
+			if err := c.Update(context.Background(), inst, ch); err != nil {
+				t.Errorf("Error calling PGWALHealthCollector.Update: %s", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+			db.Close()
+		}
+	}()
+
+	var rates int
+	for m := range ch {
+		if m.Desc() == pgWALBytesPerSecond {
+			rates++
+		}
+	}
+	convey.Convey("The rate is derived from the previous probe", t, func() {
+		convey.So(rates, convey.ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const walSettingsSubsystem = "wal_settings"
+
+func init() {
+	registerCollector(walSettingsSubsystem, defaultDisabled, ScopeGlobal, NewPGWALSettingsCollector)
+}
+
+// PGWALSettingsCollector reports the settings which decide whether the
+// server can be replicated and archived, so that a configuration drift is
+// caught before a standby or a backup is set up.
+type PGWALSettingsCollector struct {
+	log log.Logger
+}
+
+func NewPGWALSettingsCollector(config collectorConfig) (Collector, error) {
+	return &PGWALSettingsCollector{log: config.logger}, nil
+}
+
+var (
+	pgWALLevel = newDesc(
+		prometheus.BuildFQName(namespace, "wal", "level"),
+		"The setting wal_level, always 1",
+		[]string{"level"}, nil,
+		"", "pg_settings.wal_level",
+	)
+	pgArchiveMode = newDesc(
+		prometheus.BuildFQName(namespace, "archive", "mode"),
+		"The setting archive_mode, always 1",
+		[]string{"mode"}, nil,
+		"", "pg_settings.archive_mode",
+	)
+	pgWALLevelSufficientForReplication = newDesc(
+		prometheus.BuildFQName(namespace, "wal", "level_sufficient_for_replication"),
+		"Whether wal_level allows physical replication and WAL archiving (1 for replica or logical, 0 for minimal)",
+		[]string{}, nil,
+		"", "pg_settings.wal_level",
+	)
+
+	pgWALSettingsQuery = `SELECT
+		(SELECT setting FROM pg_settings WHERE name = 'wal_level') AS wal_level,
+		(SELECT setting FROM pg_settings WHERE name = 'archive_mode') AS archive_mode`
+)
+
+func (c PGWALSettingsCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	db := instance.getDB()
+	var walLevel, archiveMode sql.NullString
+	if err := db.QueryRowContext(ctx, pgWALSettingsQuery).Scan(&walLevel, &archiveMode); err != nil {
+		return err
+	}
+
+	if walLevel.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgWALLevel,
+			prometheus.GaugeValue, 1,
+			walLevel.String,
+		)
+		sufficient := 1.0
+		if walLevel.String == "minimal" {
+			sufficient = 0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			pgWALLevelSufficientForReplication,
+			prometheus.GaugeValue, sufficient,
+		)
+	}
+	if archiveMode.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgArchiveMode,
+			prometheus.GaugeValue, 1,
+			archiveMode.String,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGWALSettingsCollector(t *testing.T) {
+	cases := []struct {
+		walLevel, archiveMode string
+		sufficient            float64
+	}{
+		{walLevel: "minimal", archiveMode: "off", sufficient: 0},
+		{walLevel: "logical", archiveMode: "on", sufficient: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.walLevel, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error opening a stub db connection: %s", err)
+			}
+			defer db.Close()
+
+			inst := &instance{db: db}
+
+			rows := sqlmock.NewRows([]string{"wal_level", "archive_mode"}).
+				AddRow(tc.walLevel, tc.archiveMode)
+			mock.ExpectQuery(sanitizeQuery(pgWALSettingsQuery)).WillReturnRows(rows)
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				defer close(ch)
+				c := PGWALSettingsCollector{}
+
+				if err := c.Update(context.Background(), inst, ch); err != nil {
+					t.Errorf("Error calling PGWALSettingsCollector.Update: %s", err)
+				}
+			}()
+
+			expected := []MetricResult{
+				{labels: labelMap{"level": tc.walLevel}, metricType: dto.MetricType_GAUGE, value: 1},
+				{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: tc.sufficient},
+				{labels: labelMap{"mode": tc.archiveMode}, metricType: dto.MetricType_GAUGE, value: 1},
+			}
+
+			convey.Convey("Metrics comparison", t, func() {
+				for _, expect := range expected {
+					m := readMetric(<-ch)
+					convey.So(expect, convey.ShouldResemble, m)
+				}
+				_, ok := <-ch
+				convey.So(ok, convey.ShouldBeFalse)
+			})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const workersSubsystem = "workers"
+
+func init() {
+	registerCollector(workersSubsystem, defaultDisabled, ScopeGlobal, NewPGWorkersCollector)
+}
+
+type PGWorkersCollector struct {
+	log log.Logger
+}
+
+func NewPGWorkersCollector(config collectorConfig) (Collector, error) {
+	return &PGWorkersCollector{log: config.logger}, nil
+}
+
+var (
+	pgParallelWorkersActive = newDesc(
+		prometheus.BuildFQName(namespace, "parallel_workers", "active"),
+		"Number of running parallel workers",
+		[]string{}, nil,
+		"", "pg_stat_activity.backend_type",
+	)
+	pgParallelWorkersMax = newDesc(
+		prometheus.BuildFQName(namespace, "parallel_workers", "max"),
+		"Maximum number of parallel workers (max_parallel_workers)",
+		[]string{}, nil,
+		"", "pg_settings.max_parallel_workers",
+	)
+	pgParallelWorkersSaturation = newDesc(
+		prometheus.BuildFQName(namespace, "parallel_workers", "saturation_ratio"),
+		"Ratio of running parallel workers to max_parallel_workers",
+		[]string{}, nil,
+		"", "pg_stat_activity.backend_type and pg_settings.max_parallel_workers",
+	)
+	pgBackgroundWorkersActive = newDesc(
+		prometheus.BuildFQName(namespace, "background_workers", "active"),
+		"Number of running background worker processes, including parallel workers",
+		[]string{}, nil,
+		"", "pg_stat_activity.backend_type",
+	)
+	pgBackgroundWorkersMax = newDesc(
+		prometheus.BuildFQName(namespace, "background_workers", "max"),
+		"Maximum number of background worker processes (max_worker_processes)",
+		[]string{}, nil,
+		"", "pg_settings.max_worker_processes",
+	)
+	pgBackgroundWorkersSaturation = newDesc(
+		prometheus.BuildFQName(namespace, "background_workers", "saturation_ratio"),
+		"Ratio of running background worker processes to max_worker_processes",
+		[]string{}, nil,
+		"", "pg_stat_activity.backend_type and pg_settings.max_worker_processes",
+	)
+
+	pgWorkersBackendTypeQuery = `SELECT
+		backend_type,
+		count(*) AS count
+	FROM pg_stat_activity
+	GROUP BY backend_type`
+
+	pgWorkersSettingsQuery = `SELECT
+		current_setting('max_parallel_workers')::int AS max_parallel_workers,
+		current_setting('max_worker_processes')::int AS max_worker_processes`
+)
+
+// nonBackgroundWorkerTypes are the backend types which are not started as
+// background workers and therefore don't count against max_worker_processes.
+// Background workers registered by extensions report their own backend type.
+var nonBackgroundWorkerTypes = map[string]bool{
+	"archiver":            true,
+	"autovacuum launcher": true,
+	"autovacuum worker":   true,
+	"background writer":   true,
+	"checkpointer":        true,
+	"client backend":      true,
+	"io worker":           true,
+	"logger":              true,
+	"slotsync worker":     true,
+	"standalone backend":  true,
+	"startup":             true,
+	"walreceiver":         true,
+	"walsender":           true,
+	"walsummarizer":       true,
+	"walwriter":           true,
+}
+
+func (c PGWorkersCollector) Update(ctx context.Context, instance *instance, ch chan<- prometheus.Metric) error {
+	// backend_type and max_parallel_workers were added in PostgreSQL 10.
+	if instance.version.LT(semver.MustParse("10.0.0")) {
+		level.Debug(c.log).Log("msg", "workers collector is not supported before PostgreSQL 10")
+		return ErrNoData
+	}
+
+	db := instance.getDB()
+	rows, err := db.QueryContext(ctx,
+		pgWorkersBackendTypeQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	backends := make(map[string]float64)
+	for rows.Next() {
+		var backendType sql.NullString
+		var count sql.NullInt64
+		if err := rows.Scan(&backendType, &count); err != nil {
+			return err
+		}
+		if !backendType.Valid || !count.Valid {
+			continue
+		}
+		backends[backendType.String] += float64(count.Int64)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	parallelWorkers, backgroundWorkers := countWorkers(backends)
+
+	var maxParallelWorkers, maxWorkerProcesses sql.NullInt64
+	if err := db.QueryRowContext(ctx, pgWorkersSettingsQuery).Scan(&maxParallelWorkers, &maxWorkerProcesses); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgParallelWorkersActive,
+		prometheus.GaugeValue, parallelWorkers,
+	)
+	if maxParallelWorkers.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgParallelWorkersMax,
+			prometheus.GaugeValue, float64(maxParallelWorkers.Int64),
+		)
+		if maxParallelWorkers.Int64 > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				pgParallelWorkersSaturation,
+				prometheus.GaugeValue, parallelWorkers/float64(maxParallelWorkers.Int64),
+			)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		pgBackgroundWorkersActive,
+		prometheus.GaugeValue, backgroundWorkers,
+	)
+	if maxWorkerProcesses.Valid {
+		ch <- prometheus.MustNewConstMetric(
+			pgBackgroundWorkersMax,
+			prometheus.GaugeValue, float64(maxWorkerProcesses.Int64),
+		)
+		if maxWorkerProcesses.Int64 > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				pgBackgroundWorkersSaturation,
+				prometheus.GaugeValue, backgroundWorkers/float64(maxWorkerProcesses.Int64),
+			)
+		}
+	}
+	return nil
+}
+
+// countWorkers returns the number of parallel workers and background workers
+// from the number of backends per backend type.
+func countWorkers(backends map[string]float64) (parallel float64, background float64) {
+	for backendType, count := range backends {
+		if backendType == "parallel worker" {
+			parallel += count
+		}
+		if !nonBackgroundWorkerTypes[backendType] {
+			background += count
+		}
+	}
+	return parallel, background
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPGWorkersCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db, version: semver.MustParse("15.0.0")}
+
+	columns := []string{"backend_type", "count"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("client backend", 42).
+		AddRow("parallel worker", 6).
+		AddRow("logical replication launcher", 1).
+		AddRow("pg_cron scheduler", 1).
+		AddRow("autovacuum worker", 3).
+		AddRow("checkpointer", 1).
+		AddRow(nil, 1)
+	mock.ExpectQuery(sanitizeQuery(pgWorkersBackendTypeQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(pgWorkersSettingsQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"max_parallel_workers", "max_worker_processes"}).AddRow(8, 16))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		c := PGWorkersCollector{}
+
+		if err := c.Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling PGWorkersCollector.Update: %s", err)
+		}
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 6},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 8},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 0.75},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 8},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 16},
+		{labels: labelMap{}, metricType: dto.MetricType_GAUGE, value: 0.5},
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			m := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, m)
+		}
+	})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -18,7 +18,6 @@ import (
 	"sync"
 
 	"github.com/go-kit/log"
-	"github.com/prometheus-community/postgres_exporter/config"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -27,9 +26,36 @@ type ProbeCollector struct {
 	collectors map[string]Collector
 	logger     log.Logger
 	instance   *instance
+	// ctx bounds the collection, e.g. by the timeout of the probe.
+	ctx context.Context
 }
 
-func NewProbeCollector(logger log.Logger, excludeDatabases []string, registry *prometheus.Registry, dsn config.DSN) (*ProbeCollector, error) {
+// NewProbeCollector creates a ProbeCollector for the server at dsn. ctx
+// bounds connecting to the server. collectorLabels are static labels added to
+// the metrics of the collector of the same name.
+func NewProbeCollector(ctx context.Context, logger log.Logger, excludeDatabases []string, registry *prometheus.Registry, dsn string, collectorLabels map[string]map[string]string) (*ProbeCollector, error) {
+	collectors, err := newProbeCollectors(logger, excludeDatabases, collectorLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := newInstance(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProbeCollector{
+		registry:   registry,
+		collectors: collectors,
+		logger:     logger,
+		instance:   instance,
+		ctx:        context.Background(),
+	}, nil
+}
+
+// newProbeCollectors returns the enabled collectors, wrapped like those of
+// NewPostgresCollector.
+func newProbeCollectors(logger log.Logger, excludeDatabases []string, collectorLabels map[string]map[string]string) (map[string]Collector, error) {
 	collectors := make(map[string]Collector)
 	initiatedCollectorsMtx.Lock()
 	defer initiatedCollectorsMtx.Unlock()
@@ -48,6 +74,8 @@ func NewProbeCollector(logger log.Logger, excludeDatabases []string, registry *p
 				collectorConfig{
 					logger:           log.With(logger, "collector", key),
 					excludeDatabases: excludeDatabases,
+					omitNull:         !*emitNullAsZero,
+					failFast:         *failFast,
 				})
 			if err != nil {
 				return nil, err
@@ -57,17 +85,21 @@ func NewProbeCollector(logger log.Logger, excludeDatabases []string, registry *p
 		}
 	}
 
-	instance, err := newInstance(dsn.GetConnectionString())
-	if err != nil {
+	if *metricsVerboseHelp {
+		verboseHelpCollectors(collectors)
+	}
+	if err := labelCollectors(collectors, collectorLabels); err != nil {
 		return nil, err
 	}
+	return collectors, nil
+}
 
-	return &ProbeCollector{
-		registry:   registry,
-		collectors: collectors,
-		logger:     logger,
-		instance:   instance,
-	}, nil
+// WithContext returns a copy of the ProbeCollector whose collection is bound
+// by ctx. The copy shares the database connection.
+func (pc *ProbeCollector) WithContext(ctx context.Context) *ProbeCollector {
+	c := *pc
+	c.ctx = ctx
+	return &c
 }
 
 func (pc *ProbeCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -78,7 +110,7 @@ func (pc *ProbeCollector) Collect(ch chan<- prometheus.Metric) {
 	wg.Add(len(pc.collectors))
 	for name, c := range pc.collectors {
 		go func(name string, c Collector) {
-			execute(context.TODO(), name, c, pc.instance, ch, pc.logger)
+			execute(pc.ctx, name, c, pc.instance, ch, ch, pc.logger)
 			wg.Done()
 		}(name, c)
 	}
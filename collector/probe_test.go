@@ -0,0 +1,81 @@
+// Copyright 2023 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewProbeCollectorsVerboseHelp(t *testing.T) {
+	// Only the database_tuples collector is enabled.
+	states := make(map[string]bool)
+	for name, enabled := range collectorState {
+		states[name] = *enabled
+		*enabled = name == "database_tuples"
+	}
+	verbose := *metricsVerboseHelp
+	*metricsVerboseHelp = true
+	defer func() {
+		for name, enabled := range states {
+			*collectorState[name] = enabled
+		}
+		*metricsVerboseHelp = verbose
+		initiatedCollectorsMtx.Lock()
+		delete(initiatedCollectors, "database_tuples")
+		initiatedCollectorsMtx.Unlock()
+	}()
+
+	collectors, err := newProbeCollectors(log.NewNopLogger(), nil, nil)
+	if err != nil {
+		t.Fatalf("Error creating the probe collectors: %s", err)
+	}
+	if len(collectors) != 1 {
+		t.Fatalf("got %d collectors, want 1", len(collectors))
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error opening a stub db connection: %s", err)
+	}
+	defer db.Close()
+
+	inst := &instance{db: db}
+
+	columns := []string{"datname", "tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", 10000, 8000, 300, 200, 100)
+	mock.ExpectQuery(sanitizeQuery(pgDatabaseTuplesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err := collectors["database_tuples"].Update(context.Background(), inst, ch); err != nil {
+			t.Errorf("Error calling Update: %s", err)
+		}
+	}()
+
+	for m := range ch {
+		if desc := m.Desc().String(); !strings.Contains(desc, "(source: pg_stat_database.") {
+			t.Errorf("probe metric has no verbose help: %s", desc)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -16,11 +16,14 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus-community/postgres_exporter/collector"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v3"
 )
 
@@ -39,7 +42,37 @@ var (
 )
 
 type Config struct {
-	AuthModules map[string]AuthModule `yaml:"auth_modules"`
+	AuthModules map[string]AuthModule      `yaml:"auth_modules"`
+	Collectors  map[string]CollectorConfig `yaml:"collectors"`
+}
+
+// CollectorConfig configures the collector of the same name.
+type CollectorConfig struct {
+	// Labels are static labels added to the metrics of the collector only.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// CollectorLabels returns the static labels of the collectors which have any,
+// keyed by collector name.
+func (c *Config) CollectorLabels() map[string]map[string]string {
+	labels := make(map[string]map[string]string)
+	for name, collector := range c.Collectors {
+		if len(collector.Labels) > 0 {
+			labels[name] = collector.Labels
+		}
+	}
+	return labels
+}
+
+func (c *Config) validate() error {
+	for name, collector := range c.Collectors {
+		for label := range collector.Labels {
+			if !model.LabelName(label).IsValid() || strings.HasPrefix(label, model.ReservedLabelPrefix) {
+				return fmt.Errorf("invalid label name %q for collector %s", label, name)
+			}
+		}
+	}
+	return collector.ValidateCollectorLabels(c.CollectorLabels())
 }
 
 type AuthModule struct {
@@ -88,6 +121,9 @@ func (ch *Handler) ReloadConfig(f string, logger log.Logger) error {
 	if err = decoder.Decode(config); err != nil {
 		return fmt.Errorf("Error parsing config file %q: %s", f, err)
 	}
+	if err = config.validate(); err != nil {
+		return fmt.Errorf("Error parsing config file %q: %s", f, err)
+	}
 
 	ch.Lock()
 	ch.Config = config
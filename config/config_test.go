@@ -26,6 +26,11 @@ func TestLoadConfig(t *testing.T) {
 	if err != nil {
 		t.Errorf("Error loading config: %s", err)
 	}
+
+	labels := ch.GetConfig().CollectorLabels()
+	if got := labels["replication"]["role"]; got != "primary" {
+		t.Errorf("CollectorLabels()[replication][role] = %q, want primary", got)
+	}
 }
 
 func TestLoadBadConfigs(t *testing.T) {
@@ -45,6 +50,18 @@ func TestLoadBadConfigs(t *testing.T) {
 			input: "testdata/config-bad-extra-field.yaml",
 			want:  "Error parsing config file \"testdata/config-bad-extra-field.yaml\": yaml: unmarshal errors:\n  line 8: field doesNotExist not found in type config.AuthModule",
 		},
+		{
+			input: "testdata/config-bad-collector-label.yaml",
+			want:  "Error parsing config file \"testdata/config-bad-collector-label.yaml\": invalid label name \"role-name\" for collector replication",
+		},
+		{
+			input: "testdata/config-bad-collector-label-collision.yaml",
+			want:  "Error parsing config file \"testdata/config-bad-collector-label-collision.yaml\": configured label \"datname\" collides with a label of the database collector",
+		},
+		{
+			input: "testdata/config-bad-collector-missing.yaml",
+			want:  "Error parsing config file \"testdata/config-bad-collector-missing.yaml\": labels for missing collector: replication_lag",
+		},
 	}
 
 	for _, test := range tests {